@@ -1,17 +1,70 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
 	"github.com/wiliamhw/simplebank/token"
 )
 
+// Balance is the ledger balance, the money actually booked on the account.
+// AvailableBalance is what can still be spent once overdraft and holds are applied.
+type accountResponse struct {
+	ID               int64      `json:"id"`
+	Owner            string     `json:"owner"`
+	Balance          moneyField `json:"balance"`
+	Currency         string     `json:"currency"`
+	CreatedAt        time.Time  `json:"created_at"`
+	OverdraftLimit   moneyField `json:"overdraft_limit"`
+	HeldBalance      moneyField `json:"held_balance"`
+	Status           string     `json:"status"`
+	AvailableBalance moneyField `json:"available_balance"`
+}
+
+func newAccountResponse(account db.Account, minorUnits bool) accountResponse {
+	return accountResponse{
+		ID:               account.ID,
+		Owner:            account.Owner,
+		Balance:          newMoneyField(account.Balance, account.Currency, minorUnits),
+		Currency:         account.Currency,
+		CreatedAt:        account.CreatedAt,
+		OverdraftLimit:   newMoneyField(account.OverdraftLimit, account.Currency, minorUnits),
+		HeldBalance:      newMoneyField(account.HeldBalance, account.Currency, minorUnits),
+		Status:           account.Status,
+		AvailableBalance: newMoneyField(account.AvailableBalance(), account.Currency, minorUnits),
+	}
+}
+
+type entryResponse struct {
+	ID         int64      `json:"id"`
+	AccountID  int64      `json:"account_id"`
+	Amount     moneyField `json:"amount"`
+	CreatedAt  time.Time  `json:"created_at"`
+	TransferID *int64     `json:"transfer_id"`
+}
+
+func newEntryResponse(entry db.Entry, currency string, minorUnits bool) entryResponse {
+	return entryResponse{
+		ID:         entry.ID,
+		AccountID:  entry.AccountID,
+		Amount:     newMoneyField(entry.Amount, currency, minorUnits),
+		CreatedAt:  entry.CreatedAt,
+		TransferID: nullInt64(entry.TransferID),
+	}
+}
+
+type statementEntryResponse struct {
+	entryResponse
+	RunningBalance moneyField `json:"running_balance"`
+}
+
 type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,numeric,min=1"`
 }
@@ -19,28 +72,134 @@ type getAccountRequest struct {
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if account.Owner != authPayload.Username {
 		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account, minorUnits(ctx)))
+}
+
+// Gets any account, whoever owns it.
+func (server *Server) adminGetAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	account, valid := server.anyAccount(ctx, req.ID)
+	if !valid {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account, minorUnits(ctx)))
+}
+
+type listAccountEntriesRequest struct {
+	uri   listAccountEntriesRequestUri
+	query listAccountEntriesRequestQuery
+}
+
+type listAccountEntriesRequestUri struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+type listAccountEntriesRequestQuery struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor   int64     `form:"cursor" binding:"numeric,min=0"`
+	PageSize int32     `form:"page_size" binding:"required,numeric,min=5,max=100"`
+}
+
+type listAccountEntriesResponse struct {
+	AccountID      int64                    `json:"account_id"`
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	OpeningBalance moneyField               `json:"opening_balance"`
+	ClosingBalance moneyField               `json:"closing_balance"`
+	Entries        []statementEntryResponse `json:"entries"`
+	NextCursor     int64                    `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var req listAccountEntriesRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req.query); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if req.query.To.IsZero() {
+		req.query.To = time.Now()
+	}
+	if !req.query.From.Before(req.query.To) {
+		err := errors.New("from must be before to")
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, req.uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	arg := db.StatementTxParams{
+		AccountID: account.ID,
+		From:      req.query.From,
+		To:        req.query.To,
+		AfterID:   req.query.Cursor,
+		PageSize:  req.query.PageSize,
+	}
+
+	statement, err := server.store.StatementTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	inMinorUnits := minorUnits(ctx)
+	rsp := listAccountEntriesResponse{
+		AccountID:      account.ID,
+		From:           arg.From,
+		To:             arg.To,
+		OpeningBalance: newMoneyField(statement.OpeningBalance, account.Currency, inMinorUnits),
+		ClosingBalance: newMoneyField(statement.ClosingBalance, account.Currency, inMinorUnits),
+		Entries:        make([]statementEntryResponse, len(statement.Entries)),
+	}
+	for i, entry := range statement.Entries {
+		rsp.Entries[i] = statementEntryResponse{
+			entryResponse:  newEntryResponse(entry.Entry, account.Currency, inMinorUnits),
+			RunningBalance: newMoneyField(entry.RunningBalance, account.Currency, inMinorUnits),
+		}
+	}
+	if len(statement.Entries) == int(arg.PageSize) {
+		rsp.NextCursor = statement.Entries[len(statement.Entries)-1].ID
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type listAccountRequest struct {
@@ -51,14 +210,14 @@ type listAccountRequest struct {
 func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if _, err := server.store.GetUser(ctx, authPayload.Username); err != nil {
 		err = fmt.Errorf("user with %v as username doesn't exists", authPayload.Username)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
@@ -70,10 +229,15 @@ func (server *Server) listAccount(ctx *gin.Context) {
 
 	accounts, err := server.store.ListAccounts(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
-	ctx.JSON(http.StatusOK, accounts)
+
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account, minorUnits(ctx))
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type createAccountRequest struct {
@@ -83,14 +247,14 @@ type createAccountRequest struct {
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if _, err := server.store.GetUser(ctx, authPayload.Username); err != nil {
 		err = fmt.Errorf("user with %v as username doesn't exists", authPayload.Username)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
@@ -100,44 +264,48 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		Balance:  0,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		fmt.Printf("%v\n", err)
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account, minorUnits(ctx)))
 }
 
-type updateAccountRequest struct {
-	uri  updateAccountRequestUri
-	body updateAccountRequestForm
+type cashRequest struct {
+	uri  cashRequestUri
+	body cashRequestBody
 }
 
-type updateAccountRequestUri struct {
+type cashRequestUri struct {
 	ID int64 `uri:"id" binding:"required,numeric,min=1"`
 }
 
-type updateAccountRequestForm struct {
-	Amount int64 `form:"amount" binding:"required,numeric"`
+type cashRequestBody struct {
+	Amount requestAmount `json:"amount" binding:"required"`
 }
 
-func (server *Server) updateAccount(ctx *gin.Context) {
-	var req updateAccountRequest
+func (server *Server) depositAccount(ctx *gin.Context) {
+	server.postCash(ctx, server.store.DepositTx)
+}
+
+func (server *Server) withdrawAccount(ctx *gin.Context) {
+	server.postCash(ctx, server.store.WithdrawTx)
+}
+
+// Runs a deposit or withdrawal transaction on an account of the current user.
+func (server *Server) postCash(
+	ctx *gin.Context,
+	cashTx func(context.Context, db.CashTxParams) (db.CashTxResult, error),
+) {
+	var req cashRequest
 	if err := ctx.ShouldBindUri(&req.uri); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 	if err := ctx.ShouldBindJSON(&req.body); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
@@ -145,82 +313,267 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if _, err := server.store.GetUser(ctx, authPayload.Username); err != nil {
 		err = fmt.Errorf("user with %v as username doesn't exists", authPayload.Username)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
 	// Check if current account belongs to current user
 	account, err := server.store.GetAccount(ctx, req.uri.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 	if account.Owner != authPayload.Username {
 		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeNotOwner, err))
 		return
 	}
 
-	arg := db.AddAccountBalanceParams{
-		ID:     req.uri.ID,
-		Amount: req.body.Amount,
+	amount, err := parseAmount(ctx, req.body.Amount, account.Currency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
 	}
 
-	account, err = server.store.AddAccountBalance(ctx, arg)
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: req.uri.ID,
+		Amount:    amount.Amount(),
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newCashTxResponse(result, account.Currency, minorUnits(ctx)))
 }
 
-type deleteAccountRequest struct {
+type cashTxResponse struct {
+	Transfer transferResponse `json:"transfer"`
+	Account  accountResponse  `json:"account"`
+	Entry    entryResponse    `json:"entry"`
+}
+
+func newCashTxResponse(result db.CashTxResult, currency string, minorUnits bool) cashTxResponse {
+	return cashTxResponse{
+		Transfer: newTransferResponse(result.Transfer, currency, minorUnits),
+		Account:  newAccountResponse(result.Account, minorUnits),
+		Entry:    newEntryResponse(result.Entry, currency, minorUnits),
+	}
+}
+
+type accountStatusRequest struct {
 	ID int64 `uri:"id" binding:"required,numeric,min=1"`
 }
 
-func (server *Server) deleteAccount(ctx *gin.Context) {
-	var req deleteAccountRequest
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, server.ownedAccount, db.AccountStatusFrozen, db.AccountStatusActive)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, server.selfFrozenAccount, db.AccountStatusActive, db.AccountStatusFrozen)
+}
+
+// An operator can also freeze an account the owner froze,
+// which takes the unfreezing out of the owner's hands.
+func (server *Server) adminFreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, server.anyAccount, db.AccountStatusFrozen,
+		db.AccountStatusActive, db.AccountStatusFrozen,
+	)
+}
+
+func (server *Server) adminUnfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, server.anyAccount, db.AccountStatusActive, db.AccountStatusFrozen)
+}
+
+/**
+ * Moves an account to a status from one of the from statuses.
+ * The account is looked up with lookup, which also checks who may change it.
+ * Freezing records the current user, so an operator's freeze can't be
+ * undone by the owner.
+ */
+func (server *Server) changeAccountStatus(
+	ctx *gin.Context,
+	lookup func(ctx *gin.Context, accountID int64) (db.Account, bool),
+	to string,
+	from ...string,
+) {
+	var req accountStatusRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
-	// Check if user exists
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if _, err := server.store.GetUser(ctx, authPayload.Username); err != nil {
-		err = fmt.Errorf("user with %v as username doesn't exists", authPayload.Username)
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	account, valid := lookup(ctx, req.ID)
+	if !valid {
+		return
+	}
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || account.Status == status
+	}
+	if !allowed {
+		err := fmt.Errorf("account [%d] is %s, expected %s", account.ID, account.Status, strings.Join(from, " or "))
+		ctx.Error(newAPIError(codeConflict, err))
 		return
 	}
 
-	// Check if current account belongs to current user
-	account, err := server.store.GetAccount(ctx, req.ID)
+	// Matching who froze it too keeps an owner who read the account before
+	// an operator's freeze from undoing it.
+	arg := db.UpdateAccountStatusParams{
+		ID:           account.ID,
+		Status:       to,
+		FromStatus:   account.Status,
+		FromFrozenBy: account.FrozenBy,
+	}
+	if to == db.AccountStatusFrozen {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		arg.FrozenBy = sql.NullString{String: authPayload.Username, Valid: true}
+	}
+
+	account, err := server.store.UpdateAccountStatus(ctx, arg)
 	if err != nil {
+		// The status was changed by a concurrent request.
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			err = fmt.Errorf("account [%d] was changed by another request", req.ID)
+			ctx.Error(newAPIError(codeConflict, err))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account, minorUnits(ctx)))
+}
+
+type closeAccountRequest struct {
+	uri  closeAccountRequestUri
+	body closeAccountRequestBody
+}
+
+type closeAccountRequestUri struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+type closeAccountRequestBody struct {
+	// Optional, required if the account still holds money.
+	SweepToAccountID int64 `json:"sweep_to_account_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var req closeAccountRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
 		return
 	}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req.body); err != nil {
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+	}
+	if req.body.SweepToAccountID == req.uri.ID {
+		err := errors.New("cannot sweep an account into itself")
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, req.uri.ID)
+	if !valid {
+		return
+	}
+
+	// The remaining balance can only go to another account of the same user.
+	if req.body.SweepToAccountID > 0 {
+		sweepAccount, valid := server.ownedAccount(ctx, req.body.SweepToAccountID)
+		if !valid {
+			return
+		}
+		if sweepAccount.Currency != account.Currency {
+			err := fmt.Errorf("account [%d] currency mismatch: %s vs %s",
+				sweepAccount.ID, sweepAccount.Currency, account.Currency,
+			)
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
+	result, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:        account.ID,
+		SweepToAccountID: req.body.SweepToAccountID,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		// Closing an account that is already closed conflicts with the earlier request.
+		if errors.Is(err, db.ErrAccountNotActive) {
+			err = newAPIError(codeConflict, err)
+		}
+		ctx.Error(err)
 		return
 	}
-	ctx.JSON(
-		http.StatusOK,
-		fmt.Sprintf("account with id %v has been deleted", req.ID),
-	)
+
+	ctx.JSON(http.StatusOK, newCloseAccountTxResponse(result, account.Currency, minorUnits(ctx)))
+}
+
+type closeAccountTxResponse struct {
+	Account accountResponse     `json:"account"`
+	Sweep   *transferTxResponse `json:"sweep,omitempty"`
+}
+
+func newCloseAccountTxResponse(
+	result db.CloseAccountTxResult,
+	currency string,
+	minorUnits bool,
+) closeAccountTxResponse {
+	rsp := closeAccountTxResponse{
+		Account: newAccountResponse(result.Account, minorUnits),
+	}
+	if result.Sweep != nil {
+		sweep := newTransferTxResponse(*result.Sweep, currency, minorUnits)
+		rsp.Sweep = &sweep
+	}
+	return rsp
+}
+
+// Loads an account and checks that it belongs to the current user.
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		ctx.Error(err)
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := fmt.Errorf("account [%d] doesn't belong to the authenticated user", account.ID)
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return account, false
+	}
+	return account, true
+}
+
+/**
+ * Loads an account of the current user that the user froze themselves.
+ * Accounts frozen by an operator can only be unfrozen through /admin.
+ */
+func (server *Server) selfFrozenAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, valid := server.ownedAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	if account.FrozenBy.Valid && account.FrozenBy.String != account.Owner {
+		err := fmt.Errorf("account [%d] was frozen by an operator", account.ID)
+		ctx.Error(newAPIError(codeForbidden, err))
+		return account, false
+	}
+	return account, true
+}
+
+// Gets an account whoever owns it, for the /admin endpoints.
+func (server *Server) anyAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		ctx.Error(err)
+		return account, false
+	}
+	return account, true
 }
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	// Name of the events of the stream, one per entry of the account.
+	accountEntryEvent = "entry"
+	// Entries read per query when catching up.
+	accountEventBatchSize = 100
+	// Comments are sent at least this often, so proxies keep the stream open.
+	accountEventKeepAlive = 15 * time.Second
+)
+
+// An entry of the account, with the balance it left and its transfer.
+type accountEventResponse struct {
+	statementEntryResponse
+	Transfer *transferResponse `json:"transfer,omitempty"`
+}
+
+type streamAccountEventsRequest struct {
+	uri   streamAccountEventsRequestUri
+	query streamAccountEventsRequestQuery
+}
+
+type streamAccountEventsRequestUri struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+// For the first connection, as browsers only send Last-Event-ID on reconnects.
+type streamAccountEventsRequestQuery struct {
+	LastEventID *int64 `form:"last_event_id" binding:"omitempty,min=0"`
+}
+
+/**
+ * Streams the entries of an account as server-sent events, as they commit.
+ * The id of each event is the id of its entry. Entries after the
+ * Last-Event-ID header, or the last_event_id query parameter, are sent first.
+ * Without either, only entries committed after the stream opens are sent.
+ * The stream ends when the access token expires.
+ *
+ * Commits are notified through the database, so the stream sees entries
+ * written by any replica. Entries of an account are written while it is
+ * locked, so they commit in id order and none is skipped on resume.
+ */
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var req streamAccountEventsRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req.query); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if header := ctx.GetHeader(lastEventIDHeader); header != "" {
+		lastEventID, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || lastEventID < 0 {
+			err := errors.New("last event id must be an entry id")
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+		req.query.LastEventID = &lastEventID
+	}
+
+	account, valid := server.ownedAccount(ctx, req.uri.ID)
+	if !valid {
+		return
+	}
+
+	// Subscribe before reading, so no commit falls in between.
+	wakeUps, unsubscribe := server.accountEvents.Subscribe(account.ID)
+	defer unsubscribe()
+
+	var lastID int64
+	if req.query.LastEventID != nil {
+		lastID = *req.query.LastEventID
+	} else {
+		var err error
+		lastID, err = server.store.GetLastAccountEntryID(ctx, account.ID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(accountEventKeepAlive)
+	defer keepAlive.Stop()
+
+	// The token was only checked when the stream opened. Once it expires the
+	// client has to reconnect with a fresh one, resuming from its last event.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	expiry := time.NewTimer(time.Until(authPayload.ExpiredAt))
+	defer expiry.Stop()
+
+	for {
+		var err error
+		lastID, err = server.sendAccountEvents(ctx, account, lastID)
+		if err != nil {
+			// The client resumes from the last event it got.
+			ctx.Error(err)
+			return
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expiry.C:
+			return
+		case <-wakeUps:
+		case <-keepAlive.C:
+			ctx.Writer.WriteString(":keep-alive\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// Sends the entries of the account after lastID and returns the id of the last one sent.
+func (server *Server) sendAccountEvents(ctx *gin.Context, account db.Account, lastID int64) (int64, error) {
+	inMinorUnits := minorUnits(ctx)
+	for {
+		entries, err := server.store.ListAccountEntriesAfter(ctx, db.ListAccountEntriesAfterParams{
+			AccountID: account.ID,
+			AfterID:   lastID,
+			PageSize:  accountEventBatchSize,
+		})
+		if err != nil {
+			return lastID, err
+		}
+
+		for _, entry := range entries {
+			rsp := accountEventResponse{
+				statementEntryResponse: statementEntryResponse{
+					entryResponse: newEntryResponse(db.Entry{
+						ID:         entry.ID,
+						AccountID:  entry.AccountID,
+						Amount:     entry.Amount,
+						CreatedAt:  entry.CreatedAt,
+						TransferID: entry.TransferID,
+					}, account.Currency, inMinorUnits),
+					RunningBalance: newMoneyField(entry.RunningBalance, account.Currency, inMinorUnits),
+				},
+			}
+			if entry.TransferID.Valid {
+				transfer, err := server.store.GetTransfer(ctx, entry.TransferID.Int64)
+				if err != nil {
+					return lastID, err
+				}
+				transferRsp := newTransferResponse(transfer, account.Currency, inMinorUnits)
+				rsp.Transfer = &transferRsp
+			}
+
+			ctx.Render(-1, sse.Event{
+				Id:    strconv.FormatInt(entry.ID, 10),
+				Event: accountEntryEvent,
+				Data:  rsp,
+			})
+			lastID = entry.ID
+		}
+		ctx.Writer.Flush()
+
+		if len(entries) < accountEventBatchSize {
+			return lastID, nil
+		}
+	}
+}
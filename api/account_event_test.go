@@ -0,0 +1,493 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// How long a stream stays open in the tests, unless it fails first.
+const testStreamDuration = 100 * time.Millisecond
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Parses the events of a stream, skipping comments.
+func parseSSEvents(t *testing.T, body string) []sseEvent {
+	var events []sseEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "id:"):
+				event.ID = strings.TrimPrefix(line, "id:")
+			case strings.HasPrefix(line, "event:"):
+				event.Event = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				event.Data = strings.TrimPrefix(line, "data:")
+			}
+		}
+		if event.ID != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+type accountEventData struct {
+	ID             int64  `json:"id"`
+	Amount         string `json:"amount"`
+	RunningBalance string `json:"running_balance"`
+	Transfer       *struct {
+		ID     int64  `json:"id"`
+		Amount string `json:"amount"`
+	} `json:"transfer"`
+}
+
+// Checks that an event is the one of an entry.
+func requireEntryEvent(t *testing.T, event sseEvent, entry db.ListAccountEntriesAfterRow, currency string) {
+	require.Equal(t, fmt.Sprint(entry.ID), event.ID)
+	require.Equal(t, accountEntryEvent, event.Event)
+
+	var data accountEventData
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &data))
+	require.Equal(t, entry.ID, data.ID)
+	require.Equal(t, money.New(entry.Amount, currency).String(), data.Amount)
+	require.Equal(t, money.New(entry.RunningBalance, currency).String(), data.RunningBalance)
+	if entry.TransferID.Valid {
+		require.NotNil(t, data.Transfer)
+		require.Equal(t, entry.TransferID.Int64, data.Transfer.ID)
+	} else {
+		require.Nil(t, data.Transfer)
+	}
+}
+
+func randomAccountEntries(account db.Account, afterID int64, n int) []db.ListAccountEntriesAfterRow {
+	entries := make([]db.ListAccountEntriesAfterRow, n)
+	balance := account.Balance
+	for i := range entries {
+		amount := util.RandomInt(-100, 100)
+		balance += amount
+		entries[i] = db.ListAccountEntriesAfterRow{
+			ID:             afterID + int64(i) + 1,
+			AccountID:      account.ID,
+			Amount:         amount,
+			TransferID:     sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+			RunningBalance: balance,
+		}
+	}
+	return entries
+}
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	entries := randomAccountEntries(account, 5, 2)
+	entries[1].TransferID = sql.NullInt64{}
+
+	testCases := []struct {
+		base        baseTestCase
+		accountId   int64
+		lastEventID string
+		query       url.Values
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						GetLastAccountEntryID(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					arg := db.ListAccountEntriesAfterParams{
+						AccountID: account.ID,
+						AfterID:   5,
+						PageSize:  accountEventBatchSize,
+					}
+					store.EXPECT().
+						ListAccountEntriesAfter(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(entries, nil)
+
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(entries[0].TransferID.Int64)).
+						Times(1).
+						Return(db.Transfer{
+							ID:            entries[0].TransferID.Int64,
+							FromAccountID: account.ID,
+							ToAccountID:   account.ID + 1,
+							Amount:        -entries[0].Amount,
+						}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+					events := parseSSEvents(t, recorder.Body.String())
+					require.Len(t, events, len(entries))
+					for i, entry := range entries {
+						requireEntryEvent(t, events[i], entry, account.Currency)
+					}
+				},
+			},
+			accountId:   account.ID,
+			lastEventID: "5",
+		},
+		{
+			base: baseTestCase{
+				name: "LastEventIDQuery",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					arg := db.ListAccountEntriesAfterParams{
+						AccountID: account.ID,
+						AfterID:   0,
+						PageSize:  accountEventBatchSize,
+					}
+					store.EXPECT().
+						ListAccountEntriesAfter(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return([]db.ListAccountEntriesAfterRow{}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Empty(t, parseSSEvents(t, recorder.Body.String()))
+				},
+			},
+			accountId: account.ID,
+			query:     url.Values{"last_event_id": {"0"}},
+		},
+		{
+			base: baseTestCase{
+				name: "NoLastEventID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						GetLastAccountEntryID(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(int64(7), nil)
+
+					arg := db.ListAccountEntriesAfterParams{
+						AccountID: account.ID,
+						AfterID:   7,
+						PageSize:  accountEventBatchSize,
+					}
+					store.EXPECT().
+						ListAccountEntriesAfter(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return([]db.ListAccountEntriesAfterRow{}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Empty(t, parseSSEvents(t, recorder.Body.String()))
+				},
+			},
+			accountId: account.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidLastEventID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			accountId:   account.ID,
+			lastEventID: "abc",
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						ListAccountEntriesAfter(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			accountId:   account.ID,
+			lastEventID: "5",
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(db.Account{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						ListAccountEntriesAfter(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			accountId: 0,
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						GetLastAccountEntryID(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(int64(0), sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(testStreamDuration, cancel)
+
+			url := fmt.Sprintf("%v/%v/events?%v", accountURI, tc.accountId, tc.query.Encode())
+			request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			if tc.lastEventID != "" {
+				request.Header.Set(lastEventIDHeader, tc.lastEventID)
+			}
+			return request, nil
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+// Reads the next event of a stream, skipping comments.
+func readSSEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var block strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			events := parseSSEvents(t, block.String())
+			if len(events) > 0 {
+				return events[0]
+			}
+			block.Reset()
+			continue
+		}
+		block.WriteString(line)
+	}
+}
+
+func TestStreamAccountEventsLive(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	entries := randomAccountEntries(account, 7, 1)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+			Times(1).
+			Return(account, nil),
+		store.EXPECT().
+			GetLastAccountEntryID(gomock.Any(), gomock.Eq(account.ID)).
+			Times(1).
+			Return(int64(7), nil),
+		store.EXPECT().
+			ListAccountEntriesAfter(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return([]db.ListAccountEntriesAfterRow{}, nil),
+		// Committed by another replica.
+		store.EXPECT().
+			ListAccountEntriesAfter(gomock.Any(), gomock.Eq(db.ListAccountEntriesAfterParams{
+				AccountID: account.ID,
+				AfterID:   7,
+				PageSize:  accountEventBatchSize,
+			})).
+			Times(1).
+			Return(entries, nil),
+		store.EXPECT().
+			GetTransfer(gomock.Any(), gomock.Eq(entries[0].TransferID.Int64)).
+			Times(1).
+			Return(db.Transfer{ID: entries[0].TransferID.Int64}, nil),
+	)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	url := fmt.Sprintf("%v%v/%v/events", httpServer.URL, accountURI, account.ID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+	// The stream is subscribed once its headers are sent.
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	server.accountEvents.Publish(account.ID)
+
+	event := readSSEvent(t, bufio.NewReader(response.Body))
+	requireEntryEvent(t, event, entries[0], account.Currency)
+}
+
+func TestStreamAccountEventsTokenExpiry(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		GetLastAccountEntryID(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(int64(7), nil)
+	store.EXPECT().
+		ListAccountEntriesAfter(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListAccountEntriesAfterRow{}, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	// Fails the test instead of hanging if the stream stays open.
+	ctx, cancel := context.WithTimeout(context.Background(), accountEventKeepAlive)
+	defer cancel()
+
+	url := fmt.Sprintf("%v%v/%v/events", httpServer.URL, accountURI, account.ID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, 200*time.Millisecond)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// The server ends the stream once the token expires.
+	_, err = io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err())
+}
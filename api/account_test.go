@@ -2,24 +2,28 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/wiliamhw/simplebank/db/mock"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
 	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
-const accountURI = "/accounts"
+const accountURI = "/v1/accounts"
 
 func randomAccount(owner string) db.Account {
 	return db.Account{
@@ -27,6 +31,7 @@ func randomAccount(owner string) db.Account {
 		Owner:    owner,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		Status:   db.AccountStatusActive,
 	}
 }
 
@@ -162,6 +167,252 @@ func TestGetAccountAPI(t *testing.T) {
 	}
 }
 
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	pageSize := int32(5)
+
+	entries := make([]db.StatementEntry, pageSize)
+	balance := util.RandomMoney()
+	for i := range entries {
+		amount := util.RandomInt(-100, 100)
+		balance += amount
+		entries[i] = db.StatementEntry{
+			Entry: db.Entry{
+				ID:        int64(i + 1),
+				AccountID: account.ID,
+				Amount:    amount,
+			},
+			RunningBalance: balance,
+		}
+	}
+	statement := db.StatementTxResult{
+		OpeningBalance: entries[0].RunningBalance - entries[0].Amount,
+		ClosingBalance: balance,
+		Entries:        entries,
+	}
+
+	defaultQuery := url.Values{
+		"from":      {from.Format(time.RFC3339)},
+		"to":        {to.Format(time.RFC3339)},
+		"page_size": {fmt.Sprint(pageSize)},
+	}
+
+	testCases := []struct {
+		base      baseTestCase
+		accountId int64
+		query     url.Values
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					arg := db.StatementTxParams{
+						AccountID: account.ID,
+						From:      from,
+						To:        to,
+						AfterID:   0,
+						PageSize:  pageSize,
+					}
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(statement, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp struct {
+						AccountID      int64  `json:"account_id"`
+						OpeningBalance string `json:"opening_balance"`
+						ClosingBalance string `json:"closing_balance"`
+						Entries        []struct {
+							Amount         string `json:"amount"`
+							RunningBalance string `json:"running_balance"`
+						} `json:"entries"`
+						NextCursor int64 `json:"next_cursor"`
+					}
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, account.ID, rsp.AccountID)
+					require.Equal(t, money.New(statement.OpeningBalance, account.Currency).String(), rsp.OpeningBalance)
+					require.Equal(t, money.New(statement.ClosingBalance, account.Currency).String(), rsp.ClosingBalance)
+					require.Len(t, rsp.Entries, len(entries))
+					for i, entry := range entries {
+						require.Equal(t, money.New(entry.Amount, account.Currency).String(), rsp.Entries[i].Amount)
+						require.Equal(t, money.New(entry.RunningBalance, account.Currency).String(), rsp.Entries[i].RunningBalance)
+					}
+					require.Equal(t, entries[len(entries)-1].ID, rsp.NextCursor)
+				},
+			},
+			accountId: account.ID,
+			query:     defaultQuery,
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query:     defaultQuery,
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query:     defaultQuery,
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(db.Account{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query:     defaultQuery,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidRange",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query: url.Values{
+				"from":      {to.Format(time.RFC3339)},
+				"to":        {from.Format(time.RFC3339)},
+				"page_size": {fmt.Sprint(pageSize)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidPageSize",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query: url.Values{
+				"page_size": {"1000"},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						StatementTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.StatementTxResult{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			accountId: account.ID,
+			query:     defaultQuery,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/entries?%v", accountURI, tc.accountId, tc.query.Encode())
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
 func TestListAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 
@@ -357,7 +608,7 @@ func TestCreateAccountAPI(t *testing.T) {
 						Return(user, nil)
 
 					store.EXPECT().
-						CreateAccount(gomock.Any(), gomock.Eq(createAccountParams)).
+						CreateAccountTx(gomock.Any(), gomock.Eq(createAccountParams)).
 						Times(1).
 						Return(account, nil)
 				},
@@ -381,7 +632,7 @@ func TestCreateAccountAPI(t *testing.T) {
 						Return(db.User{}, sql.ErrConnDone)
 
 					store.EXPECT().
-						CreateAccount(gomock.Any(), gomock.Any()).
+						CreateAccountTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -401,7 +652,7 @@ func TestCreateAccountAPI(t *testing.T) {
 						Times(0)
 
 					store.EXPECT().
-						CreateAccount(gomock.Any(), gomock.Any()).
+						CreateAccountTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -422,7 +673,7 @@ func TestCreateAccountAPI(t *testing.T) {
 						Times(0)
 
 					store.EXPECT().
-						CreateAccount(gomock.Any(), gomock.Any()).
+						CreateAccountTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -446,7 +697,7 @@ func TestCreateAccountAPI(t *testing.T) {
 						Return(user, nil)
 
 					store.EXPECT().
-						CreateAccount(gomock.Any(), gomock.Eq(createAccountParams)).
+						CreateAccountTx(gomock.Any(), gomock.Eq(createAccountParams)).
 						Times(1).
 						Return(db.Account{}, sql.ErrConnDone)
 				},
@@ -481,26 +732,49 @@ func TestCreateAccountAPI(t *testing.T) {
 	}
 }
 
-func TestUpdateAccountAPI(t *testing.T) {
+func TestDepositAccountAPI(t *testing.T) {
+	testCashAccountAPI(t, "deposits", func(store *mockdb.MockStore) *gomock.Call {
+		return store.EXPECT().DepositTx(gomock.Any(), gomock.Any())
+	})
+}
+
+func TestWithdrawAccountAPI(t *testing.T) {
+	testCashAccountAPI(t, "withdrawals", func(store *mockdb.MockStore) *gomock.Call {
+		return store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any())
+	})
+}
+
+// Runs the test cases shared by the deposit and withdrawal endpoints.
+func testCashAccountAPI(
+	t *testing.T,
+	path string,
+	expectCashTx func(store *mockdb.MockStore) *gomock.Call,
+) {
 	user, _ := randomUser(t)
 	account := randomAccount(user.Username)
 
-	amount := util.RandomInt(-100, 100)
-	updatedAccount := account
-	updatedAccount.Balance = account.Balance + amount
+	amount := util.RandomInt(1, 100)
+	result := db.CashTxResult{
+		Account: account,
+		Entry: db.Entry{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    amount,
+		},
+	}
 
-	defaultRequest := updateAccountRequest{
-		uri: updateAccountRequestUri{
+	defaultRequest := cashRequest{
+		uri: cashRequestUri{
 			ID: account.ID,
 		},
-		body: updateAccountRequestForm{
-			Amount: amount,
+		body: cashRequestBody{
+			Amount: decimalAmount(amount, account.Currency),
 		},
 	}
 
 	testCases := []struct {
 		base baseTestCase
-		req  updateAccountRequest
+		req  cashRequest
 	}{
 		{
 			base: baseTestCase{
@@ -509,10 +783,6 @@ func TestUpdateAccountAPI(t *testing.T) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
-					arg := db.AddAccountBalanceParams{
-						ID:     account.ID,
-						Amount: amount,
-					}
 					store.EXPECT().
 						GetUser(gomock.Any(), gomock.Eq(user.Username)).
 						Times(1).
@@ -523,14 +793,23 @@ func TestUpdateAccountAPI(t *testing.T) {
 						Times(1).
 						Return(account, nil)
 
-					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Eq(arg)).
+					arg := db.CashTxParams{
+						AccountID: account.ID,
+						Amount:    amount,
+					}
+					expectCashTx(store).
+						Do(func(_ context.Context, got db.CashTxParams) {
+							require.Equal(t, arg, got)
+						}).
 						Times(1).
-						Return(updatedAccount, nil)
+						Return(result, nil)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusOK, recorder.Code)
-					requireBodyMatchAccount(t, recorder.Body, updatedAccount)
+
+					want, err := json.Marshal(newCashTxResponse(result, account.Currency, false))
+					require.NoError(t, err)
+					require.JSONEq(t, string(want), recorder.Body.String())
 				},
 			},
 			req: defaultRequest,
@@ -551,9 +830,7 @@ func TestUpdateAccountAPI(t *testing.T) {
 						GetAccount(gomock.Any(), gomock.Any()).
 						Times(0)
 
-					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Any()).
-						Times(0)
+					expectCashTx(store).Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -563,21 +840,43 @@ func TestUpdateAccountAPI(t *testing.T) {
 		},
 		{
 			base: baseTestCase{
-				name: "NoAuthorization",
+				name: "AccountOfAnotherUser",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
+					otherAccount := account
+					otherAccount.Owner = util.RandomOwner()
+
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						Times(1).
+						Return(user, nil)
 
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(otherAccount, nil)
 
+					expectCashTx(store).Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			req: defaultRequest,
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Any()).
+						GetUser(gomock.Any(), gomock.Any()).
 						Times(0)
+
+					expectCashTx(store).Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -587,71 +886,90 @@ func TestUpdateAccountAPI(t *testing.T) {
 		},
 		{
 			base: baseTestCase{
-				name: "InvalidQuery",
+				name: "NotFound",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						Times(1).
+						Return(user, nil)
 
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(db.Account{}, sql.ErrNoRows)
 
+					expectCashTx(store).Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			req: defaultRequest,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Any()).
+						GetUser(gomock.Any(), gomock.Any()).
 						Times(0)
+
+					expectCashTx(store).Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusBadRequest, recorder.Code)
 				},
 			},
-			req: updateAccountRequest{
-				uri: updateAccountRequestUri{
+			req: cashRequest{
+				uri: cashRequestUri{
 					ID: -1,
 				},
-				body: updateAccountRequestForm{
-					Amount: amount,
+				body: cashRequestBody{
+					Amount: decimalAmount(amount, account.Currency),
 				},
 			},
 		},
 		{
 			base: baseTestCase{
-				name: "InvalidBody",
+				name: "NegativeAmount",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						Times(1).
+						Return(user, nil)
 
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
 
-					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Any()).
-						Times(0)
+					expectCashTx(store).Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusBadRequest, recorder.Code)
 				},
 			},
-			req: updateAccountRequest{
-				uri: updateAccountRequestUri{
+			req: cashRequest{
+				uri: cashRequestUri{
 					ID: account.ID,
 				},
-				body: updateAccountRequestForm{
-					Amount: 0,
+				body: cashRequestBody{
+					Amount: decimalAmount(-amount, account.Currency),
 				},
 			},
 		},
 		{
 			base: baseTestCase{
-				name: "InternalError",
+				name: "InsufficientFunds",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
@@ -666,18 +984,48 @@ func TestUpdateAccountAPI(t *testing.T) {
 						Times(1).
 						Return(account, nil)
 
-					store.EXPECT().
-						AddAccountBalance(gomock.Any(), gomock.Any()).
+					expectCashTx(store).
 						Times(1).
-						Return(db.Account{}, sql.ErrConnDone)
+						Return(db.CashTxResult{}, &db.InsufficientFundsError{
+							AccountID:        account.ID,
+							AvailableBalance: amount - 1,
+							Amount:           amount,
+						})
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 				},
 			},
 			req: defaultRequest,
 		},
-	}
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						Times(1).
+						Return(user, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					expectCashTx(store).
+						Times(1).
+						Return(db.CashTxResult{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			req: defaultRequest,
+		},
+	}
 
 	for i := range testCases {
 		tc := testCases[i]
@@ -689,9 +1037,9 @@ func TestUpdateAccountAPI(t *testing.T) {
 				return nil, err
 			}
 
-			url := fmt.Sprintf("%v/%v", accountURI, tc.req.uri.ID)
+			url := fmt.Sprintf("%v/%v/%v", accountURI, tc.req.uri.ID, path)
 			request, err := http.NewRequest(
-				http.MethodPatch, url, bytes.NewReader(data),
+				http.MethodPost, url, bytes.NewReader(data),
 			)
 			if err != nil {
 				return nil, err
@@ -703,198 +1051,740 @@ func TestUpdateAccountAPI(t *testing.T) {
 	}
 }
 
-func TestDeleteAccountAPI(t *testing.T) {
+func TestFreezeAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozenAccount := account
+	frozenAccount.Status = db.AccountStatusFrozen
+	frozenAccount.FrozenBy = sql.NullString{String: user.Username, Valid: true}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.UpdateAccountStatusParams{
+					ID:         account.ID,
+					Status:     db.AccountStatusFrozen,
+					FrozenBy:   sql.NullString{String: user.Username, Valid: true},
+					FromStatus: db.AccountStatusActive,
+				}
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(frozenAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, frozenAccount)
+			},
+		},
+		{
+			name: "AlreadyFrozen",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(frozenAccount, nil)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentChange",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/freeze", accountURI, account.ID)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestUnfreezeAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	account := randomAccount(user.Username)
 
+	frozenAccount := account
+	frozenAccount.Status = db.AccountStatusFrozen
+	frozenAccount.FrozenBy = sql.NullString{String: user.Username, Valid: true}
+
+	operatorFrozenAccount := frozenAccount
+	operatorFrozenAccount.FrozenBy = sql.NullString{String: testAdminUsername, Valid: true}
+
+	closedAccount := account
+	closedAccount.Status = db.AccountStatusClosed
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(frozenAccount, nil)
+
+				arg := db.UpdateAccountStatusParams{
+					ID:           account.ID,
+					Status:       db.AccountStatusActive,
+					FromStatus:   db.AccountStatusFrozen,
+					FromFrozenBy: frozenAccount.FrozenBy,
+				}
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "FrozenByOperator",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(operatorFrozenAccount, nil)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+		{
+			name: "Closed",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(closedAccount, nil)
+
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/unfreeze", accountURI, account.ID)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestAdminGetAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "Customer",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/accounts/%v", account.ID)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestAdminFreezeAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozenAccount := account
+	frozenAccount.Status = db.AccountStatusFrozen
+	frozenAccount.FrozenBy = sql.NullString{String: testAdminUsername, Valid: true}
+
+	ownerFrozenAccount := frozenAccount
+	ownerFrozenAccount.FrozenBy = sql.NullString{String: user.Username, Valid: true}
+
+	closedAccount := account
+	closedAccount.Status = db.AccountStatusClosed
+
 	testCases := []struct {
-		base      baseTestCase
-		accountId int64
+		base   baseTestCase
+		action string
 	}{
 		{
 			base: baseTestCase{
-				name: "OK",
+				name: "SupportFreezes",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
-					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Eq(user.Username)).
-						Times(1).
-						Return(user, nil)
-
 					store.EXPECT().
 						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 						Times(1).
 						Return(account, nil)
 
+					arg := db.UpdateAccountStatusParams{
+						ID:         account.ID,
+						Status:     db.AccountStatusFrozen,
+						FrozenBy:   sql.NullString{String: testAdminUsername, Valid: true},
+						FromStatus: db.AccountStatusActive,
+					}
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+						UpdateAccountStatus(gomock.Any(), gomock.Eq(arg)).
 						Times(1).
-						Return(nil)
+						Return(frozenAccount, nil)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchAccount(t, recorder.Body, frozenAccount)
 				},
 			},
-			accountId: account.ID,
+			action: "freeze",
 		},
 		{
 			base: baseTestCase{
-				name: "UnauthorizedUser",
+				name: "SupportCannotUnfreeze",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
-					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Eq("unauthorized_user")).
-						Times(1).
-						Return(db.User{}, sql.ErrConnDone)
-
 					store.EXPECT().
 						GetAccount(gomock.Any(), gomock.Any()).
 						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+			action: "unfreeze",
+		},
+		{
+			base: baseTestCase{
+				name: "AdminUnfreezes",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(frozenAccount, nil)
 
+					arg := db.UpdateAccountStatusParams{
+						ID:           account.ID,
+						Status:       db.AccountStatusActive,
+						FromStatus:   db.AccountStatusFrozen,
+						FromFrozenBy: frozenAccount.FrozenBy,
+					}
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
-						Times(0)
+						UpdateAccountStatus(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(account, nil)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchAccount(t, recorder.Body, account)
 				},
 			},
-			accountId: account.ID,
+			action: "unfreeze",
 		},
 		{
 			base: baseTestCase{
-				name: "NoAuthorization",
+				name: "OverridesOwnerFreeze",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Any()).
-						Times(0)
-
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(ownerFrozenAccount, nil)
+
+					arg := db.UpdateAccountStatusParams{
+						ID:           account.ID,
+						Status:       db.AccountStatusFrozen,
+						FrozenBy:     sql.NullString{String: testAdminUsername, Valid: true},
+						FromStatus:   db.AccountStatusFrozen,
+						FromFrozenBy: ownerFrozenAccount.FrozenBy,
+					}
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						UpdateAccountStatus(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(frozenAccount, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchAccount(t, recorder.Body, frozenAccount)
+				},
+			},
+			action: "freeze",
+		},
+		{
+			base: baseTestCase{
+				name: "Closed",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(closedAccount, nil)
 
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+						UpdateAccountStatus(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+					requireErrorCode(t, recorder, codeConflict)
 				},
 			},
-			accountId: account.ID,
+			action: "freeze",
 		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/accounts/%v/%v", account.ID, tc.action)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestOperatorFreezeOverridesOwnerFreeze(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Stands in for the row, so that each request sees the previous one.
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, _ int64) (db.Account, error) {
+			return account, nil
+		})
+	store.EXPECT().
+		UpdateAccountStatus(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.UpdateAccountStatusParams) (db.Account, error) {
+			if arg.FromStatus != account.Status || arg.FromFrozenBy != account.FrozenBy {
+				return db.Account{}, sql.ErrNoRows
+			}
+			account.Status = arg.Status
+			account.FrozenBy = arg.FrozenBy
+			return account, nil
+		})
+
+	server := newTestServer(t, store)
+	post := func(url string, setupAuth func(request *http.Request)) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodPost, url, nil)
+		require.NoError(t, err)
+		setupAuth(request)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	asOwner := func(request *http.Request) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	}
+	asOperator := func(request *http.Request) {
+		addRoleAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+	}
+
+	recorder := post(fmt.Sprintf("%v/%v/freeze", accountURI, account.ID), asOwner)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, user.Username, account.FrozenBy.String)
+
+	recorder = post(fmt.Sprintf("/v1/admin/accounts/%v/freeze", account.ID), asOperator)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, testAdminUsername, account.FrozenBy.String)
+
+	recorder = post(fmt.Sprintf("%v/%v/unfreeze", accountURI, account.ID), asOwner)
+	requireErrorCode(t, recorder, codeForbidden)
+	require.Equal(t, db.AccountStatusFrozen, account.Status)
+}
+
+func TestCloseAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	sweepAccount := randomAccount(user.Username)
+	sweepAccount.ID = account.ID + 1
+	sweepAccount.Currency = account.Currency
+
+	otherAccount := randomAccount("other_user")
+	otherAccount.ID = account.ID + 2
+	otherAccount.Currency = account.Currency
+
+	closedAccount := account
+	closedAccount.Status = db.AccountStatusClosed
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
 		{
 			base: baseTestCase{
-				name: "NotFound",
+				name: "OK",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 						Times(1).
-						Return(user, nil)
+						Return(account, nil)
 
+					arg := db.CloseAccountTxParams{
+						AccountID: account.ID,
+					}
+					store.EXPECT().
+						CloseAccountTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.CloseAccountTxResult{Account: closedAccount}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var result gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &result)
+					require.NoError(t, err)
+					require.Equal(t, db.AccountStatusClosed, result["account"].(map[string]interface{})["status"])
+					require.NotContains(t, result, "sweep")
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "Sweep",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
 						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 						Times(1).
-						Return(db.Account{}, sql.ErrNoRows)
+						Return(account, nil)
 
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetAccount(gomock.Any(), gomock.Eq(sweepAccount.ID)).
+						Times(1).
+						Return(sweepAccount, nil)
+
+					arg := db.CloseAccountTxParams{
+						AccountID:        account.ID,
+						SweepToAccountID: sweepAccount.ID,
+					}
+					store.EXPECT().
+						CloseAccountTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.CloseAccountTxResult{
+							Account: closedAccount,
+							Sweep: &db.TransferTxResult{
+								FromAccount: closedAccount,
+								ToAccount:   sweepAccount,
+							},
+						}, nil)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusNotFound, recorder.Code)
+					require.Equal(t, http.StatusOK, recorder.Code)
 				},
 			},
-			accountId: account.ID,
+			body: gin.H{
+				"sweep_to_account_id": sweepAccount.ID,
+			},
 		},
 		{
 			base: baseTestCase{
-				name: "InternalErrorOnAssertingAccountExistance",
+				name: "SweepToAnotherUser",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Eq(user.Username)).
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 						Times(1).
-						Return(user, nil)
+						Return(account, nil)
 
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).
 						Times(1).
-						Return(db.Account{}, sql.ErrConnDone)
+						Return(otherAccount, nil)
 
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Any()).
+						CloseAccountTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
 				},
 			},
-			accountId: account.ID,
+			body: gin.H{
+				"sweep_to_account_id": otherAccount.ID,
+			},
 		},
 		{
 			base: baseTestCase{
-				name: "InternalErrorOnAccountDeletion",
+				name: "SweepToItself",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Eq(user.Username)).
-						Times(1).
-						Return(user, nil)
-
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"sweep_to_account_id": account.ID,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "BalanceNotZero",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
 						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 						Times(1).
 						Return(account, nil)
 
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Any()).
+						CloseAccountTx(gomock.Any(), gomock.Any()).
 						Times(1).
-						Return(sql.ErrConnDone)
+						Return(db.CloseAccountTxResult{}, db.ErrAccountBalanceNotZero)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 				},
 			},
-			accountId: account.ID,
 		},
 		{
 			base: baseTestCase{
-				name: "InvalidID",
+				name: "AlreadyClosed",
 				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 				},
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						GetUser(gomock.Any(), gomock.Any()).
-						Times(0)
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(closedAccount, nil)
 
 					store.EXPECT().
-						GetAccount(gomock.Any(), gomock.Any()).
-						Times(0)
+						CloseAccountTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CloseAccountTxResult{}, &db.AccountNotActiveError{
+							AccountID: account.ID,
+							Status:    db.AccountStatusClosed,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusConflict, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
 
 					store.EXPECT().
-						DeleteAccount(gomock.Any(), gomock.Any()).
+						CloseAccountTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CloseAccountTxResult{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CloseAccountTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-					require.Equal(t, http.StatusBadRequest, recorder.Code)
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
 				},
 			},
-			accountId: 0,
 		},
 	}
 
@@ -902,12 +1792,8 @@ func TestDeleteAccountAPI(t *testing.T) {
 		tc := testCases[i]
 
 		getRequest := func() (*http.Request, error) {
-			url := fmt.Sprintf("%v/%v", accountURI, tc.accountId)
-			request, err := http.NewRequest(http.MethodDelete, url, nil)
-			if err != nil {
-				return nil, err
-			}
-			return request, err
+			url := fmt.Sprintf("%v/%v/close", accountURI, account.ID)
+			return newJSONRequest(http.MethodPost, url, tc.body)
 		}
 		tc.base.runTestCase(t, getRequest)
 	}
@@ -917,18 +1803,20 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccount db.Account
-	err = json.Unmarshal(data, &gotAccount)
+	want, err := json.Marshal(newAccountResponse(account, false))
 	require.NoError(t, err)
-	require.Equal(t, account, gotAccount)
+	require.JSONEq(t, string(want), string(data))
 }
 
 func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
 
-	var gotAccounts []db.Account
-	err = json.Unmarshal(data, &gotAccounts)
+	want := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		want[i] = newAccountResponse(account, false)
+	}
+	wantData, err := json.Marshal(want)
 	require.NoError(t, err)
-	require.Equal(t, accounts, gotAccounts)
+	require.JSONEq(t, string(wantData), string(data))
 }
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Lists the currencies accounts can be opened and money moved in.
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	currencies, err := server.store.ListCurrencies(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	enabled := []db.Currency{}
+	for _, currency := range currencies {
+		if currency.Enabled {
+			enabled = append(enabled, currency)
+		}
+	}
+	ctx.JSON(http.StatusOK, enabled)
+}
+
+// Lists every currency, including the disabled ones.
+func (server *Server) listAllCurrencies(ctx *gin.Context) {
+	currencies, err := server.store.ListCurrencies(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, currencies)
+}
+
+type createCurrencyRequest struct {
+	Code string `json:"code" binding:"required,len=3,alpha,uppercase"`
+
+	// Decimal places of the minor unit, 0 for JPY, 3 for BHD.
+	Exponent int32  `json:"exponent" binding:"min=0,max=4"`
+	Symbol   string `json:"symbol" binding:"max=8"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Adds a currency, along with the system accounts it settles against.
+func (server *Server) createCurrency(ctx *gin.Context) {
+	var req createCurrencyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	result, err := server.store.CreateCurrencyTx(ctx, db.CreateCurrencyParams{
+		Code:     req.Code,
+		Exponent: req.Exponent,
+		Symbol:   req.Symbol,
+		Enabled:  req.Enabled,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	util.InvalidateCurrencies()
+	ctx.JSON(http.StatusOK, result.Currency)
+}
+
+type updateCurrencyRequest struct {
+	uri  updateCurrencyRequestUri
+	body updateCurrencyRequestBody
+}
+
+type updateCurrencyRequestUri struct {
+	Code string `uri:"code" binding:"required,len=3,alpha,uppercase"`
+}
+
+// The exponent can't change, existing amounts are expressed in it.
+type updateCurrencyRequestBody struct {
+	Symbol  string `json:"symbol" binding:"max=8"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Replaces the symbol of a currency and enables or disables it.
+// Disabled currencies are rejected by new requests, existing balances stay.
+func (server *Server) updateCurrency(ctx *gin.Context) {
+	var req updateCurrencyRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req.body); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	currency, err := server.store.UpdateCurrency(ctx, db.UpdateCurrencyParams{
+		Code:    req.uri.Code,
+		Symbol:  req.body.Symbol,
+		Enabled: req.body.Enabled,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	util.InvalidateCurrencies()
+	ctx.JSON(http.StatusOK, currency)
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	currencies := []db.Currency{
+		{Code: "BHD", Exponent: 3, Symbol: "BD", Enabled: false},
+		{Code: util.EUR, Exponent: 2, Symbol: "€", Enabled: true},
+		{Code: "JPY", Exponent: 0, Symbol: "¥", Enabled: true},
+	}
+
+	testCases := []struct {
+		base     baseTestCase
+		url      string
+		expected []db.Currency
+	}{
+		{
+			base: baseTestCase{
+				name: "Enabled",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+			},
+			url:      "/v1/currencies",
+			expected: currencies[1:],
+		},
+		{
+			base: baseTestCase{
+				name: "AdminAll",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+			},
+			url:      "/v1/admin/currencies",
+			expected: currencies,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		tc.base.buildStubs = func(store *mockdb.MockStore) {
+			store.EXPECT().
+				ListCurrencies(gomock.Any()).
+				Times(1).
+				Return(currencies, nil)
+		}
+		tc.base.checkResponse = func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var gotCurrencies []db.Currency
+			err := json.Unmarshal(recorder.Body.Bytes(), &gotCurrencies)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, gotCurrencies)
+		}
+		tc.base.runTestCase(t, func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, tc.url, nil)
+		})
+	}
+}
+
+func TestCreateCurrencyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	currency := db.Currency{Code: "JPY", Exponent: 0, Symbol: "¥", Enabled: true}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.CreateCurrencyParams{
+						Code:     currency.Code,
+						Exponent: currency.Exponent,
+						Symbol:   currency.Symbol,
+						Enabled:  currency.Enabled,
+					}
+					store.EXPECT().
+						CreateCurrencyTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.CreateCurrencyTxResult{Currency: currency}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var gotCurrency db.Currency
+					err := json.Unmarshal(recorder.Body.Bytes(), &gotCurrency)
+					require.NoError(t, err)
+					require.Equal(t, currency, gotCurrency)
+				},
+			},
+			body: gin.H{
+				"code":     currency.Code,
+				"exponent": currency.Exponent,
+				"symbol":   currency.Symbol,
+				"enabled":  currency.Enabled,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "DuplicateCode",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateCurrencyTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CreateCurrencyTxResult{}, &pq.Error{Code: "23505"})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"code":     util.USD,
+				"exponent": 2,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidCode",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateCurrencyTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"code":     "jpy",
+				"exponent": 0,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "ExponentTooHigh",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateCurrencyTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"code":     "XYZ",
+				"exponent": 5,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotAdmin",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateCurrencyTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"code":     currency.Code,
+				"exponent": currency.Exponent,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		tc.base.runTestCase(t, func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, "/v1/admin/currencies", tc.body)
+		})
+	}
+}
+
+func TestUpdateCurrencyAPI(t *testing.T) {
+	currency := db.Currency{Code: util.CAD, Exponent: 2, Symbol: "C$", Enabled: false}
+
+	testCases := []struct {
+		base baseTestCase
+		code string
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpdateCurrencyParams{
+						Code:    currency.Code,
+						Symbol:  currency.Symbol,
+						Enabled: currency.Enabled,
+					}
+					store.EXPECT().
+						UpdateCurrency(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(currency, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			code: currency.Code,
+			body: gin.H{
+				"symbol":  currency.Symbol,
+				"enabled": currency.Enabled,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateCurrency(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.Currency{}, sql.ErrNoRows)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			code: "GBP",
+			body: gin.H{"enabled": true},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidCode",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateCurrency(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			code: "GBPX",
+			body: gin.H{"enabled": true},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateCurrency(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.Currency{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			code: currency.Code,
+			body: gin.H{"enabled": true},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		tc.base.setupAuth = func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+		}
+		tc.base.runTestCase(t, func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/currencies/%s", tc.code)
+			return newJSONRequest(http.MethodPut, url, tc.body)
+		})
+	}
+}
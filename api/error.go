@@ -0,0 +1,228 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+/**
+ * An entry of the error catalogue: the HTTP status of an error and its machine code.
+ * Codes are part of the API contract, clients switch on them,
+ * so an existing code must never change its meaning.
+ */
+type errorCode struct {
+	status int
+	code   string
+}
+
+var (
+	codeInvalidRequest        = errorCode{http.StatusBadRequest, "invalid_request"}
+	codeValidationFailed      = errorCode{http.StatusBadRequest, "validation_failed"}
+	codeUnauthenticated       = errorCode{http.StatusUnauthorized, "unauthenticated"}
+	codeNotOwner              = errorCode{http.StatusUnauthorized, "not_owner"}
+	codeForbidden             = errorCode{http.StatusForbidden, "forbidden"}
+	codeAlreadyExists         = errorCode{http.StatusForbidden, "already_exists"}
+	codeInvalidReference      = errorCode{http.StatusForbidden, "invalid_reference"}
+	codeTransferDenied        = errorCode{http.StatusForbidden, "transfer_denied"}
+	codeNotFound              = errorCode{http.StatusNotFound, "not_found"}
+	codeConflict              = errorCode{http.StatusConflict, "conflict"}
+	codeUnprocessable         = errorCode{http.StatusUnprocessableEntity, "unprocessable"}
+	codeInsufficientFunds     = errorCode{http.StatusUnprocessableEntity, "insufficient_funds"}
+	codeTransferLimitExceeded = errorCode{http.StatusUnprocessableEntity, "transfer_limit_exceeded"}
+	codeInternal              = errorCode{http.StatusInternalServerError, "internal"}
+)
+
+// Domain errors that handlers pass on as they are, with the catalogue entry reporting them.
+var domainErrorCodes = []struct {
+	err  error
+	code errorCode
+}{
+	{db.ErrAccountNotActive, errorCode{http.StatusUnprocessableEntity, "account_not_active"}},
+	{db.ErrAccountBalanceNotZero, errorCode{http.StatusUnprocessableEntity, "account_balance_not_zero"}},
+	{db.ErrAccountHasActiveHolds, errorCode{http.StatusUnprocessableEntity, "account_has_active_holds"}},
+	{db.ErrFeeOverflow, errorCode{http.StatusUnprocessableEntity, "fee_overflow"}},
+	{db.ErrIdempotencyKeyMismatch, errorCode{http.StatusUnprocessableEntity, "idempotency_key_mismatch"}},
+	{db.ErrHoldNotActive, errorCode{http.StatusConflict, "hold_not_active"}},
+	{db.ErrHoldExpired, errorCode{http.StatusConflict, "hold_expired"}},
+	{db.ErrCaptureExceedsHold, errorCode{http.StatusUnprocessableEntity, "capture_exceeds_hold"}},
+	{db.ErrQuoteExpired, errorCode{http.StatusConflict, "quote_expired"}},
+	{db.ErrQuoteAlreadyUsed, errorCode{http.StatusConflict, "quote_already_used"}},
+	{db.ErrQuoteCurrencyMismatch, errorCode{http.StatusUnprocessableEntity, "quote_currency_mismatch"}},
+	{db.ErrTransferAlreadyReversed, errorCode{http.StatusConflict, "transfer_already_reversed"}},
+	{db.ErrReversalExceedsTransfer, errorCode{http.StatusUnprocessableEntity, "reversal_exceeds_transfer"}},
+	{db.ErrReversalNotReversible, errorCode{http.StatusUnprocessableEntity, "reversal_not_reversible"}},
+	{db.ErrReversalNotAllowed, errorCode{http.StatusForbidden, "reversal_not_allowed"}},
+	{errScheduledTransferLocked, errorCode{http.StatusConflict, "scheduled_transfer_locked"}},
+	{errWebhookDeliveryLocked, errorCode{http.StatusConflict, "webhook_delivery_locked"}},
+	{token.ErrInvalidToken, codeUnauthenticated},
+	{token.ErrExpiredToken, codeUnauthenticated},
+}
+
+// An error reported to the client in the error envelope.
+type apiError struct {
+	errorCode
+	message  string
+	details  []fieldViolation
+	metadata gin.H
+	cause    error
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (e *apiError) Unwrap() error {
+	return e.cause
+}
+
+// Reports err under a catalogue entry, with its message shown to the client.
+func newAPIError(code errorCode, err error) *apiError {
+	return &apiError{errorCode: code, message: err.Error(), cause: err}
+}
+
+type fieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+/**
+ * Reports a request that could not be bound or is otherwise malformed.
+ * Failed binding rules become one violation per field,
+ * instead of the raw message of the validator.
+ */
+func invalidRequestError(err error) *apiError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		apiErr := &apiError{
+			errorCode: codeValidationFailed,
+			message:   "request validation failed",
+			cause:     err,
+		}
+		for _, fieldErr := range validationErrs {
+			apiErr.details = append(apiErr.details, fieldViolation{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Message: violationMessage(fieldErr),
+			})
+		}
+		return apiErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &apiError{
+			errorCode: codeValidationFailed,
+			message:   "request validation failed",
+			details: []fieldViolation{{
+				Field:   typeErr.Field,
+				Message: fmt.Sprintf("must be a %s", typeErr.Type.Kind()),
+			}},
+			cause: err,
+		}
+	}
+
+	return newAPIError(codeInvalidRequest, err)
+}
+
+/**
+ * Maps an error a handler attached to the context to its catalogue entry.
+ * Errors with no entry are internal: their message may leak driver
+ * or query details, so the client only gets a generic one.
+ */
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var fundsErr *db.InsufficientFundsError
+	if errors.As(err, &fundsErr) {
+		apiErr = newAPIError(codeInsufficientFunds, err)
+		apiErr.metadata = gin.H{"available_balance": fundsErr.AvailableBalance}
+		return apiErr
+	}
+
+	var limitErr *db.TransferLimitError
+	if errors.As(err, &limitErr) {
+		apiErr = newAPIError(codeTransferLimitExceeded, err)
+		apiErr.metadata = gin.H{
+			"limit":     limitErr.Limit,
+			"max":       limitErr.Max,
+			"used":      limitErr.Used,
+			"resets_at": limitErr.ResetsAt,
+		}
+		return apiErr
+	}
+
+	for _, domainErr := range domainErrorCodes {
+		if errors.Is(err, domainErr.err) {
+			return newAPIError(domainErr.code, err)
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &apiError{errorCode: codeNotFound, message: "resource not found", cause: err}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return &apiError{errorCode: codeAlreadyExists, message: "resource already exists", cause: err}
+		case "foreign_key_violation":
+			return &apiError{errorCode: codeInvalidReference, message: "referenced resource does not exist", cause: err}
+		}
+	}
+
+	return &apiError{errorCode: codeInternal, message: "internal server error", cause: err}
+}
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string           `json:"code"`
+	Message   string           `json:"message"`
+	Details   []fieldViolation `json:"details,omitempty"`
+	Metadata  gin.H            `json:"metadata,omitempty"`
+	RequestID string           `json:"request_id"`
+}
+
+/**
+ * ErrorMiddleware renders the last error attached by the handlers
+ * with ctx.Error as an error envelope.
+ * Handlers attach the error and return, without writing a response.
+ * The errors stay on the context, so the logger still records their cause.
+ */
+func errorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		// A response was already written, like an event stream that broke.
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		apiErr := toAPIError(ctx.Errors.Last().Err)
+		ctx.JSON(apiErr.status, errorEnvelope{
+			Error: errorBody{
+				Code:      apiErr.code,
+				Message:   apiErr.message,
+				Details:   apiErr.details,
+				Metadata:  apiErr.metadata,
+				RequestID: ctx.GetString(requestIDKey),
+			},
+		})
+	}
+}
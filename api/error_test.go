@@ -0,0 +1,228 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "ValidationFailed",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateUserTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeValidationFailed)
+					require.ElementsMatch(t, []fieldViolation{
+						{Field: "password", Rule: "min", Message: "must be at least 6 characters"},
+						{Field: "email", Rule: "email", Message: "must be a valid email address"},
+					}, body.Details)
+				},
+			},
+			body: gin.H{
+				"username":  user.Username,
+				"password":  "123",
+				"full_name": user.FullName,
+				"email":     "invalid-email",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "WrongType",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateUserTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeValidationFailed)
+					require.Len(t, body.Details, 1)
+					require.Equal(t, "username", body.Details[0].Field)
+				},
+			},
+			body: gin.H{
+				"username":  123,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AlreadyExists",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateUserTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.User{}, &pq.Error{Code: "23505", Message: "duplicate key value violates users_pkey"})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeAlreadyExists)
+					require.NotContains(t, body.Message, "users_pkey")
+				},
+			},
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalErrorIsHidden",
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateUserTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.User{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeInternal)
+					require.Equal(t, "internal server error", body.Message)
+					require.NotContains(t, recorder.Body.String(), sql.ErrConnDone.Error())
+				},
+			},
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		tc.base.runTestCase(t, func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, userURI, tc.body)
+		})
+	}
+}
+
+func TestErrorEnvelopeRequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	// The ID of the client is kept.
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, accountURI, nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeader, "client-request-id")
+
+	server.router.ServeHTTP(recorder, request)
+	body := requireErrorCode(t, recorder, codeUnauthenticated)
+	require.Equal(t, "client-request-id", body.RequestID)
+
+	// Unknown routes get the envelope as well, with a generated ID.
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/accounts", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	body = requireErrorCode(t, recorder, codeNotFound)
+	require.NotEqual(t, "client-request-id", body.RequestID)
+}
+
+func TestToAPIError(t *testing.T) {
+	resetsAt := time.Now()
+
+	testCases := []struct {
+		name     string
+		err      error
+		code     errorCode
+		metadata gin.H
+	}{
+		{
+			name: "APIError",
+			err:  newAPIError(codeNotOwner, fmt.Errorf("account doesn't belong to the authenticated user")),
+			code: codeNotOwner,
+		},
+		{
+			name: "NoRows",
+			err:  fmt.Errorf("cannot get account: %w", sql.ErrNoRows),
+			code: codeNotFound,
+		},
+		{
+			name: "ForeignKeyViolation",
+			err:  &pq.Error{Code: "23503"},
+			code: codeInvalidReference,
+		},
+		{
+			name:     "InsufficientFunds",
+			err:      &db.InsufficientFundsError{AccountID: 1, AvailableBalance: 5, Amount: 10},
+			code:     codeInsufficientFunds,
+			metadata: gin.H{"available_balance": int64(5)},
+		},
+		{
+			name: "TransferLimit",
+			err:  &db.TransferLimitError{Limit: db.LimitPerDay, Max: 100, Used: 95, ResetsAt: &resetsAt},
+			code: codeTransferLimitExceeded,
+			metadata: gin.H{
+				"limit":     db.LimitPerDay,
+				"max":       int64(100),
+				"used":      int64(95),
+				"resets_at": &resetsAt,
+			},
+		},
+		{
+			name: "DomainError",
+			err:  fmt.Errorf("cannot capture hold: %w", db.ErrHoldExpired),
+			code: errorCode{http.StatusConflict, "hold_expired"},
+		},
+		{
+			name: "ExpiredToken",
+			err:  token.ErrExpiredToken,
+			code: codeUnauthenticated,
+		},
+		{
+			name: "Internal",
+			err:  sql.ErrConnDone,
+			code: codeInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := toAPIError(tc.err)
+			require.Equal(t, tc.code, apiErr.errorCode)
+			require.Equal(t, tc.metadata, apiErr.metadata)
+			require.ErrorIs(t, apiErr, tc.err)
+		})
+	}
+}
+
+// Every domain error has its own code, so clients can tell them apart.
+func TestDomainErrorCodesAreUnique(t *testing.T) {
+	codes := map[string]bool{}
+	for _, domainErr := range domainErrorCodes {
+		if domainErr.code == codeUnauthenticated {
+			continue
+		}
+		require.False(t, codes[domainErr.code.code], "duplicate code %s", domainErr.code.code)
+		codes[domainErr.code.code] = true
+	}
+}
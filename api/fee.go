@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	schedules, err := server.store.ListFeeSchedules(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+type upsertFeeScheduleRequest struct {
+	Currency  string `json:"currency" binding:"required,currency"`
+	MinAmount int64  `json:"min_amount" binding:"min=0"`
+	FlatFee   int64  `json:"flat_fee" binding:"min=0"`
+
+	// Basis points of the amount, 100 is 1%.
+	PercentageBps int32 `json:"percentage_bps" binding:"min=0,max=10000"`
+}
+
+// Creates the tier of a currency that starts at min_amount, or replaces its fees.
+func (server *Server) upsertFeeSchedule(ctx *gin.Context) {
+	var req upsertFeeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	schedule, err := server.store.UpsertFeeSchedule(ctx, db.UpsertFeeScheduleParams{
+		Currency:      req.Currency,
+		MinAmount:     req.MinAmount,
+		FlatFee:       req.FlatFee,
+		PercentageBps: req.PercentageBps,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+type deleteFeeScheduleRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) deleteFeeSchedule(ctx *gin.Context) {
+	var req deleteFeeScheduleRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	schedule, err := server.store.DeleteFeeSchedule(ctx, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
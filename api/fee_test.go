@@ -0,0 +1,207 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestListFeeSchedulesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	schedules := []db.FeeSchedule{
+		{ID: 1, Currency: util.USD, FlatFee: 25},
+		{ID: 2, Currency: util.USD, MinAmount: 10000, PercentageBps: 50},
+	}
+
+	testCase := baseTestCase{
+		name: "OK",
+		setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+		},
+		buildStubs: func(store *mockdb.MockStore) {
+			store.EXPECT().
+				ListFeeSchedules(gomock.Any()).
+				Times(1).
+				Return(schedules, nil)
+		},
+		checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var gotSchedules []db.FeeSchedule
+			err := json.Unmarshal(recorder.Body.Bytes(), &gotSchedules)
+			require.NoError(t, err)
+			require.Equal(t, schedules, gotSchedules)
+		},
+	}
+
+	testCase.runTestCase(t, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, "/v1/fees", nil)
+	})
+}
+
+func TestUpsertFeeScheduleAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpsertFeeScheduleParams{
+						Currency:      util.EUR,
+						MinAmount:     10000,
+						FlatFee:       10,
+						PercentageBps: 25,
+					}
+					store.EXPECT().
+						UpsertFeeSchedule(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.FeeSchedule{ID: 1, Currency: util.EUR}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"currency":       util.EUR,
+				"min_amount":     10000,
+				"flat_fee":       10,
+				"percentage_bps": 25,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "PercentageTooHigh",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFeeSchedule(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"currency":       util.EUR,
+				"percentage_bps": 10001,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NegativeFlatFee",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFeeSchedule(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"currency": util.EUR,
+				"flat_fee": -1,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotAdmin",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFeeSchedule(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"currency": util.EUR,
+				"flat_fee": 10,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPut, "/v1/admin/fees", tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestDeleteFeeScheduleAPI(t *testing.T) {
+	schedule := db.FeeSchedule{ID: util.RandomInt(1, 1000), Currency: util.CAD, FlatFee: 5}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFeeSchedule(gomock.Any(), gomock.Eq(schedule.ID)).
+					Times(1).
+					Return(schedule, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFeeSchedule(gomock.Any(), gomock.Eq(schedule.ID)).
+					Times(1).
+					Return(db.FeeSchedule{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/fees/%d", schedule.ID)
+			return http.NewRequest(http.MethodDelete, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/fx"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// How long a quote's rate is locked in when FX_QUOTE_DURATION is not set.
+const defaultFxQuoteDuration = 30 * time.Second
+
+func (server *Server) listFxRates(ctx *gin.Context) {
+	rates, err := server.store.ListFxRates(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rates)
+}
+
+type upsertFxRateRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	Rate         string `json:"rate" binding:"required"`
+}
+
+func (server *Server) upsertFxRate(ctx *gin.Context) {
+	var req upsertFxRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if _, err := fx.ParseRate(req.Rate); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	rate, err := server.store.UpsertFxRate(ctx, db.UpsertFxRateParams{
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Rate:         req.Rate,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rate)
+}
+
+type createFxQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+
+	// In the source currency.
+	Amount requestAmount `json:"amount" binding:"required"`
+}
+
+type fxQuoteResponse struct {
+	ID               int64      `json:"id"`
+	Owner            string     `json:"owner"`
+	FromCurrency     string     `json:"from_currency"`
+	ToCurrency       string     `json:"to_currency"`
+	Rate             string     `json:"rate"`
+	FromAmount       moneyField `json:"from_amount"`
+	ToAmount         moneyField `json:"to_amount"`
+	DebitTransferID  *int64     `json:"debit_transfer_id"`
+	CreditTransferID *int64     `json:"credit_transfer_id"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func newFxQuoteResponse(quote db.FxQuote, minorUnits bool) fxQuoteResponse {
+	return fxQuoteResponse{
+		ID:               quote.ID,
+		Owner:            quote.Owner,
+		FromCurrency:     quote.FromCurrency,
+		ToCurrency:       quote.ToCurrency,
+		Rate:             quote.Rate,
+		FromAmount:       newMoneyField(quote.FromAmount, quote.FromCurrency, minorUnits),
+		ToAmount:         newMoneyField(quote.ToAmount, quote.ToCurrency, minorUnits),
+		DebitTransferID:  nullInt64(quote.DebitTransferID),
+		CreditTransferID: nullInt64(quote.CreditTransferID),
+		ExpiresAt:        quote.ExpiresAt,
+		CreatedAt:        quote.CreatedAt,
+	}
+}
+
+// The customer side of a conversion, each leg in its own currency.
+type convertTxResponse struct {
+	Quote       fxQuoteResponse `json:"quote"`
+	FromAccount accountResponse `json:"from_account"`
+	ToAccount   accountResponse `json:"to_account"`
+	FromEntry   entryResponse   `json:"from_entry"`
+	ToEntry     entryResponse   `json:"to_entry"`
+}
+
+func newConvertTxResponse(result db.ConvertTxResult, minorUnits bool) convertTxResponse {
+	return convertTxResponse{
+		Quote:       newFxQuoteResponse(result.Quote, minorUnits),
+		FromAccount: newAccountResponse(result.FromAccount, minorUnits),
+		ToAccount:   newAccountResponse(result.ToAccount, minorUnits),
+		FromEntry:   newEntryResponse(result.FromEntry, result.Quote.FromCurrency, minorUnits),
+		ToEntry:     newEntryResponse(result.ToEntry, result.Quote.ToCurrency, minorUnits),
+	}
+}
+
+func (server *Server) createFxQuote(ctx *gin.Context) {
+	var req createFxQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	amount, err := parseAmount(ctx, req.Amount, req.FromCurrency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	rate, valid := server.fxRate(ctx, req.FromCurrency, req.ToCurrency)
+	if !valid {
+		return
+	}
+
+	// Both currencies were checked to exist by the binding.
+	fromCurrency, _ := util.LookupCurrency(req.FromCurrency)
+	toCurrency, _ := util.LookupCurrency(req.ToCurrency)
+
+	toAmount, err := fx.Convert(amount.Amount(), rate, fromCurrency.Exponent, toCurrency.Exponent)
+	if err != nil {
+		ctx.Error(newAPIError(codeUnprocessable, err))
+		return
+	}
+	if toAmount == 0 {
+		err := fmt.Errorf("amount is too small to be converted to %s", req.ToCurrency)
+		ctx.Error(newAPIError(codeUnprocessable, err))
+		return
+	}
+
+	duration := server.config.FxQuoteDuration
+	if duration <= 0 {
+		duration = defaultFxQuoteDuration
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	quote, err := server.store.CreateFxQuote(ctx, db.CreateFxQuoteParams{
+		Owner:        authPayload.Username,
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Rate:         rate,
+		FromAmount:   amount.Amount(),
+		ToAmount:     toAmount,
+		ExpiresAt:    time.Now().Add(duration),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newFxQuoteResponse(quote, minorUnits(ctx)))
+}
+
+type createFxConversionRequest struct {
+	QuoteID       int64 `json:"quote_id" binding:"required,min=1"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64 `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+}
+
+func (server *Server) createFxConversion(ctx *gin.Context) {
+	var req createFxConversionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	quote, err := server.store.GetFxQuote(ctx, req.QuoteID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if quote.Owner != authPayload.Username {
+		err := errors.New("quote doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	// Conversions only move money between the user's own accounts.
+	accounts := []struct {
+		id       int64
+		currency string
+	}{
+		{req.FromAccountID, quote.FromCurrency},
+		{req.ToAccountID, quote.ToCurrency},
+	}
+	for _, a := range accounts {
+		account, valid := server.validAccount(ctx, a.id, a.currency)
+		if !valid {
+			return
+		}
+		if account.Owner != authPayload.Username {
+			err := fmt.Errorf("account [%d] doesn't belong to the authenticated user", account.ID)
+			ctx.Error(newAPIError(codeNotOwner, err))
+			return
+		}
+	}
+
+	result, err := server.store.ConvertTx(ctx, db.ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newConvertTxResponse(result, minorUnits(ctx)))
+}
+
+/**
+ * Finds the rate from one currency to another.
+ * When only the opposite direction is configured, its inverse is used.
+ */
+func (server *Server) fxRate(ctx *gin.Context, from, to string) (string, bool) {
+	rate, err := server.store.GetFxRate(ctx, db.GetFxRateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+	})
+	if err == nil {
+		return rate.Rate, true
+	}
+	if err != sql.ErrNoRows {
+		ctx.Error(err)
+		return "", false
+	}
+
+	rate, err = server.store.GetFxRate(ctx, db.GetFxRateParams{
+		FromCurrency: to,
+		ToCurrency:   from,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("no exchange rate from %s to %s", from, to)
+			ctx.Error(newAPIError(codeUnprocessable, err))
+			return "", false
+		}
+		ctx.Error(err)
+		return "", false
+	}
+
+	inverted, err := fx.Invert(rate.Rate)
+	if err != nil {
+		ctx.Error(err)
+		return "", false
+	}
+	return inverted, true
+}
@@ -0,0 +1,688 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func randomFxQuote(owner string) db.FxQuote {
+	return db.FxQuote{
+		ID:           util.RandomInt(1, 1000),
+		Owner:        owner,
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.92",
+		FromAmount:   100,
+		ToAmount:     92,
+		ExpiresAt:    time.Now().Add(time.Minute).Truncate(time.Second).UTC(),
+	}
+}
+
+// Returns the quote CreateFxQuote would store.
+func quoteOf(arg db.CreateFxQuoteParams) db.FxQuote {
+	return db.FxQuote{
+		ID:           util.RandomInt(1, 1000),
+		Owner:        arg.Owner,
+		FromCurrency: arg.FromCurrency,
+		ToCurrency:   arg.ToCurrency,
+		Rate:         arg.Rate,
+		FromAmount:   arg.FromAmount,
+		ToAmount:     arg.ToAmount,
+		ExpiresAt:    arg.ExpiresAt,
+	}
+}
+
+func TestListFxRatesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	rates := []db.FxRate{
+		{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.92"},
+		{FromCurrency: util.USD, ToCurrency: util.CAD, Rate: "1.36"},
+	}
+
+	testCase := baseTestCase{
+		name: "OK",
+		setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+		},
+		buildStubs: func(store *mockdb.MockStore) {
+			store.EXPECT().
+				ListFxRates(gomock.Any()).
+				Times(1).
+				Return(rates, nil)
+		},
+		checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var gotRates []db.FxRate
+			err := json.Unmarshal(recorder.Body.Bytes(), &gotRates)
+			require.NoError(t, err)
+			require.Equal(t, rates, gotRates)
+		},
+	}
+
+	testCase.runTestCase(t, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, "/v1/fx/rates", nil)
+	})
+}
+
+func TestUpsertFxRateAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpsertFxRateParams{
+						FromCurrency: util.USD,
+						ToCurrency:   util.EUR,
+						Rate:         "0.93",
+					}
+					store.EXPECT().
+						UpsertFxRate(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.FxRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.93"}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.93",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotAdmin",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.93",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.93",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidRate",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "-1",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "SameCurrency",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.USD,
+				"rate":          "1",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPut, "/v1/admin/fx/rates", tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestCreateFxQuoteAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	usdToEur := db.GetFxRateParams{FromCurrency: util.USD, ToCurrency: util.EUR}
+	eurToUsd := db.GetFxRateParams{FromCurrency: util.EUR, ToCurrency: util.USD}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Eq(usdToEur)).
+						Times(1).
+						Return(db.FxRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.92"}, nil)
+
+					store.EXPECT().
+						CreateFxQuote(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateFxQuoteParams) (db.FxQuote, error) {
+							require.Equal(t, user.Username, arg.Owner)
+							require.Equal(t, "0.92", arg.Rate)
+							require.Equal(t, int64(100), arg.FromAmount)
+							require.Equal(t, int64(92), arg.ToAmount)
+							require.WithinDuration(t, time.Now().Add(defaultFxQuoteDuration), arg.ExpiresAt, time.Second)
+							return quoteOf(arg), nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var gotQuote gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &gotQuote)
+					require.NoError(t, err)
+					require.Equal(t, "1.00", gotQuote["from_amount"])
+					require.Equal(t, "0.92", gotQuote["to_amount"])
+					require.Nil(t, gotQuote["debit_transfer_id"])
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        decimalAmount(100, util.USD),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InverseRate",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Eq(eurToUsd)).
+						Times(1).
+						Return(db.FxRate{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Eq(usdToEur)).
+						Times(1).
+						Return(db.FxRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.8"}, nil)
+
+					store.EXPECT().
+						CreateFxQuote(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateFxQuoteParams) (db.FxQuote, error) {
+							require.Equal(t, "1.25", arg.Rate)
+							require.Equal(t, int64(125), arg.ToAmount)
+							return quoteOf(arg), nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.EUR,
+				"to_currency":   util.USD,
+				"amount":        decimalAmount(100, util.EUR),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoRate",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Any()).
+						Times(2).
+						Return(db.FxRate{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						CreateFxQuote(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        decimalAmount(100, util.USD),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AmountTooSmall",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Eq(usdToEur)).
+						Times(1).
+						Return(db.FxRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.92"}, nil)
+
+					store.EXPECT().
+						CreateFxQuote(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        decimalAmount(1, util.USD),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "SameCurrency",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.USD,
+				"amount":        decimalAmount(100, util.USD),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxRate(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        decimalAmount(100, util.USD),
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, "/v1/fx/quotes", tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestCreateFxConversionAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	quote := randomFxQuote(user1.Username)
+
+	usdAccount := randomAccount(user1.Username)
+	usdAccount.Currency = util.USD
+	eurAccount := randomAccount(user1.Username)
+	eurAccount.ID = usdAccount.ID + 1
+	eurAccount.Currency = util.EUR
+	otherEurAccount := randomAccount(user2.Username)
+	otherEurAccount.ID = usdAccount.ID + 2
+	otherEurAccount.Currency = util.EUR
+
+	body := gin.H{
+		"quote_id":        quote.ID,
+		"from_account_id": usdAccount.ID,
+		"to_account_id":   eurAccount.ID,
+	}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(usdAccount.ID)).
+						Times(1).
+						Return(usdAccount, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).
+						Times(1).
+						Return(eurAccount, nil)
+
+					arg := db.ConvertTxParams{
+						QuoteID:       quote.ID,
+						FromAccountID: usdAccount.ID,
+						ToAccountID:   eurAccount.ID,
+					}
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.ConvertTxResult{
+							Quote:       quote,
+							FromAccount: usdAccount,
+							ToAccount:   eurAccount,
+							FromEntry:   db.Entry{AccountID: usdAccount.ID, Amount: -quote.FromAmount},
+							ToEntry:     db.Entry{AccountID: eurAccount.ID, Amount: quote.ToAmount},
+						}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp struct {
+						Quote     gin.H `json:"quote"`
+						FromEntry gin.H `json:"from_entry"`
+						ToEntry   gin.H `json:"to_entry"`
+					}
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, "1.00", rsp.Quote["from_amount"])
+					require.Equal(t, "-1.00", rsp.FromEntry["amount"])
+					require.Equal(t, "0.92", rsp.ToEntry["amount"])
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "QuoteOfAnotherUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "AccountOfAnotherUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(usdAccount.ID)).
+						Times(1).
+						Return(usdAccount, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(otherEurAccount.ID)).
+						Times(1).
+						Return(otherEurAccount, nil)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"quote_id":        quote.ID,
+				"from_account_id": usdAccount.ID,
+				"to_account_id":   otherEurAccount.ID,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "CurrencyMismatch",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).
+						Times(1).
+						Return(eurAccount, nil)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"quote_id":        quote.ID,
+				"from_account_id": eurAccount.ID,
+				"to_account_id":   usdAccount.ID,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "QuoteExpired",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(usdAccount.ID)).
+						Times(1).
+						Return(usdAccount, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).
+						Times(1).
+						Return(eurAccount, nil)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.ConvertTxResult{}, db.ErrQuoteExpired)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusConflict, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "InsufficientFunds",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(quote, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(usdAccount.ID)).
+						Times(1).
+						Return(usdAccount, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).
+						Times(1).
+						Return(eurAccount, nil)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.ConvertTxResult{}, &db.InsufficientFundsError{
+							AccountID:        usdAccount.ID,
+							AvailableBalance: quote.FromAmount - 1,
+							Amount:           quote.FromAmount,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "QuoteNotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).
+						Times(1).
+						Return(db.FxQuote{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						ConvertTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetFxQuote(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: body,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, "/v1/fx/conversions", tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+// How long a hold lasts when the client doesn't ask for a duration.
+const defaultHoldDuration = 7 * 24 * time.Hour
+
+type createHoldRequest struct {
+	AccountID   int64         `json:"account_id" binding:"required,min=1,nefield=ToAccountID"`
+	ToAccountID int64         `json:"to_account_id" binding:"required,min=1"`
+	Amount      requestAmount `json:"amount" binding:"required"`
+	Currency    string        `json:"currency" binding:"required,currency"`
+
+	// Optional, defaults to defaultHoldDuration. At most 30 days.
+	ExpiresInSeconds int64 `json:"expires_in_seconds" binding:"omitempty,min=60,max=2592000"`
+}
+
+type holdResponse struct {
+	ID          int64      `json:"id"`
+	AccountID   int64      `json:"account_id"`
+	ToAccountID int64      `json:"to_account_id"`
+	Amount      moneyField `json:"amount"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	TransferID  *int64     `json:"transfer_id"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// The currency is the one of both accounts of the hold.
+func newHoldResponse(hold db.Hold, currency string, minorUnits bool) holdResponse {
+	return holdResponse{
+		ID:          hold.ID,
+		AccountID:   hold.AccountID,
+		ToAccountID: hold.ToAccountID,
+		Amount:      newMoneyField(hold.Amount, currency, minorUnits),
+		Currency:    currency,
+		Status:      hold.Status,
+		TransferID:  nullInt64(hold.TransferID),
+		ExpiresAt:   hold.ExpiresAt,
+		CreatedAt:   hold.CreatedAt,
+		UpdatedAt:   hold.UpdatedAt,
+	}
+}
+
+type holdTxResponse struct {
+	Hold    holdResponse    `json:"hold"`
+	Account accountResponse `json:"account"`
+}
+
+func newHoldTxResponse(result db.HoldTxResult, minorUnits bool) holdTxResponse {
+	return holdTxResponse{
+		Hold:    newHoldResponse(result.Hold, result.Account.Currency, minorUnits),
+		Account: newAccountResponse(result.Account, minorUnits),
+	}
+}
+
+type captureHoldTxResponse struct {
+	Hold holdResponse `json:"hold"`
+	transferTxResponse
+}
+
+func (server *Server) createHold(ctx *gin.Context) {
+	var req createHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	amount, err := parseAmount(ctx, req.Amount, req.Currency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	decision, valid := server.assessTransfer(ctx, authPayload, req.AccountID, req.ToAccountID, amount)
+	if !valid {
+		return
+	}
+	if decision != nil && decision.Action == string(risk.ActionDeny) {
+		ctx.Error(transferDeniedError(decision))
+		return
+	}
+
+	duration := defaultHoldDuration
+	if req.ExpiresInSeconds > 0 {
+		duration = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	result, err := server.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   req.AccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      amount.Amount(),
+		ExpiresAt:   time.Now().Add(duration),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newHoldTxResponse(result, minorUnits(ctx)))
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	hold, valid := server.validHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	// Both the payer and the recipient can see the hold.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{hold.AccountID, hold.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if account.Owner == authPayload.Username {
+			ctx.JSON(http.StatusOK, newHoldResponse(hold, account.Currency, minorUnits(ctx)))
+			return
+		}
+	}
+
+	err := errors.New("hold doesn't belong to the authenticated user")
+	ctx.Error(newAPIError(codeNotOwner, err))
+}
+
+type captureHoldRequest struct {
+	uri  captureHoldRequestUri
+	body captureHoldRequestBody
+}
+
+type captureHoldRequestUri struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+type captureHoldRequestBody struct {
+	// Optional, the whole hold is captured when omitted.
+	Amount requestAmount `json:"amount"`
+}
+
+func (server *Server) captureHold(ctx *gin.Context) {
+	var req captureHoldRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	// The body is optional, without it the whole hold is captured.
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req.body); err != nil {
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+	}
+
+	hold, toAccount, valid := server.validHoldRecipient(ctx, req.uri.ID)
+	if !valid {
+		return
+	}
+
+	arg := db.CaptureHoldTxParams{HoldID: hold.ID}
+	if req.body.Amount != nil {
+		amount, err := parseAmount(ctx, req.body.Amount, toAccount.Currency)
+		if err != nil {
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+		arg.Amount = amount.Amount()
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, captureHoldTxResponse{
+		Hold:               newHoldResponse(result.Hold, toAccount.Currency, minorUnits(ctx)),
+		transferTxResponse: newTransferTxResponse(result.TransferTxResult, toAccount.Currency, minorUnits(ctx)),
+	})
+}
+
+type voidHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) voidHold(ctx *gin.Context) {
+	var req voidHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	hold, _, valid := server.validHoldRecipient(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.VoidHoldTx(ctx, hold.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newHoldTxResponse(result, minorUnits(ctx)))
+}
+
+func (server *Server) validHold(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, holdID)
+	if err != nil {
+		ctx.Error(err)
+		return hold, false
+	}
+	return hold, true
+}
+
+// Loads a hold that the current user is allowed to capture or void,
+// along with the recipient account.
+// Like a card authorization, only the recipient settles the hold.
+func (server *Server) validHoldRecipient(ctx *gin.Context, holdID int64) (db.Hold, db.Account, bool) {
+	hold, valid := server.validHold(ctx, holdID)
+	if !valid {
+		return hold, db.Account{}, false
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return hold, toAccount, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username {
+		err := errors.New("hold isn't payable to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return hold, toAccount, false
+	}
+	return hold, toAccount, true
+}
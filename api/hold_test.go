@@ -0,0 +1,615 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const holdURI = "/v1/holds"
+
+func randomHold(accountID, toAccountID int64) db.Hold {
+	return db.Hold{
+		ID:          util.RandomInt(1, 1000),
+		AccountID:   accountID,
+		ToAccountID: toAccountID,
+		Amount:      util.RandomInt(1, 100),
+		Status:      db.HoldStatusActive,
+		ExpiresAt:   time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+	}
+}
+
+func TestCreateHoldAPI(t *testing.T) {
+	amount := int64(10)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						PlaceHoldTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.PlaceHoldTxParams) (db.HoldTxResult, error) {
+							require.Equal(t, account1.ID, arg.AccountID)
+							require.Equal(t, account2.ID, arg.ToAccountID)
+							require.Equal(t, amount, arg.Amount)
+							require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+
+							hold := randomHold(arg.AccountID, arg.ToAccountID)
+							hold.Amount = arg.Amount
+							return db.HoldTxResult{Hold: hold, Account: account1}, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp struct {
+						Hold    gin.H `json:"hold"`
+						Account gin.H `json:"account"`
+					}
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, money.New(amount, util.USD).String(), rsp.Hold["amount"])
+					require.Equal(t, util.USD, rsp.Hold["currency"])
+					require.Nil(t, rsp.Hold["transfer_id"])
+					require.Equal(t, account1.ID, int64(rsp.Account["id"].(float64)))
+					require.NotContains(t, rsp.Account, "frozen_by")
+				},
+			},
+			body: gin.H{
+				"account_id":         account1.ID,
+				"to_account_id":      account2.ID,
+				"amount":             decimalAmount(amount, util.USD),
+				"currency":           util.USD,
+				"expires_in_seconds": 3600,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						PlaceHoldTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        decimalAmount(amount, util.USD),
+				"currency":      util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "CurrencyMismatch",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						PlaceHoldTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        decimalAmount(amount, util.EUR),
+				"currency":      util.EUR,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidExpiry",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"account_id":         account1.ID,
+				"to_account_id":      account2.ID,
+				"amount":             decimalAmount(amount, util.USD),
+				"currency":           util.USD,
+				"expires_in_seconds": 1,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InsufficientFunds",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						PlaceHoldTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.HoldTxResult{}, &db.InsufficientFundsError{
+							AccountID: account1.ID,
+							Amount:    amount,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        decimalAmount(amount, util.USD),
+				"currency":      util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						PlaceHoldTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        decimalAmount(amount, util.USD),
+				"currency":      util.USD,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, holdURI, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestGetHoldAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	hold := randomHold(account1.ID, account2.ID)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(hold, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotHold gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotHold)
+				require.NoError(t, err)
+				require.Equal(t, hold.ID, int64(gotHold["id"].(float64)))
+				require.Equal(t, money.New(hold.Amount, account1.Currency).String(), gotHold["amount"])
+				require.Equal(t, account1.Currency, gotHold["currency"])
+				require.Equal(t, hold.Status, gotHold["status"])
+				require.Nil(t, gotHold["transfer_id"])
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(hold, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.Hold{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v", holdURI, hold.ID)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestCaptureHoldAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.Currency = account1.Currency
+	hold := randomHold(account1.ID, account2.ID)
+
+	captured := db.CaptureHoldTxResult{
+		Hold: hold,
+		TransferTxResult: db.TransferTxResult{
+			Transfer: db.Transfer{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        hold.Amount,
+			},
+			FromAccount: account1,
+			ToAccount:   account2,
+		},
+	}
+
+	// Stubs for loading the hold and its recipient.
+	getHold := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+			Times(1).
+			Return(hold, nil)
+
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+			Times(1).
+			Return(account2, nil)
+	}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					arg := db.CaptureHoldTxParams{
+						HoldID: hold.ID,
+					}
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(captured, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp struct {
+						Hold     gin.H `json:"hold"`
+						Transfer gin.H `json:"transfer"`
+					}
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					amount := money.New(hold.Amount, account2.Currency).String()
+					require.Equal(t, amount, rsp.Hold["amount"])
+					require.Equal(t, amount, rsp.Transfer["amount"])
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "Partial",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					arg := db.CaptureHoldTxParams{
+						HoldID: hold.ID,
+						Amount: 1,
+					}
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(captured, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "PayerCannotCapture",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotActive",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CaptureHoldTxResult{}, db.ErrHoldNotActive)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusConflict, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "ExceedsHold",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CaptureHoldTxResult{}, db.ErrCaptureExceedsHold)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(hold.Amount+1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidAmount",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getHold(store)
+
+					store.EXPECT().
+						CaptureHoldTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(-1, account2.Currency),
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/capture", holdURI, hold.ID)
+			return newJSONRequest(http.MethodPost, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestVoidHoldAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	hold := randomHold(account1.ID, account2.ID)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(hold, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				store.EXPECT().
+					VoidHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{Hold: hold, Account: account1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotActive",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(hold, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				store.EXPECT().
+					VoidHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrHoldNotActive)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VoidHoldTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/void", holdURI, hold.ID)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,17 +14,21 @@ import (
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/wiliamhw/simplebank/db/mock"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/notify"
 	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
+// Username of the operators calling the /admin endpoints in tests.
+const testAdminUsername = "admin"
+
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, notify.NewHub())
 	require.NoError(t, err)
 
 	return server
@@ -34,6 +40,19 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// Builds a request with an optional JSON body.
+func newJSONRequest(method, url string, body gin.H) (*http.Request, error) {
+	if body == nil {
+		return http.NewRequest(method, url, nil)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequest(method, url, bytes.NewReader(data))
+}
+
 type baseTestCase struct {
 	name          string
 	buildStubs    func(store *mockdb.MockStore) // Assert DB query calls.
@@ -66,3 +85,16 @@ func (btc *baseTestCase) runTestCase(
 		btc.checkResponse(t, recorder)
 	})
 }
+
+// Checks that the response is an error envelope of the given catalogue entry.
+func requireErrorCode(t *testing.T, recorder *httptest.ResponseRecorder, code errorCode) errorBody {
+	require.Equal(t, code.status, recorder.Code)
+
+	var envelope errorEnvelope
+	err := json.Unmarshal(recorder.Body.Bytes(), &envelope)
+	require.NoError(t, err)
+	require.Equal(t, code.code, envelope.Error.Code)
+	require.NotEmpty(t, envelope.Error.RequestID)
+	require.Equal(t, recorder.Header().Get(requestIDHeader), envelope.Error.RequestID)
+	return envelope.Error
+}
@@ -3,17 +3,22 @@ package api
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+
+	requestIDHeader    = "X-Request-ID"
+	requestIDKey       = "request_id"
+	maxRequestIDLength = 128
 )
 
 // AuthMiddleware creates a gin middleware for authorization
@@ -23,28 +28,32 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 
 		if len(authorizationHeader) == 0 {
 			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.Error(newAPIError(codeUnauthenticated, err))
+			ctx.Abort()
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
 			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.Error(newAPIError(codeUnauthenticated, err))
+			ctx.Abort()
 			return
 		}
 
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
 			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.Error(newAPIError(codeUnauthenticated, err))
+			ctx.Abort()
 			return
 		}
 
 		accessToken := fields[1]
 		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			ctx.Error(newAPIError(codeUnauthenticated, err))
+			ctx.Abort()
 			return
 		}
 
@@ -52,3 +61,81 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// RequireRole only lets users with one of the given roles through.
+// It must run after authMiddleware.
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !util.InArray(roles, payload.Role) {
+			err := fmt.Errorf("one of the roles %s is required", strings.Join(roles, ", "))
+			ctx.Error(newAPIError(codeForbidden, err))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// PermissionMiddleware checks the role of the user against the permission
+// matrix, for the permission of the matched /admin route.
+// It must run after authMiddleware.
+func permissionMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.Request.Method + " " + strings.TrimPrefix(ctx.FullPath(), apiVersionPrefix)
+		perm, ok := adminRoutePermissions[route]
+		if !ok {
+			err := fmt.Errorf("no permission grants access to %s", route)
+			ctx.Error(newAPIError(codeForbidden, err))
+			ctx.Abort()
+			return
+		}
+
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !hasPermission(payload.Role, perm) {
+			err := fmt.Errorf("the %s permission is required", perm)
+			ctx.Error(newAPIError(codeForbidden, err))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// MoneyFormatMiddleware reads the money format requested by the client.
+// Without the header, amounts are decimal strings.
+func moneyFormatMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format := ctx.GetHeader(moneyFormatHeader)
+		switch format {
+		case "", moneyFormatDecimal:
+			ctx.Set(moneyMinorUnitsKey, false)
+		case moneyFormatMinorUnits:
+			ctx.Set(moneyMinorUnitsKey, true)
+		default:
+			err := fmt.Errorf("unsupported %s %q", moneyFormatHeader, format)
+			ctx.Error(newAPIError(codeInvalidRequest, err))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequestIDMiddleware tags each request with an ID, echoed in the response and in errors.
+// The ID sent by the client, like one set by a proxy, is kept.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
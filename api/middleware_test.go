@@ -8,12 +8,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
 )
 
 const authPath = "/auth"
 
+// Authorizes the request as a customer.
 func addAuthorization(
 	t *testing.T,
 	request *http.Request,
@@ -22,7 +25,19 @@ func addAuthorization(
 	username string,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenMaker.CreateToken(username, duration)
+	addRoleAuthorization(t, request, tokenMaker, authorizationType, username, util.CustomerRole, duration)
+}
+
+func addRoleAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(username, role, uuid.Nil, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
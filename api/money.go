@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wiliamhw/simplebank/money"
+)
+
+/**
+ * Clients choose how amounts are written with the Money-Format header.
+ * By default they are decimal strings in the currency, like "12.34".
+ * With minor-units, they stay integers of minor units, like 1234,
+ * for the clients written before amounts were decimal.
+ */
+const (
+	moneyFormatHeader     = "Money-Format"
+	moneyFormatDecimal    = "decimal"
+	moneyFormatMinorUnits = "minor-units"
+	moneyMinorUnitsKey    = "money_minor_units"
+)
+
+// Tells whether the client asked for amounts in minor units.
+func minorUnits(ctx *gin.Context) bool {
+	return ctx.GetBool(moneyMinorUnitsKey)
+}
+
+// Money in a response, written as a decimal string,
+// or as an integer of minor units in compatibility mode.
+type moneyField struct {
+	money.Money
+	minorUnits bool
+}
+
+func newMoneyField(amount int64, currency string, minorUnits bool) moneyField {
+	return moneyField{Money: money.New(amount, currency), minorUnits: minorUnits}
+}
+
+func (field moneyField) MarshalJSON() ([]byte, error) {
+	if field.minorUnits {
+		return json.Marshal(field.Amount())
+	}
+	return field.Money.MarshalJSON()
+}
+
+/**
+ * An amount in a request body, kept as is until its currency is known.
+ * It must be a decimal string like "12.34",
+ * or an integer of minor units in compatibility mode.
+ */
+type requestAmount json.RawMessage
+
+func (amount *requestAmount) UnmarshalJSON(data []byte) error {
+	*amount = append((*amount)[:0], data...)
+	return nil
+}
+
+func (amount requestAmount) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(amount).MarshalJSON()
+}
+
+// Parses a request amount in the currency, which must be positive.
+func parseAmount(ctx *gin.Context, amount requestAmount, currency string) (money.Money, error) {
+	var m money.Money
+	if minorUnits(ctx) {
+		var units int64
+		if err := json.Unmarshal(amount, &units); err != nil {
+			return m, errors.New("amount must be an integer of minor units")
+		}
+		m = money.New(units, currency)
+	} else {
+		var text string
+		if err := json.Unmarshal(amount, &text); err != nil {
+			return m, errors.New("amount must be a decimal string")
+		}
+		var err error
+		if m, err = money.Parse(text, currency); err != nil {
+			return m, err
+		}
+	}
+
+	if !m.IsPositive() {
+		return m, errors.New("amount must be positive")
+	}
+	return m, nil
+}
@@ -0,0 +1,398 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Writes an amount of minor units as a decimal request amount.
+func decimalAmount(amount int64, currency string) requestAmount {
+	return requestAmount(strconv.Quote(money.New(amount, currency).String()))
+}
+
+func TestAccountMoneyFormat(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Balance = 1234
+	account.OverdraftLimit = 500
+	account.HeldBalance = 34
+
+	testCases := []struct {
+		base   baseTestCase
+		format string
+	}{
+		{
+			base: baseTestCase{
+				name: "Decimal",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, "12.34", rsp["balance"])
+					require.Equal(t, "5.00", rsp["overdraft_limit"])
+					require.Equal(t, "0.34", rsp["held_balance"])
+					require.Equal(t, "17.00", rsp["available_balance"])
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "ExplicitDecimal",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, "12.34", rsp["balance"])
+				},
+			},
+			format: moneyFormatDecimal,
+		},
+		{
+			base: baseTestCase{
+				name: "MinorUnits",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, float64(1234), rsp["balance"])
+					require.Equal(t, float64(500), rsp["overdraft_limit"])
+					require.Equal(t, float64(34), rsp["held_balance"])
+					require.Equal(t, float64(1700), rsp["available_balance"])
+				},
+			},
+			format: moneyFormatMinorUnits,
+		},
+		{
+			base: baseTestCase{
+				name: "UnsupportedFormat",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			format: "float",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v", accountURI, account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			if tc.format != "" {
+				request.Header.Set(moneyFormatHeader, tc.format)
+			}
+			return request, nil
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestTransferMoneyFormat(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	// Stubs for a transfer of amount going through.
+	buildTransferStubs := func(store *mockdb.MockStore, amount int64) {
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+			Times(1).
+			Return(account1, nil)
+
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+			Times(1).
+			Return(account2, nil)
+
+		arg := db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		}
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Eq(arg)).
+			Times(1).
+			Return(db.TransferTxResult{
+				Transfer: db.Transfer{
+					ID:            util.RandomInt(1, 1000),
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				},
+				FromAccount: account1,
+				ToAccount:   account2,
+			}, nil)
+	}
+
+	// Rejected before any account is loaded.
+	buildNoStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Any()).
+			Times(0)
+
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []struct {
+		name          string
+		amount        interface{}
+		format        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Decimal",
+			amount: "12.34",
+			buildStubs: func(store *mockdb.MockStore) {
+				buildTransferStubs(store, 1234)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Transfer gin.H `json:"transfer"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, "12.34", rsp.Transfer["amount"])
+				require.Equal(t, util.USD, rsp.Transfer["currency"])
+			},
+		},
+		{
+			name:   "MinorUnits",
+			amount: 1234,
+			format: moneyFormatMinorUnits,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildTransferStubs(store, 1234)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Transfer gin.H `json:"transfer"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, float64(1234), rsp.Transfer["amount"])
+			},
+		},
+		{
+			name:       "TooManyDecimalPlaces",
+			amount:     "12.345",
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "Overflow",
+			amount:     "92233720368547758.08",
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "Zero",
+			amount:     "0.00",
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			// Would be read as whole dollars by a client expecting decimals.
+			name:       "IntegerInDecimalFormat",
+			amount:     1234,
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "DecimalInMinorUnitsFormat",
+			amount:     "12.34",
+			format:     moneyFormatMinorUnits,
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "NullAmount",
+			amount:     nil,
+			buildStubs: buildNoStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		base := baseTestCase{
+			name: tc.name,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs:    tc.buildStubs,
+			checkResponse: tc.checkResponse,
+		}
+		getRequest := func() (*http.Request, error) {
+			request, err := newJSONRequest(http.MethodPost, transferURI, gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if tc.format != "" {
+				request.Header.Set(moneyFormatHeader, tc.format)
+			}
+			return request, nil
+		}
+		base.runTestCase(t, getRequest)
+	}
+}
+
+func TestFxQuoteMoneyFormat(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		amount     interface{}
+		format     string
+		fromAmount interface{}
+		toAmount   interface{}
+	}{
+		{
+			name:       "Decimal",
+			amount:     "12.34",
+			fromAmount: "12.34",
+			toAmount:   "11.35",
+		},
+		{
+			name:       "MinorUnits",
+			amount:     1234,
+			format:     moneyFormatMinorUnits,
+			fromAmount: float64(1234),
+			toAmount:   float64(1135),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		base := baseTestCase{
+			name: tc.name,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFxRate(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FxRate{FromCurrency: util.USD, ToCurrency: util.EUR, Rate: "0.92"}, nil)
+
+				store.EXPECT().
+					CreateFxQuote(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateFxQuoteParams) (db.FxQuote, error) {
+						return quoteOf(arg), nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotQuote gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotQuote)
+				require.NoError(t, err)
+				require.Equal(t, tc.fromAmount, gotQuote["from_amount"])
+				require.Equal(t, tc.toAmount, gotQuote["to_amount"])
+			},
+		}
+		getRequest := func() (*http.Request, error) {
+			request, err := newJSONRequest(http.MethodPost, "/v1/fx/quotes", gin.H{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"amount":        tc.amount,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if tc.format != "" {
+				request.Header.Set(moneyFormatHeader, tc.format)
+			}
+			return request, nil
+		}
+		base.runTestCase(t, getRequest)
+	}
+}
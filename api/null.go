@@ -0,0 +1,30 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+)
+
+// The helpers below turn nullable columns into pointers,
+// which are encoded as JSON null instead of {"Valid": false}.
+
+func nullInt64(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
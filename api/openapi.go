@@ -0,0 +1,863 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const (
+	openAPIVersion   = "3.0.3"
+	openAPIPath      = "/openapi.json"
+	bearerAuthScheme = "bearerAuth"
+)
+
+/**
+ * Describes the request and response of a route.
+ * The uri, query and body fields hold zero values of the structs the handler binds,
+ * so the spec follows their tags, including the binding rules.
+ * Every route registered in setupRouter must have an entry here, keyed without the version prefix.
+ */
+type routeDoc struct {
+	summary  string
+	public   bool
+	uri      interface{}
+	query    interface{}
+	body     interface{}
+	headers  []openAPIParameter
+	response interface{}
+
+	// The response is a text/event-stream whose events hold the response value.
+	stream bool
+}
+
+var routeDocs = map[string]routeDoc{
+	"POST /users": {
+		summary:  "Create a user",
+		public:   true,
+		body:     createUserRequest{},
+		response: userResponse{},
+	},
+	"POST /users/login": {
+		summary:  "Log in and open a session",
+		public:   true,
+		body:     loginUserRequest{},
+		response: loginUserResponse{},
+	},
+	"POST /tokens/renew_access": {
+		summary:  "Renew an access token, rotating the refresh token",
+		public:   true,
+		body:     renewAccessTokenRequest{},
+		response: renewAccessTokenResponse{},
+	},
+
+	"POST /users/logout": {
+		summary:  "Log out of the current session",
+		response: sessionResponse{},
+	},
+	"POST /users/logout_all": {
+		summary:  "Log out of every session",
+		response: logoutAllSessionsResponse{},
+	},
+	"GET /sessions": {
+		summary:  "List the sessions of the current user",
+		response: []userSessionResponse{},
+	},
+	"DELETE /sessions/:id": {
+		summary:  "Revoke a session of the current user",
+		uri:      revokeSessionRequest{},
+		response: sessionResponse{},
+	},
+
+	"GET /accounts": {
+		summary:  "List the accounts of the user",
+		query:    listAccountRequest{},
+		response: []accountResponse{},
+	},
+	"GET /accounts/:id": {
+		summary:  "Get an account",
+		uri:      getAccountRequest{},
+		response: accountResponse{},
+	},
+	"GET /accounts/:id/entries": {
+		summary:  "Get the statement of an account",
+		uri:      listAccountEntriesRequestUri{},
+		query:    listAccountEntriesRequestQuery{},
+		response: listAccountEntriesResponse{},
+	},
+	"GET /accounts/:id/events": {
+		summary: "Stream the new entries of an account",
+		uri:     streamAccountEventsRequestUri{},
+		query:   streamAccountEventsRequestQuery{},
+		headers: []openAPIParameter{{
+			Name:        lastEventIDHeader,
+			In:          "header",
+			Description: "ID of the last entry received, entries after it are sent first.",
+			Schema:      &openAPISchema{Type: "integer", Format: "int64"},
+		}},
+		response: accountEventResponse{},
+		stream:   true,
+	},
+	"POST /accounts": {
+		summary:  "Open an account",
+		body:     createAccountRequest{},
+		response: accountResponse{},
+	},
+	"POST /accounts/:id/deposits": {
+		summary:  "Deposit cash into an account",
+		uri:      cashRequestUri{},
+		body:     cashRequestBody{},
+		response: cashTxResponse{},
+	},
+	"POST /accounts/:id/withdrawals": {
+		summary:  "Withdraw cash from an account",
+		uri:      cashRequestUri{},
+		body:     cashRequestBody{},
+		response: cashTxResponse{},
+	},
+	"POST /accounts/:id/freeze": {
+		summary:  "Freeze an account",
+		uri:      accountStatusRequest{},
+		response: accountResponse{},
+	},
+	"POST /accounts/:id/unfreeze": {
+		summary:  "Unfreeze an account",
+		uri:      accountStatusRequest{},
+		response: accountResponse{},
+	},
+	"POST /accounts/:id/close": {
+		summary:  "Close an account, sweeping its balance to another account",
+		uri:      closeAccountRequestUri{},
+		body:     closeAccountRequestBody{},
+		response: closeAccountTxResponse{},
+	},
+
+	"GET /transfers": {
+		summary:  "List the transfers of the user",
+		query:    listTransferRequest{},
+		response: []transferResponse{},
+	},
+	"GET /transfers/:id": {
+		summary:  "Get a transfer",
+		uri:      getTransferRequest{},
+		response: transferResponse{},
+	},
+	"POST /transfers": {
+		summary: "Transfer money between accounts",
+		body:    transferRequest{},
+		headers: []openAPIParameter{{
+			Name:        idempotencyKeyHeader,
+			In:          "header",
+			Description: "Retries with the same key return the first result. Keys starting with " + db.ScheduledRunKeyPrefix + " are reserved.",
+			Schema:      &openAPISchema{Type: "string", MaxLength: intPtr(maxIdempotencyKeyLength)},
+		}},
+		response: transferTxResponse{},
+	},
+	"POST /transfers/:id/reversals": {
+		summary:  "Reverse a transfer, fully or partially",
+		uri:      reverseTransferRequestUri{},
+		body:     reverseTransferRequestBody{},
+		response: transferTxResponse{},
+	},
+
+	"GET /scheduled-transfers": {
+		summary:  "List the scheduled transfers of the user",
+		query:    listScheduledTransferRequest{},
+		response: []scheduledTransferResponse{},
+	},
+	"GET /scheduled-transfers/:id": {
+		summary:  "Get a scheduled transfer",
+		uri:      scheduledTransferUriRequest{},
+		response: scheduledTransferResponse{},
+	},
+	"POST /scheduled-transfers": {
+		summary:  "Schedule a transfer",
+		body:     createScheduledTransferRequest{},
+		response: scheduledTransferResponse{},
+	},
+	"PUT /scheduled-transfers/:id": {
+		summary:  "Update a scheduled transfer",
+		uri:      scheduledTransferUriRequest{},
+		body:     updateScheduledTransferRequestBody{},
+		response: scheduledTransferResponse{},
+	},
+	"DELETE /scheduled-transfers/:id": {
+		summary:  "Cancel a scheduled transfer",
+		uri:      scheduledTransferUriRequest{},
+		response: scheduledTransferResponse{},
+	},
+
+	"GET /holds/:id": {
+		summary:  "Get a hold",
+		uri:      getHoldRequest{},
+		response: holdResponse{},
+	},
+	"POST /holds": {
+		summary:  "Hold money of an account",
+		body:     createHoldRequest{},
+		response: holdTxResponse{},
+	},
+	"POST /holds/:id/capture": {
+		summary:  "Capture a hold, fully or partially",
+		uri:      captureHoldRequestUri{},
+		body:     captureHoldRequestBody{},
+		response: captureHoldTxResponse{},
+	},
+	"POST /holds/:id/void": {
+		summary:  "Release a hold",
+		uri:      voidHoldRequest{},
+		response: holdTxResponse{},
+	},
+
+	"GET /webhooks": {
+		summary:  "List the webhooks of the user",
+		query:    listWebhookRequest{},
+		response: []webhookResponse{},
+	},
+	"GET /webhooks/:id": {
+		summary:  "Get a webhook",
+		uri:      webhookUriRequest{},
+		response: webhookResponse{},
+	},
+	"POST /webhooks": {
+		summary:  "Subscribe a URL to events",
+		body:     webhookRequest{},
+		response: createWebhookResponse{},
+	},
+	"PUT /webhooks/:id": {
+		summary:  "Update a webhook",
+		uri:      webhookUriRequest{},
+		body:     updateWebhookRequestBody{},
+		response: webhookResponse{},
+	},
+	"DELETE /webhooks/:id": {
+		summary:  "Delete a webhook",
+		uri:      webhookUriRequest{},
+		response: webhookResponse{},
+	},
+	"GET /webhooks/:id/deliveries": {
+		summary:  "List the deliveries of a webhook",
+		uri:      webhookUriRequest{},
+		query:    listWebhookDeliveryQuery{},
+		response: []webhookDeliveryResponse{},
+	},
+	"POST /webhooks/:id/deliveries/:delivery_id/redeliver": {
+		summary:  "Send a delivery again",
+		uri:      webhookDeliveryUriRequest{},
+		response: webhookDeliveryResponse{},
+	},
+
+	"GET /currencies": {
+		summary:  "List the enabled currencies",
+		response: []db.Currency{},
+	},
+	"GET /fees": {
+		summary:  "List the fee schedules",
+		response: []db.FeeSchedule{},
+	},
+	"GET /fx/rates": {
+		summary:  "List the exchange rates",
+		response: []db.FxRate{},
+	},
+	"POST /fx/quotes": {
+		summary:  "Quote a currency conversion",
+		body:     createFxQuoteRequest{},
+		response: fxQuoteResponse{},
+	},
+	"POST /fx/conversions": {
+		summary:  "Convert money between accounts with a quote",
+		body:     createFxConversionRequest{},
+		response: convertTxResponse{},
+	},
+
+	"GET /admin/currencies": {
+		summary:  "List all currencies",
+		response: []db.Currency{},
+	},
+	"POST /admin/currencies": {
+		summary:  "Add a currency",
+		body:     createCurrencyRequest{},
+		response: db.Currency{},
+	},
+	"PUT /admin/currencies/:code": {
+		summary:  "Update a currency",
+		uri:      updateCurrencyRequestUri{},
+		body:     updateCurrencyRequestBody{},
+		response: db.Currency{},
+	},
+	"PUT /admin/fees": {
+		summary:  "Create or update a fee schedule",
+		body:     upsertFeeScheduleRequest{},
+		response: db.FeeSchedule{},
+	},
+	"DELETE /admin/fees/:id": {
+		summary:  "Delete a fee schedule",
+		uri:      deleteFeeScheduleRequest{},
+		response: db.FeeSchedule{},
+	},
+	"PUT /admin/fx/rates": {
+		summary:  "Create or update an exchange rate",
+		body:     upsertFxRateRequest{},
+		response: db.FxRate{},
+	},
+	"GET /admin/risk-decisions": {
+		summary:  "List the risk decisions",
+		query:    listRiskDecisionsRequest{},
+		response: []db.RiskDecision{},
+	},
+	"GET /admin/risk-decisions/:id": {
+		summary:  "Get a risk decision",
+		uri:      getRiskDecisionRequest{},
+		response: db.RiskDecision{},
+	},
+	"GET /admin/transfer-limits": {
+		summary:  "List the transfer limits",
+		response: []db.TransferLimit{},
+	},
+	"PUT /admin/transfer-limits": {
+		summary:  "Create or update a transfer limit",
+		body:     upsertTransferLimitRequest{},
+		response: db.TransferLimit{},
+	},
+	"PUT /admin/users/:username/tier": {
+		summary:  "Change the tier of a user",
+		uri:      updateUserTierRequestUri{},
+		body:     updateUserTierRequestBody{},
+		response: userResponse{},
+	},
+	"GET /admin/users": {
+		summary:  "List the users",
+		query:    listUsersRequest{},
+		response: []userResponse{},
+	},
+	"PUT /admin/users/:username/role": {
+		summary:  "Change the role of a user",
+		uri:      updateUserRoleRequestUri{},
+		body:     updateUserRoleRequestBody{},
+		response: userResponse{},
+	},
+	"GET /admin/users/:username/sessions": {
+		summary:  "List the sessions of a user",
+		uri:      listUserSessionsRequest{},
+		response: []sessionResponse{},
+	},
+	"POST /admin/sessions/:id/block": {
+		summary:  "Block a session",
+		uri:      blockSessionRequest{},
+		response: sessionResponse{},
+	},
+	"GET /admin/accounts/:id": {
+		summary:  "Get any account",
+		uri:      getAccountRequest{},
+		response: accountResponse{},
+	},
+	"POST /admin/accounts/:id/freeze": {
+		summary:  "Freeze any account",
+		uri:      accountStatusRequest{},
+		response: accountResponse{},
+	},
+	"POST /admin/accounts/:id/unfreeze": {
+		summary:  "Unfreeze any account",
+		uri:      accountStatusRequest{},
+		response: accountResponse{},
+	},
+	"POST /admin/transfers/:id/reversals": {
+		summary:  "Reverse any transfer, fully or partially",
+		uri:      reverseTransferRequestUri{},
+		body:     reverseTransferRequestBody{},
+		response: transferTxResponse{},
+	},
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas"`
+	Parameters      map[string]openAPIParameter      `json:"parameters"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Security    []map[string][]string      `json:"security"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Ref         string         `json:"$ref,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	In          string         `json:"in,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref              string                    `json:"$ref,omitempty"`
+	Type             string                    `json:"type,omitempty"`
+	Format           string                    `json:"format,omitempty"`
+	Description      string                    `json:"description,omitempty"`
+	Pattern          string                    `json:"pattern,omitempty"`
+	Enum             []interface{}             `json:"enum,omitempty"`
+	Minimum          *float64                  `json:"minimum,omitempty"`
+	Maximum          *float64                  `json:"maximum,omitempty"`
+	ExclusiveMinimum bool                      `json:"exclusiveMinimum,omitempty"`
+	MinLength        *int                      `json:"minLength,omitempty"`
+	MaxLength        *int                      `json:"maxLength,omitempty"`
+	MinItems         *int                      `json:"minItems,omitempty"`
+	MaxItems         *int                      `json:"maxItems,omitempty"`
+	Nullable         bool                      `json:"nullable,omitempty"`
+	Items            *openAPISchema            `json:"items,omitempty"`
+	Properties       map[string]*openAPISchema `json:"properties,omitempty"`
+	Required         []string                  `json:"required,omitempty"`
+	OneOf            []*openAPISchema          `json:"oneOf,omitempty"`
+}
+
+/**
+ * Builds the OpenAPI document of the given routes from routeDocs.
+ * Paths are relative to the version prefix, given as the server URL.
+ * Routes without an entry are left out, which the tests catch,
+ * as are the routes outside the prefix, like the documentation itself.
+ */
+func newOpenAPIDocument(routes gin.RoutesInfo) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:   "Simple Bank API",
+			Version: "1.0.0",
+		},
+		Servers: []openAPIServer{{URL: apiVersionPrefix}},
+		Paths:   map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			Schemas: map[string]*openAPISchema{
+				"Money": {
+					Description: "A decimal string in the currency unit, " +
+						"or an integer in minor units when the Money-Format header is minor-units.",
+					OneOf: []*openAPISchema{
+						{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?$`},
+						{Type: "integer", Format: "int64"},
+					},
+				},
+				"Error": schemaOf(reflect.TypeOf(errorEnvelope{})),
+			},
+			Parameters: map[string]openAPIParameter{
+				"MoneyFormat": {
+					Name:        moneyFormatHeader,
+					In:          "header",
+					Description: "Format of the amounts in the request and response.",
+					Schema: &openAPISchema{
+						Type: "string",
+						Enum: []interface{}{moneyFormatDecimal, moneyFormatMinorUnits},
+					},
+				},
+			},
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				bearerAuthScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, apiVersionPrefix+"/") {
+			continue
+		}
+		route.Path = strings.TrimPrefix(route.Path, apiVersionPrefix)
+
+		spec, ok := routeDocs[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+
+		path := openAPIPathOf(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = newOpenAPIOperation(route, spec)
+	}
+
+	return doc
+}
+
+func newOpenAPIOperation(route gin.RouteInfo, spec routeDoc) *openAPIOperation {
+	operation := &openAPIOperation{
+		OperationID: operationIDOf(route.Handler),
+		Summary:     spec.summary,
+		Tags:        []string{strings.Split(strings.Trim(route.Path, "/"), "/")[0]},
+		Security:    []map[string][]string{{bearerAuthScheme: {}}},
+		Parameters:  []openAPIParameter{{Ref: "#/components/parameters/MoneyFormat"}},
+		Responses: map[string]openAPIResponse{
+			"default": {
+				Description: "Error",
+				Content: map[string]openAPIMediaType{
+					"application/json": {Schema: &openAPISchema{Ref: "#/components/schemas/Error"}},
+				},
+			},
+		},
+	}
+	if spec.public {
+		operation.Security = []map[string][]string{}
+	}
+	if perm, ok := adminRoutePermissions[route.Method+" "+route.Path]; ok {
+		operation.Description = fmt.Sprintf("Requires the %s permission, granted to: %s.",
+			perm, strings.Join(permissionRoles[perm], ", "),
+		)
+	}
+
+	operation.Parameters = append(operation.Parameters, pathParameters(route.Path, spec.uri)...)
+	if spec.query != nil {
+		operation.Parameters = append(operation.Parameters, structParameters(spec.query, "query", "form")...)
+	}
+	operation.Parameters = append(operation.Parameters, spec.headers...)
+
+	if spec.body != nil {
+		operation.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content: map[string]openAPIMediaType{
+				"application/json": {Schema: schemaOf(reflect.TypeOf(spec.body))},
+			},
+		}
+	}
+
+	contentType := "application/json"
+	if spec.stream {
+		contentType = "text/event-stream"
+	}
+	operation.Responses["200"] = openAPIResponse{
+		Description: "OK",
+		Content: map[string]openAPIMediaType{
+			contentType: {Schema: schemaOf(reflect.TypeOf(spec.response))},
+		},
+	}
+
+	return operation
+}
+
+// Converts the gin path parameters, such as /accounts/:id, to /accounts/{id}.
+func openAPIPathOf(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Takes the method name out of a handler name like "...api.(*Server).getAccount-fm".
+func operationIDOf(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// Path parameters not bound by the uri struct are still listed, as plain strings.
+func pathParameters(path string, uri interface{}) []openAPIParameter {
+	var params []openAPIParameter
+	if uri != nil {
+		params = structParameters(uri, "path", "uri")
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name, found := segment[1:], false
+		for _, param := range params {
+			found = found || param.Name == name
+		}
+		if !found {
+			params = append(params, openAPIParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openAPISchema{Type: "string"},
+			})
+		}
+	}
+
+	return params
+}
+
+func structParameters(v interface{}, in string, tagKey string) []openAPIParameter {
+	schema := objectSchema(reflect.TypeOf(v), tagKey)
+
+	var params []openAPIParameter
+	for _, field := range structFields(reflect.TypeOf(v), tagKey) {
+		params = append(params, openAPIParameter{
+			Name:     field.name,
+			In:       in,
+			Required: in == "path" || util.InArray(schema.Required, field.name),
+			Schema:   schema.Properties[field.name],
+		})
+	}
+	return params
+}
+
+type structField struct {
+	name string
+	reflect.StructField
+}
+
+// Lists the fields named by the tag, flattening embedded structs like encoding/json does.
+func structFields(t reflect.Type, tagKey string) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(tagKey), ",")[0]
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, structFields(field.Type, tagKey)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, structField{name, field})
+	}
+	return fields
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	nullUUIDType      = reflect.TypeOf(uuid.NullUUID{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	moneyFieldType    = reflect.TypeOf(moneyField{})
+	requestAmountType = reflect.TypeOf(requestAmount{})
+)
+
+// Reflects the JSON encoding of a type into a schema.
+func schemaOf(t reflect.Type) *openAPISchema {
+	switch t {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &openAPISchema{Type: "string", Format: "uuid"}
+	case nullUUIDType:
+		return &openAPISchema{Type: "string", Format: "uuid", Nullable: true}
+	case rawMessageType:
+		return &openAPISchema{}
+	case moneyFieldType, requestAmountType:
+		return &openAPISchema{Ref: "#/components/schemas/Money"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaOf(t.Elem())
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object"}
+	case reflect.Struct:
+		return objectSchema(t, "json")
+	}
+	return &openAPISchema{}
+}
+
+func objectSchema(t reflect.Type, tagKey string) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	for _, field := range structFields(t, tagKey) {
+		property := schemaOf(field.Type)
+		if applyBinding(property, field.Tag.Get("binding"), func(name string) string {
+			return fieldName(t, name, tagKey)
+		}) {
+			schema.Required = append(schema.Required, field.name)
+		}
+		schema.Properties[field.name] = property
+	}
+	return schema
+}
+
+// Looks up the tag name of a struct field, for rules like nefield.
+func fieldName(t reflect.Type, name string, tagKey string) string {
+	for _, field := range structFields(t, tagKey) {
+		if field.Name == name {
+			return field.name
+		}
+	}
+	return name
+}
+
+/**
+ * Translates the validator rules of a binding tag into the schema.
+ * Rules after dive apply to the items of an array.
+ * Returns whether the field is required.
+ */
+func applyBinding(schema *openAPISchema, tag string, fieldName func(string) string) (required bool) {
+	if tag == "" {
+		return false
+	}
+
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = required || target == schema
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			}
+		case "min":
+			setMinimum(target, param)
+		case "max":
+			setMaximum(target, param)
+		case "len":
+			setMinimum(target, param)
+			setMaximum(target, param)
+		case "gt":
+			setMinimum(target, param)
+			target.ExclusiveMinimum = true
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.ParseInt(value, 10, 64); err == nil && target.Type == "integer" {
+					target.Enum = append(target.Enum, n)
+					continue
+				}
+				target.Enum = append(target.Enum, value)
+			}
+		case "alpha":
+			target.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		case "uppercase":
+			if target.Pattern == "" {
+				target.Pattern = "^[^a-z]*$"
+			}
+			target.Pattern = strings.ReplaceAll(target.Pattern, "a-zA-Z", "A-Z")
+		case "numeric":
+			if target.Type == "string" {
+				target.Pattern = `^[-+]?[0-9]+(\.[0-9]+)?$`
+			}
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "uuid":
+			target.Format = "uuid"
+		case "currency":
+			target.Format = "currency"
+			target.Description = "Code of an enabled currency, see GET /currencies."
+		case "nefield":
+			target.Description = "Must differ from " + fieldName(param) + "."
+		}
+	}
+
+	return required
+}
+
+// Sets the lower bound the way the validator reads it for the type:
+// the value of numbers, the length of strings and the size of arrays.
+func setMinimum(schema *openAPISchema, param string) {
+	switch schema.Type {
+	case "string":
+		schema.MinLength = intPtr(atoi(param))
+	case "array":
+		schema.MinItems = intPtr(atoi(param))
+	default:
+		n, err := strconv.ParseFloat(param, 64)
+		if err == nil {
+			schema.Minimum = &n
+		}
+	}
+}
+
+func setMaximum(schema *openAPISchema, param string) {
+	switch schema.Type {
+	case "string":
+		schema.MaxLength = intPtr(atoi(param))
+	case "array":
+		schema.MaxItems = intPtr(atoi(param))
+	default:
+		n, err := strconv.ParseFloat(param, 64)
+		if err == nil {
+			schema.Maximum = &n
+		}
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// Serves the OpenAPI document of the HTTP API.
+func (server *Server) getOpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.openAPI)
+}
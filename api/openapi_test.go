@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+)
+
+// Routes serving the documentation, which are not part of it.
+var docsRoutes = map[string]bool{
+	"GET " + openAPIPath:           true,
+	"GET " + swaggerPath + "/*any": true,
+}
+
+func newDocsTestServer(t *testing.T) *Server {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	return newTestServer(t, mockdb.NewMockStore(ctrl))
+}
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	server := newDocsTestServer(t)
+
+	registered := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + route.Path
+		if docsRoutes[key] {
+			continue
+		}
+		require.True(t, strings.HasPrefix(route.Path, apiVersionPrefix+"/"), "route %s is not versioned", key)
+
+		path := strings.TrimPrefix(route.Path, apiVersionPrefix)
+		key = route.Method + " " + path
+		registered[key] = true
+
+		operation := server.openAPI.Paths[openAPIPathOf(path)][strings.ToLower(route.Method)]
+		require.NotNil(t, operation, "route %s is missing from the OpenAPI document, add it to routeDocs", key)
+		require.NotEmpty(t, operation.OperationID)
+		require.NotEmpty(t, operation.Summary)
+	}
+
+	for key := range routeDocs {
+		require.True(t, registered[key], "routeDocs entry %s is not a registered route", key)
+	}
+}
+
+func TestOpenAPIDocumentParameters(t *testing.T) {
+	server := newDocsTestServer(t)
+
+	operation := server.openAPI.Paths["/webhooks/{id}/deliveries/{delivery_id}/redeliver"]["post"]
+	require.Equal(t, "redeliverWebhookDelivery", operation.OperationID)
+	require.Equal(t, []string{"webhooks"}, operation.Tags)
+	require.Equal(t, []map[string][]string{{bearerAuthScheme: {}}}, operation.Security)
+
+	params := map[string]openAPIParameter{}
+	for _, param := range operation.Parameters {
+		params[param.Name] = param
+	}
+	for _, name := range []string{"id", "delivery_id"} {
+		require.Equal(t, "path", params[name].In)
+		require.True(t, params[name].Required)
+		require.Equal(t, "integer", params[name].Schema.Type)
+		require.Equal(t, 1.0, *params[name].Schema.Minimum)
+	}
+
+	operation = server.openAPI.Paths["/accounts"]["get"]
+	params = map[string]openAPIParameter{}
+	for _, param := range operation.Parameters {
+		params[param.Name] = param
+	}
+	require.Equal(t, "query", params["page_size"].In)
+	require.True(t, params["page_size"].Required)
+	require.Equal(t, 5.0, *params["page_size"].Schema.Minimum)
+	require.Equal(t, 10.0, *params["page_size"].Schema.Maximum)
+
+	operation = server.openAPI.Paths["/users/login"]["post"]
+	require.Empty(t, operation.Security)
+
+	// Admin operations name their permission.
+	operation = server.openAPI.Paths["/admin/accounts/{id}/unfreeze"]["post"]
+	require.Equal(t, "Requires the accounts:unfreeze permission, granted to: admin.", operation.Description)
+}
+
+func TestOpenAPIDocumentBindingRules(t *testing.T) {
+	server := newDocsTestServer(t)
+	bodyOf := func(path, method string) *openAPISchema {
+		operation := server.openAPI.Paths[path][method]
+		require.NotNil(t, operation.RequestBody)
+		return operation.RequestBody.Content["application/json"].Schema
+	}
+
+	schema := bodyOf("/users", "post")
+	require.ElementsMatch(t, []string{"username", "password", "full_name", "email"}, schema.Required)
+	require.Equal(t, "^[a-zA-Z0-9]+$", schema.Properties["username"].Pattern)
+	require.Equal(t, 6, *schema.Properties["password"].MinLength)
+	require.Equal(t, "email", schema.Properties["email"].Format)
+
+	schema = bodyOf("/accounts", "post")
+	require.Equal(t, []string{"currency"}, schema.Required)
+	require.Equal(t, "currency", schema.Properties["currency"].Format)
+
+	// Embedded structs are flattened, and amounts accept both money formats.
+	schema = bodyOf("/scheduled-transfers", "post")
+	require.Contains(t, schema.Required, "from_account_id")
+	require.Contains(t, schema.Required, "run_at")
+	require.Equal(t, "Must differ from to_account_id.", schema.Properties["from_account_id"].Description)
+	require.Equal(t, "#/components/schemas/Money", schema.Properties["amount"].Ref)
+	require.Equal(t, "date-time", schema.Properties["run_at"].Format)
+	require.Equal(t, []interface{}{"once", "weekly", "monthly"}, schema.Properties["recurrence"].Enum)
+
+	schema = bodyOf("/admin/currencies", "post")
+	require.Equal(t, 3, *schema.Properties["code"].MinLength)
+	require.Equal(t, 3, *schema.Properties["code"].MaxLength)
+	require.Equal(t, "^[A-Z]+$", schema.Properties["code"].Pattern)
+
+	schema = bodyOf("/holds", "post")
+	require.Contains(t, schema.Required, "amount")
+	require.Equal(t, "#/components/schemas/Money", schema.Properties["amount"].Ref)
+	require.NotContains(t, schema.Required, "expires_in_seconds")
+
+	schema = bodyOf("/holds/{id}/capture", "post")
+	require.NotContains(t, schema.Required, "amount")
+	require.Equal(t, "#/components/schemas/Money", schema.Properties["amount"].Ref)
+
+	// Rules after dive apply to the items.
+	schema = bodyOf("/webhooks", "post").Properties["event_types"]
+	require.Equal(t, "array", schema.Type)
+	require.Empty(t, schema.Enum)
+	require.Equal(t, []interface{}{"transfer.created", "account.created", "user.created"}, schema.Items.Enum)
+
+	response := server.openAPI.Paths["/accounts/{id}/events"]["get"].Responses["200"]
+	require.Contains(t, response.Content, "text/event-stream")
+}
+
+func TestServeOpenAPIDocument(t *testing.T) {
+	server := newDocsTestServer(t)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, openAPIPath, nil)
+	require.NoError(t, err)
+
+	// Served without authorization.
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &doc)
+	require.NoError(t, err)
+	require.Equal(t, openAPIVersion, doc["openapi"])
+	require.Equal(t, []interface{}{map[string]interface{}{"url": apiVersionPrefix}}, doc["servers"])
+	require.Contains(t, doc["paths"], "/accounts/{id}")
+	require.NotContains(t, doc["paths"], openAPIPath)
+}
+
+func TestServeSwaggerUI(t *testing.T) {
+	server := newDocsTestServer(t)
+
+	testCases := []struct {
+		name       string
+		url        string
+		statusCode int
+		contains   string
+	}{
+		{
+			name:       "Index",
+			url:        swaggerPath + "/",
+			statusCode: http.StatusOK,
+			contains:   "swagger-ui",
+		},
+		{
+			name:       "Initializer",
+			url:        swaggerPath + "/swagger-initializer.js",
+			statusCode: http.StatusOK,
+			contains:   `url: "` + openAPIPath + `"`,
+		},
+		{
+			name:       "Asset",
+			url:        swaggerPath + "/swagger-ui.css",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "NotFound",
+			url:        swaggerPath + "/missing.js",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+			require.Contains(t, recorder.Body.String(), tc.contains)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// An action of the /admin endpoints that only some roles may take.
+type permission string
+
+const (
+	permissionReadUsers            permission = "users:read"
+	permissionManageUserRoles      permission = "users:manage_roles"
+	permissionManageUserTiers      permission = "users:manage_tiers"
+	permissionReadSessions         permission = "sessions:read"
+	permissionBlockSessions        permission = "sessions:block"
+	permissionReadAccounts         permission = "accounts:read"
+	permissionFreezeAccounts       permission = "accounts:freeze"
+	permissionUnfreezeAccounts     permission = "accounts:unfreeze"
+	permissionReverseTransfers     permission = "transfers:reverse"
+	permissionReadCurrencies       permission = "currencies:read"
+	permissionManageCurrencies     permission = "currencies:manage"
+	permissionManageFees           permission = "fees:manage"
+	permissionManageFxRates        permission = "fx_rates:manage"
+	permissionReadRiskDecisions    permission = "risk_decisions:read"
+	permissionReadTransferLimits   permission = "transfer_limits:read"
+	permissionManageTransferLimits permission = "transfer_limits:manage"
+)
+
+/**
+ * The permission matrix, the roles granted each permission.
+ * Support can look into users and accounts, reverse a mistaken transfer
+ * and stop an ongoing fraud, by freezing an account or blocking a session,
+ * but only admins can undo it or change the configuration of the bank.
+ */
+var permissionRoles = map[permission][]string{
+	permissionReadUsers:            {util.SupportRole, util.AdminRole},
+	permissionManageUserRoles:      {util.AdminRole},
+	permissionManageUserTiers:      {util.AdminRole},
+	permissionReadSessions:         {util.SupportRole, util.AdminRole},
+	permissionBlockSessions:        {util.SupportRole, util.AdminRole},
+	permissionReadAccounts:         {util.SupportRole, util.AdminRole},
+	permissionFreezeAccounts:       {util.SupportRole, util.AdminRole},
+	permissionUnfreezeAccounts:     {util.AdminRole},
+	permissionReverseTransfers:     {util.SupportRole, util.AdminRole},
+	permissionReadCurrencies:       {util.SupportRole, util.AdminRole},
+	permissionManageCurrencies:     {util.AdminRole},
+	permissionManageFees:           {util.AdminRole},
+	permissionManageFxRates:        {util.AdminRole},
+	permissionReadRiskDecisions:    {util.SupportRole, util.AdminRole},
+	permissionReadTransferLimits:   {util.SupportRole, util.AdminRole},
+	permissionManageTransferLimits: {util.AdminRole},
+}
+
+// Roles allowed into the /admin endpoints, before checking their permissions.
+var operatorRoles = []string{util.SupportRole, util.AdminRole}
+
+// Permission required by each /admin route, keyed like routeDocs.
+// Routes missing from it are denied to everyone.
+var adminRoutePermissions = map[string]permission{
+	"GET /admin/users":                    permissionReadUsers,
+	"PUT /admin/users/:username/role":     permissionManageUserRoles,
+	"PUT /admin/users/:username/tier":     permissionManageUserTiers,
+	"GET /admin/users/:username/sessions": permissionReadSessions,
+	"POST /admin/sessions/:id/block":      permissionBlockSessions,
+	"GET /admin/accounts/:id":             permissionReadAccounts,
+	"POST /admin/accounts/:id/freeze":     permissionFreezeAccounts,
+	"POST /admin/accounts/:id/unfreeze":   permissionUnfreezeAccounts,
+	"POST /admin/transfers/:id/reversals": permissionReverseTransfers,
+	"GET /admin/currencies":               permissionReadCurrencies,
+	"POST /admin/currencies":              permissionManageCurrencies,
+	"PUT /admin/currencies/:code":         permissionManageCurrencies,
+	"PUT /admin/fees":                     permissionManageFees,
+	"DELETE /admin/fees/:id":              permissionManageFees,
+	"PUT /admin/fx/rates":                 permissionManageFxRates,
+	"GET /admin/risk-decisions":           permissionReadRiskDecisions,
+	"GET /admin/risk-decisions/:id":       permissionReadRiskDecisions,
+	"GET /admin/transfer-limits":          permissionReadTransferLimits,
+	"PUT /admin/transfer-limits":          permissionManageTransferLimits,
+}
+
+// Tells whether the role is granted the permission.
+func hasPermission(role string, perm permission) bool {
+	return util.InArray(permissionRoles[perm], role)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestAdminRoutePermissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	registered := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		path := strings.TrimPrefix(route.Path, apiVersionPrefix)
+		if !strings.HasPrefix(path, "/admin/") {
+			continue
+		}
+		key := route.Method + " " + path
+		registered[key] = true
+		require.Contains(t, adminRoutePermissions, key, "route %s has no permission", key)
+	}
+
+	for key, perm := range adminRoutePermissions {
+		require.True(t, registered[key], "adminRoutePermissions entry %s is not a registered route", key)
+		require.Contains(t, permissionRoles, perm)
+	}
+
+	// Admins are granted everything, customers nothing.
+	for perm := range permissionRoles {
+		require.True(t, hasPermission(util.AdminRole, perm), "admins lack %s", perm)
+		require.False(t, hasPermission(util.CustomerRole, perm), "customers have %s", perm)
+	}
+}
+
+/**
+ * Sends invalid requests, rejected by the handlers with a 400 once the
+ * role is authorized, so no store call is made either way.
+ */
+func TestPermissionMiddleware(t *testing.T) {
+	testCases := []struct {
+		name       string
+		role       string
+		method     string
+		url        string
+		statusCode int
+	}{
+		{
+			name:       "CustomerCannotEnter",
+			role:       util.CustomerRole,
+			method:     http.MethodGet,
+			url:        "/v1/admin/users",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "MissingRole",
+			role:       "",
+			method:     http.MethodGet,
+			url:        "/v1/admin/users",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "SupportReads",
+			role:       util.SupportRole,
+			method:     http.MethodGet,
+			url:        "/v1/admin/users",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "SupportFreezes",
+			role:       util.SupportRole,
+			method:     http.MethodPost,
+			url:        "/v1/admin/accounts/0/freeze",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "SupportCannotUnfreeze",
+			role:       util.SupportRole,
+			method:     http.MethodPost,
+			url:        "/v1/admin/accounts/0/unfreeze",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "SupportCannotManage",
+			role:       util.SupportRole,
+			method:     http.MethodPost,
+			url:        "/v1/admin/currencies",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "AdminManages",
+			role:       util.AdminRole,
+			method:     http.MethodPost,
+			url:        "/v1/admin/currencies",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+			addRoleAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, testAdminUsername, tc.role, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.statusCode, recorder.Code)
+		})
+	}
+}
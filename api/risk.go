@@ -0,0 +1,167 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+// Returned to the user when the risk rules deny a transfer.
+// The reasons are only shown to analysts, through the recorded decision.
+var errTransferDenied = errors.New("transfer denied by risk rules")
+
+/**
+ * Evaluates the risk rules on a transfer and records the decision.
+ * Holds are assessed when they are placed, since that is when the payer
+ * commits the money, and scheduled transfers when they are created or edited.
+ * Returns nil when no rule is configured.
+ * The response is written and false returned if the decision can't be made.
+ */
+func (server *Server) assessTransfer(
+	ctx *gin.Context,
+	authPayload *token.Payload,
+	fromAccountID int64,
+	toAccountID int64,
+	amount money.Money,
+) (*db.RiskDecision, bool) {
+	if server.riskEngine.Empty() {
+		return nil, true
+	}
+
+	known, err := server.store.HasTransferBetween(ctx, db.HasTransferBetweenParams{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	transfer := risk.Transfer{
+		Username:      authPayload.Username,
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount.Amount(),
+		Currency:      amount.Currency(),
+		NewRecipient:  !known,
+		ClientIP:      ctx.ClientIP(),
+		Time:          time.Now(),
+	}
+
+	// Tokens issued before sessions were tracked in them have no session.
+	sessionID := uuid.NullUUID{UUID: authPayload.SessionID, Valid: authPayload.SessionID != uuid.Nil}
+	if sessionID.Valid {
+		session, err := server.loginSession(ctx, sessionID.UUID)
+		if err != nil && err != sql.ErrNoRows {
+			ctx.Error(err)
+			return nil, false
+		}
+		if err == nil {
+			transfer.Session = &risk.Session{
+				ClientIP:  session.ClientIp,
+				CreatedAt: session.CreatedAt,
+			}
+		}
+	}
+
+	decision := server.riskEngine.Evaluate(transfer)
+	matches, err := json.Marshal(decision.Matches)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	record, err := server.store.CreateRiskDecision(ctx, db.CreateRiskDecisionParams{
+		Username:      transfer.Username,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      transfer.Currency,
+		SessionID:     sessionID,
+		ClientIp:      transfer.ClientIP,
+		Action:        string(decision.Action),
+		Matches:       matches,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+	return &record, true
+}
+
+/**
+ * Loads the session opened at the login the session was rotated from.
+ * Renewals create a new session each time, but the rules are about
+ * how old the login is and where it came from.
+ */
+func (server *Server) loginSession(ctx *gin.Context, sessionID uuid.UUID) (db.Session, error) {
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil || session.FamilyID == session.ID {
+		return session, err
+	}
+	return server.store.GetSession(ctx, session.FamilyID)
+}
+
+// Reports a denied transfer with the decision, for support to look up.
+func transferDeniedError(decision *db.RiskDecision) *apiError {
+	apiErr := newAPIError(codeTransferDenied, errTransferDenied)
+	apiErr.metadata = gin.H{"risk_decision_id": decision.ID}
+	return apiErr
+}
+
+type listRiskDecisionsRequest struct {
+	Action   string `form:"action" binding:"omitempty,oneof=allow deny flag"`
+	Username string `form:"username" binding:"omitempty,alphanum"`
+	PageID   int32  `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+func (server *Server) listRiskDecisions(ctx *gin.Context) {
+	var req listRiskDecisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	decisions, err := server.store.ListRiskDecisions(ctx, db.ListRiskDecisionsParams{
+		Action:   req.Action,
+		Username: req.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, decisions)
+}
+
+type getRiskDecisionRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) getRiskDecision(ctx *gin.Context) {
+	var req getRiskDecisionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	decision, err := server.store.GetRiskDecision(ctx, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, decision)
+}
@@ -0,0 +1,770 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const riskDecisionURI = "/v1/admin/risk-decisions"
+
+// Address the requests of the risk tests come from.
+const testClientIP = "192.0.2.1"
+
+func randomRiskDecision(username string, action risk.Action) db.RiskDecision {
+	return db.RiskDecision{
+		ID:            util.RandomInt(1, 1000),
+		Username:      username,
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Currency:      util.USD,
+		SessionID:     uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ClientIp:      testClientIP,
+		Action:        string(action),
+		Matches:       json.RawMessage(`[]`),
+	}
+}
+
+// Expects the decision to be recorded with the action and matching rules.
+func expectRiskDecision(
+	store *mockdb.MockStore,
+	decision db.RiskDecision,
+	action risk.Action,
+	rules ...string,
+) {
+	store.EXPECT().
+		CreateRiskDecision(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+			var matches []risk.Match
+			if err := json.Unmarshal(arg.Matches, &matches); err != nil {
+				return decision, err
+			}
+			names := []string{}
+			for _, match := range matches {
+				names = append(names, match.Rule)
+			}
+			if arg.Action != string(action) || fmt.Sprint(names) != fmt.Sprint(rules) {
+				return decision, fmt.Errorf("unexpected decision %s %v", arg.Action, names)
+			}
+
+			decision.Action = arg.Action
+			decision.Matches = arg.Matches
+			return decision, nil
+		})
+}
+
+func TestCreateTransferRisk(t *testing.T) {
+	amount := int64(500)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	sessionID := uuid.New()
+	session := db.Session{
+		ID:        sessionID,
+		FamilyID:  sessionID,
+		Username:  user1.Username,
+		ClientIp:  testClientIP,
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	freshSession := session
+	freshSession.CreatedAt = time.Now().Add(-time.Minute)
+
+	// Renewed a moment ago, from another address, but logged in long ago.
+	rotatedSession := session
+	rotatedSession.ID = uuid.New()
+	rotatedSession.ClientIp = "198.51.100.7"
+	rotatedSession.CreatedAt = time.Now()
+
+	decision := randomRiskDecision(user1.Username, risk.ActionAllow)
+	transfer := randomTransfer(account1.ID, account2.ID)
+
+	engine := risk.NewEngine(
+		risk.Check{
+			Name:   "large_transfer_to_new_recipient",
+			Action: risk.ActionDeny,
+			Rule:   risk.NewRecipientRule{MinAmount: amount},
+		},
+		risk.Check{
+			Name:   "fresh_session",
+			Action: risk.ActionFlag,
+			Rule:   risk.SessionAgeRule{MaxAge: 5 * time.Minute},
+		},
+	)
+
+	buildAccountStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+			Times(1).
+			Return(account1, nil)
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+			Times(1).
+			Return(account2, nil)
+	}
+	buildTransferStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.TransferTxResult{
+				Transfer:    transfer,
+				FromAccount: account1,
+				ToAccount:   account2,
+			}, nil)
+		store.EXPECT().
+			UpdateRiskDecisionTransfer(gomock.Any(), gomock.Eq(db.UpdateRiskDecisionTransferParams{
+				ID:         decision.ID,
+				TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+			})).
+			Times(1)
+	}
+
+	testCases := []struct {
+		name           string
+		sessionID      uuid.UUID
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Allow",
+			sessionID: session.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Eq(db.HasTransferBetweenParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+					})).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				expectRiskDecision(store, decision, risk.ActionAllow)
+				buildTransferStubs(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Flag",
+			sessionID: freshSession.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(freshSession.ID)).
+					Times(1).
+					Return(freshSession, nil)
+				expectRiskDecision(store, decision, risk.ActionFlag, "fresh_session")
+				buildTransferStubs(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "RotatedSession",
+			sessionID: rotatedSession.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(rotatedSession.ID)).
+					Times(1).
+					Return(rotatedSession, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				// The session is judged by its login, so it is not fresh.
+				expectRiskDecision(store, decision, risk.ActionAllow)
+				buildTransferStubs(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Deny",
+			sessionID: freshSession.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(freshSession, nil)
+				// Denying stops the evaluation before the session is flagged.
+				expectRiskDecision(store, decision, risk.ActionDeny, "large_transfer_to_new_recipient")
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateRiskDecisionTransfer(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				body := requireErrorCode(t, recorder, codeTransferDenied)
+				require.Equal(t, errTransferDenied.Error(), body.Message)
+				require.Equal(t, float64(decision.ID), body.Metadata["risk_decision_id"])
+				require.NotContains(t, recorder.Body.String(), "large_transfer_to_new_recipient")
+			},
+		},
+		{
+			name:      "NoSession",
+			sessionID: uuid.Nil,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+				expectRiskDecision(store, decision, risk.ActionAllow)
+				buildTransferStubs(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			sessionID: session.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+				expectRiskDecision(store, decision, risk.ActionAllow)
+				buildTransferStubs(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// The retry of a transfer that went through isn't assessed again,
+			// so it can't be denied by rules that match only now.
+			name:           "IdempotentReplay",
+			sessionID:      freshSession.ID,
+			idempotencyKey: util.RandomString(16),
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					GetIdempotentTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    transfer,
+						FromAccount: account1,
+						ToAccount:   account2,
+						Replayed:    true,
+					}, nil)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateRiskDecision(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotencyReplayedHeader))
+			},
+		},
+		{
+			name:      "RecordDecisionError",
+			sessionID: session.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				buildAccountStubs(store)
+				store.EXPECT().
+					HasTransferBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					CreateRiskDecision(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RiskDecision{}, sql.ErrConnDone)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.riskEngine = engine
+			recorder := httptest.NewRecorder()
+
+			request, err := newJSONRequest(http.MethodPost, transferURI, gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			})
+			require.NoError(t, err)
+			request.RemoteAddr = testClientIP + ":1234"
+			if tc.idempotencyKey != "" {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user1.Username, util.CustomerRole, tc.sessionID, time.Minute)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCreateHoldRisk(t *testing.T) {
+	amount := int64(500)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	sessionID := uuid.New()
+	session := db.Session{
+		ID:        sessionID,
+		FamilyID:  sessionID,
+		Username:  user1.Username,
+		ClientIp:  testClientIP,
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	decision := randomRiskDecision(user1.Username, risk.ActionDeny)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+		Times(1).
+		Return(account1, nil)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+		Times(1).
+		Return(account2, nil)
+	store.EXPECT().
+		HasTransferBetween(gomock.Any(), gomock.Eq(db.HasTransferBetweenParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+		})).
+		Times(1).
+		Return(false, nil)
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		Return(session, nil)
+	expectRiskDecision(store, decision, risk.ActionDeny, "large_transfer_to_new_recipient")
+	// A denied hold reserves nothing.
+	store.EXPECT().
+		PlaceHoldTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	server.riskEngine = risk.NewEngine(risk.Check{
+		Name:   "large_transfer_to_new_recipient",
+		Action: risk.ActionDeny,
+		Rule:   risk.NewRecipientRule{MinAmount: amount},
+	})
+	recorder := httptest.NewRecorder()
+
+	request, err := newJSONRequest(http.MethodPost, holdURI, gin.H{
+		"account_id":    account1.ID,
+		"to_account_id": account2.ID,
+		"amount":        decimalAmount(amount, util.USD),
+		"currency":      util.USD,
+	})
+	require.NoError(t, err)
+	request.RemoteAddr = testClientIP + ":1234"
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user1.Username, util.CustomerRole, session.ID, time.Minute)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+	server.router.ServeHTTP(recorder, request)
+	body := requireErrorCode(t, recorder, codeTransferDenied)
+	require.Equal(t, float64(decision.ID), body.Metadata["risk_decision_id"])
+}
+
+func TestCreateScheduledTransferRisk(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	session := db.Session{
+		ID:        uuid.New(),
+		Username:  user1.Username,
+		ClientIp:  testClientIP,
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	session.FamilyID = session.ID
+
+	engine := risk.NewEngine(risk.Check{
+		Name:   "large_transfer_to_new_recipient",
+		Action: risk.ActionDeny,
+		Rule:   risk.NewRecipientRule{MinAmount: 500},
+	}, risk.Check{
+		Name:   "new_recipient",
+		Action: risk.ActionFlag,
+		Rule:   risk.NewRecipientRule{},
+	})
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore, decision db.RiskDecision)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, decision db.RiskDecision)
+	}{
+		{
+			name:   "Denied",
+			amount: 500,
+			buildStubs: func(store *mockdb.MockStore, decision db.RiskDecision) {
+				expectRiskDecision(store, decision, risk.ActionDeny, "large_transfer_to_new_recipient")
+				// The worker would otherwise move the money without asking again.
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, decision db.RiskDecision) {
+				body := requireErrorCode(t, recorder, codeTransferDenied)
+				require.Equal(t, float64(decision.ID), body.Metadata["risk_decision_id"])
+			},
+		},
+		{
+			name:   "Flagged",
+			amount: 100,
+			buildStubs: func(store *mockdb.MockStore, decision db.RiskDecision) {
+				expectRiskDecision(store, decision, risk.ActionFlag, "new_recipient")
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						// Linked to the transfer of the first run.
+						want := sql.NullInt64{Int64: decision.ID, Valid: true}
+						if arg.RiskDecisionID != want {
+							return db.ScheduledTransfer{}, fmt.Errorf("unexpected risk decision %v", arg.RiskDecisionID)
+						}
+						return db.ScheduledTransfer{
+							FromAccountID:  arg.FromAccountID,
+							Amount:         arg.Amount,
+							RiskDecisionID: arg.RiskDecisionID,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, decision db.RiskDecision) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			decision := randomRiskDecision(user1.Username, risk.ActionAllow)
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+				Times(1).
+				Return(account1, nil)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+				Times(1).
+				Return(account2, nil)
+			store.EXPECT().
+				HasTransferBetween(gomock.Any(), gomock.Eq(db.HasTransferBetweenParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+				})).
+				Times(1).
+				Return(false, nil)
+			store.EXPECT().
+				GetSession(gomock.Any(), gomock.Eq(session.ID)).
+				Times(1).
+				Return(session, nil)
+			tc.buildStubs(store, decision)
+
+			server := newTestServer(t, store)
+			server.riskEngine = engine
+			recorder := httptest.NewRecorder()
+
+			request, err := newJSONRequest(http.MethodPost, scheduledTransferURI, gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(tc.amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          time.Now().Add(time.Hour),
+			})
+			require.NoError(t, err)
+			request.RemoteAddr = testClientIP + ":1234"
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user1.Username, util.CustomerRole, session.ID, time.Minute)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, decision)
+		})
+	}
+}
+
+func TestListRiskDecisionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	n := 5
+	decisions := make([]db.RiskDecision, n)
+	for i := range decisions {
+		decisions[i] = randomRiskDecision(user.Username, risk.ActionDeny)
+	}
+
+	testCases := []struct {
+		base  baseTestCase
+		query string
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.ListRiskDecisionsParams{
+						Action:   string(risk.ActionDeny),
+						Username: user.Username,
+						Limit:    int32(n),
+						Offset:   int32(n),
+					}
+					store.EXPECT().
+						ListRiskDecisions(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(decisions, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchRiskDecisions(t, recorder.Body, decisions)
+				},
+			},
+			query: fmt.Sprintf("action=deny&username=%s&page_id=2&page_size=%d", user.Username, n),
+		},
+		{
+			base: baseTestCase{
+				name: "NotAdmin",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListRiskDecisions(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			query: "page_id=1&page_size=5",
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidAction",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListRiskDecisions(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			query: "action=block&page_id=1&page_size=5",
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListRiskDecisions(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return([]db.RiskDecision{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			query: "page_id=1&page_size=5",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodGet, fmt.Sprintf("%s?%s", riskDecisionURI, tc.query), nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestGetRiskDecisionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	decision := randomRiskDecision(user.Username, risk.ActionFlag)
+
+	testCases := []struct {
+		base baseTestCase
+		id   int64
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetRiskDecision(gomock.Any(), gomock.Eq(decision.ID)).
+						Times(1).
+						Return(decision, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchRiskDecisions(t, recorder.Body, decision)
+				},
+			},
+			id: decision.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetRiskDecision(gomock.Any(), gomock.Eq(decision.ID)).
+						Times(1).
+						Return(db.RiskDecision{}, sql.ErrNoRows)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			id: decision.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetRiskDecision(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			id: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", riskDecisionURI, tc.id), nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+// Checks that the body holds the decision, or the list of decisions when given a slice.
+func requireBodyMatchRiskDecisions(t *testing.T, body *bytes.Buffer, expected interface{}) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	want, err := json.Marshal(expected)
+	require.NoError(t, err)
+	require.JSONEq(t, string(want), string(data))
+}
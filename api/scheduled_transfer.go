@@ -0,0 +1,342 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+// Returned when a scheduled transfer is being executed, completed or cancelled.
+var errScheduledTransferLocked = errors.New("scheduled transfer is running or no longer active")
+
+// When and how often a scheduled transfer runs.
+// Monthly transfers run on the day of the month of RunAt.
+type scheduleRequest struct {
+	RunAt      time.Time `json:"run_at" binding:"required"`
+	Recurrence string    `json:"recurrence" binding:"omitempty,oneof=once weekly monthly"`
+}
+
+// Checks the schedule and fills in its defaults.
+func (req *scheduleRequest) validate() error {
+	if !req.RunAt.After(time.Now()) {
+		return errors.New("run_at must be in the future")
+	}
+	if req.Recurrence == "" {
+		req.Recurrence = db.RecurrenceOnce
+	}
+	return nil
+}
+
+func (req scheduleRequest) dayOfMonth() int32 {
+	if req.Recurrence != db.RecurrenceMonthly {
+		return 0
+	}
+	return int32(req.RunAt.UTC().Day())
+}
+
+type scheduledTransferResponse struct {
+	ID             int64      `json:"id"`
+	Owner          string     `json:"owner"`
+	FromAccountID  int64      `json:"from_account_id"`
+	ToAccountID    int64      `json:"to_account_id"`
+	Amount         moneyField `json:"amount"`
+	Currency       string     `json:"currency"`
+	Recurrence     string     `json:"recurrence"`
+	DayOfMonth     int32      `json:"day_of_month"`
+	Status         string     `json:"status"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	RunCount       int32      `json:"run_count"`
+	Attempts       int32      `json:"attempts"`
+	RetryAt        *time.Time `json:"retry_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	LastError      *string    `json:"last_error"`
+	LastTransferID *int64     `json:"last_transfer_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newScheduledTransferResponse(
+	scheduled db.ScheduledTransfer,
+	currency string,
+	minorUnits bool,
+) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ID:             scheduled.ID,
+		Owner:          scheduled.Owner,
+		FromAccountID:  scheduled.FromAccountID,
+		ToAccountID:    scheduled.ToAccountID,
+		Amount:         newMoneyField(scheduled.Amount, currency, minorUnits),
+		Currency:       currency,
+		Recurrence:     scheduled.Recurrence,
+		DayOfMonth:     scheduled.DayOfMonth,
+		Status:         scheduled.Status,
+		NextRunAt:      scheduled.NextRunAt,
+		RunCount:       scheduled.RunCount,
+		Attempts:       scheduled.Attempts,
+		RetryAt:        nullTime(scheduled.RetryAt),
+		LockedUntil:    nullTime(scheduled.LockedUntil),
+		LastError:      nullString(scheduled.LastError),
+		LastTransferID: nullInt64(scheduled.LastTransferID),
+		CreatedAt:      scheduled.CreatedAt,
+	}
+}
+
+type createScheduledTransferRequest struct {
+	transferRequest
+	scheduleRequest
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := req.scheduleRequest.validate(); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	amount, err := parseAmount(ctx, req.Amount, req.Currency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("source account doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	decisionID, valid := server.assessScheduledTransfer(ctx, authPayload, req.FromAccountID, req.ToAccountID, amount)
+	if !valid {
+		return
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:          authPayload.Username,
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		Amount:         amount.Amount(),
+		Recurrence:     req.Recurrence,
+		DayOfMonth:     req.dayOfMonth(),
+		NextRunAt:      req.RunAt,
+		RiskDecisionID: decisionID,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled, req.Currency, minorUnits(ctx)))
+}
+
+type listScheduledTransferRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransfer(ctx *gin.Context) {
+	var req listScheduledTransferRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduled, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Transfers are in the currency of their source account.
+	currencies := make(map[int64]string)
+	rsp := make([]scheduledTransferResponse, len(scheduled))
+	for i, transfer := range scheduled {
+		currency, ok := currencies[transfer.FromAccountID]
+		if !ok {
+			account, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			currency = account.Currency
+			currencies[transfer.FromAccountID] = currency
+		}
+		rsp[i] = newScheduledTransferResponse(transfer, currency, minorUnits(ctx))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type scheduledTransferUriRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	scheduled, currency, valid := server.ownedScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled, currency, minorUnits(ctx)))
+}
+
+type updateScheduledTransferRequest struct {
+	uri  scheduledTransferUriRequest
+	body updateScheduledTransferRequestBody
+}
+
+type updateScheduledTransferRequestBody struct {
+	Amount requestAmount `json:"amount" binding:"required"`
+	scheduleRequest
+}
+
+// Replaces the amount and the schedule of a transfer.
+// A failed transfer becomes active again.
+// A run that is already under way keeps the amount it started with.
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req.body); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := req.body.scheduleRequest.validate(); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	scheduled, currency, valid := server.ownedScheduledTransfer(ctx, req.uri.ID)
+	if !valid {
+		return
+	}
+	amount, err := parseAmount(ctx, req.body.Amount, currency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	decisionID, valid := server.assessScheduledTransfer(ctx, authPayload, scheduled.FromAccountID, scheduled.ToAccountID, amount)
+	if !valid {
+		return
+	}
+
+	scheduled, err = server.store.UpdateScheduledTransfer(ctx, db.UpdateScheduledTransferParams{
+		ID:             req.uri.ID,
+		Amount:         amount.Amount(),
+		Recurrence:     req.body.Recurrence,
+		DayOfMonth:     req.body.dayOfMonth(),
+		NextRunAt:      req.body.RunAt,
+		RiskDecisionID: decisionID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errScheduledTransferLocked
+		}
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled, currency, minorUnits(ctx)))
+}
+
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	_, currency, valid := server.ownedScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	scheduled, err := server.store.CancelScheduledTransfer(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errScheduledTransferLocked
+		}
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled, currency, minorUnits(ctx)))
+}
+
+// Assesses a scheduled transfer when it is created or edited, while the owner
+// is still around to be told about a deny.
+// Returns the ID of the decision, which the scheduler links to the transfer
+// of the next run.
+func (server *Server) assessScheduledTransfer(
+	ctx *gin.Context,
+	authPayload *token.Payload,
+	fromAccountID int64,
+	toAccountID int64,
+	amount money.Money,
+) (sql.NullInt64, bool) {
+	decision, valid := server.assessTransfer(ctx, authPayload, fromAccountID, toAccountID, amount)
+	if !valid || decision == nil {
+		return sql.NullInt64{}, valid
+	}
+	if decision.Action == string(risk.ActionDeny) {
+		ctx.Error(transferDeniedError(decision))
+		return sql.NullInt64{}, false
+	}
+	return sql.NullInt64{Int64: decision.ID, Valid: true}, true
+}
+
+// Loads a scheduled transfer of the current user, with the currency of its source account.
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, string, bool) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return scheduled, "", false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return scheduled, "", false
+	}
+
+	account, err := server.store.GetAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return scheduled, "", false
+	}
+	return scheduled, account.Currency, true
+}
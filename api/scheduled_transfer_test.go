@@ -0,0 +1,633 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const scheduledTransferURI = "/v1/scheduled-transfers"
+
+func randomScheduledTransfer(fromAccount db.Account) db.ScheduledTransfer {
+	return db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Recurrence:    db.RecurrenceOnce,
+		Status:        db.ScheduleStatusActive,
+		NextRunAt:     time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+	}
+}
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	amount := int64(10)
+	runAt := time.Date(2100, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					arg := db.CreateScheduledTransferParams{
+						Owner:         user1.Username,
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+						Recurrence:    db.RecurrenceMonthly,
+						DayOfMonth:    31,
+						NextRunAt:     runAt,
+					}
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+						Times(1)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          runAt,
+				"recurrence":      db.RecurrenceMonthly,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "DefaultsToOnce",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+							require.Equal(t, db.RecurrenceOnce, arg.Recurrence)
+							require.Zero(t, arg.DayOfMonth)
+							return db.ScheduledTransfer{}, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          runAt,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "RunAtInThePast",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          time.Now().Add(-time.Minute),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidRecurrence",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          runAt,
+				"recurrence":      "daily",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          runAt,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+				"run_at":          runAt,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, scheduledTransferURI, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestListScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	// Both are sent from the same account, whose currency is loaded once.
+	scheduled := []db.ScheduledTransfer{
+		randomScheduledTransfer(account),
+		randomScheduledTransfer(account),
+	}
+	scheduled[1].LastError = sql.NullString{String: "insufficient funds", Valid: true}
+	scheduled[1].LastTransferID = sql.NullInt64{Int64: 7, Valid: true}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListScheduledTransfersParams{
+					Owner:  user.Username,
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(scheduled, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, len(scheduled))
+				for i := range scheduled {
+					require.Equal(t, float64(scheduled[i].ID), got[i]["id"])
+					require.Equal(t, money.New(scheduled[i].Amount, account.Currency).String(), got[i]["amount"])
+					require.Equal(t, account.Currency, got[i]["currency"])
+					require.Nil(t, got[i]["retry_at"])
+				}
+
+				// Nullable columns are null or their value.
+				require.Nil(t, got[0]["last_error"])
+				require.Nil(t, got[0]["last_transfer_id"])
+				require.Equal(t, "insufficient funds", got[1]["last_error"])
+				require.Equal(t, float64(7), got[1]["last_transfer_id"])
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s?page_id=2&page_size=5", scheduledTransferURI)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestGetScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	scheduled := randomScheduledTransfer(account)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(scheduled, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				want, err := json.Marshal(newScheduledTransferResponse(scheduled, account.Currency, false))
+				require.NoError(t, err)
+				require.JSONEq(t, string(want), recorder.Body.String())
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(scheduled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", scheduledTransferURI, scheduled.ID)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	scheduled := randomScheduledTransfer(account)
+	runAt := time.Date(2100, time.March, 15, 9, 0, 0, 0, time.UTC)
+
+	body := gin.H{
+		"amount":     decimalAmount(20, account.Currency),
+		"run_at":     runAt,
+		"recurrence": db.RecurrenceWeekly,
+	}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+						Times(1).
+						Return(scheduled, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					arg := db.UpdateScheduledTransferParams{
+						ID:         scheduled.ID,
+						Amount:     20,
+						Recurrence: db.RecurrenceWeekly,
+						NextRunAt:  runAt,
+					}
+					store.EXPECT().
+						UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+						Times(1)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "Locked",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+						Times(1).
+						Return(scheduled, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusConflict, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+						Times(1).
+						Return(scheduled, nil)
+
+					store.EXPECT().
+						UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: body,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidAmount",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+						Times(1).
+						Return(scheduled, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(-1, account.Currency),
+				"run_at": runAt,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", scheduledTransferURI, scheduled.ID)
+			return newJSONRequest(http.MethodPut, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestCancelScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	scheduled := randomScheduledTransfer(account)
+
+	cancelled := scheduled
+	cancelled.Status = db.ScheduleStatusCancelled
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(scheduled, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduleStatusCancelled, got["status"])
+			},
+		},
+		{
+			name: "AlreadyCancelled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(cancelled, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).
+					Times(1).
+					Return(scheduled, nil)
+
+				store.EXPECT().
+					CancelScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", scheduledTransferURI, scheduled.ID)
+			return http.NewRequest(http.MethodDelete, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
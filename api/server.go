@@ -1,39 +1,60 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/notify"
+	"github.com/wiliamhw/simplebank/risk"
 	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
+// All API routes are mounted under this prefix, the documentation is not.
+const apiVersionPrefix = "/v1"
+
 // Serve HTTP requests.
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config        util.Config
+	store         db.Store
+	tokenMaker    token.Maker
+	riskEngine    *risk.Engine
+	accountEvents *notify.Hub
+	router        *gin.Engine
+	openAPI       *openAPIDocument
 }
 
 // Creates a new HTTP server and setup routing.
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+// Account event streams are woken up by accountEvents.
+func NewServer(config util.Config, store db.Store, accountEvents *notify.Hub) (*Server, error) {
 	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %v", err)
 	}
 
+	riskEngine := risk.NewEngine()
+	if config.RiskRulesFile != "" {
+		riskEngine, err = risk.LoadEngine(config.RiskRulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load risk rules: %v", err)
+		}
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:        config,
+		store:         store,
+		tokenMaker:    tokenMaker,
+		riskEngine:    riskEngine,
+		accountEvents: accountEvents,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterTagNameFunc(validationFieldName)
 	}
 
 	server.setupRouter()
@@ -42,19 +63,94 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 
 func (server *Server) setupRouter() {
 	router := gin.Default()
+	router.Use(requestIDMiddleware(), errorMiddleware(), moneyFormatMiddleware())
+	router.NoRoute(func(ctx *gin.Context) {
+		ctx.Error(newAPIError(codeNotFound, errors.New("route not found")))
+	})
+
+	v1 := router.Group(apiVersionPrefix)
+	v1.POST("/users", server.createUser)
+	v1.POST("/users/login", server.loginUser)
+	v1.POST("/tokens/renew_access", server.renewAccessToken)
 
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
-	router.POST("/tokens/renew_access", server.renewAccessToken)
+	authRoutes := v1.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.POST("/users/logout_all", server.logoutAllSessions)
+	authRoutes.GET("/sessions", server.listSessions)
+	authRoutes.DELETE("/sessions/:id", server.revokeSession)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
 	authRoutes.GET("/accounts", server.listAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
 	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.PATCH("/accounts/:id", server.updateAccount)
-	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	authRoutes.POST("/accounts/:id/deposits", server.depositAccount)
+	authRoutes.POST("/accounts/:id/withdrawals", server.withdrawAccount)
+	authRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
 
+	authRoutes.GET("/transfers", server.listTransfer)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/:id/reversals", server.reverseTransfer)
+
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	authRoutes.PUT("/scheduled-transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", server.cancelScheduledTransfer)
+
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds", server.createHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/void", server.voidHold)
+
+	authRoutes.GET("/webhooks", server.listWebhook)
+	authRoutes.GET("/webhooks/:id", server.getWebhook)
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.PUT("/webhooks/:id", server.updateWebhook)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDelivery)
+	authRoutes.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", server.redeliverWebhookDelivery)
+
+	authRoutes.GET("/currencies", server.listCurrencies)
+
+	authRoutes.GET("/fees", server.listFeeSchedules)
+
+	authRoutes.GET("/fx/rates", server.listFxRates)
+	authRoutes.POST("/fx/quotes", server.createFxQuote)
+	authRoutes.POST("/fx/conversions", server.createFxConversion)
+
+	// Every /admin route needs a permission in adminRoutePermissions.
+	adminRoutes := v1.Group("/admin").Use(
+		authMiddleware(server.tokenMaker),
+		requireRole(operatorRoles...),
+		permissionMiddleware(),
+	)
+	adminRoutes.GET("/users", server.listUsers)
+	adminRoutes.PUT("/users/:username/role", server.updateUserRole)
+	adminRoutes.GET("/users/:username/sessions", server.listUserSessions)
+	adminRoutes.POST("/sessions/:id/block", server.blockSession)
+	adminRoutes.GET("/accounts/:id", server.adminGetAccount)
+	adminRoutes.POST("/accounts/:id/freeze", server.adminFreezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", server.adminUnfreezeAccount)
+	adminRoutes.POST("/transfers/:id/reversals", server.adminReverseTransfer)
+	adminRoutes.GET("/currencies", server.listAllCurrencies)
+	adminRoutes.POST("/currencies", server.createCurrency)
+	adminRoutes.PUT("/currencies/:code", server.updateCurrency)
+	adminRoutes.PUT("/fees", server.upsertFeeSchedule)
+	adminRoutes.DELETE("/fees/:id", server.deleteFeeSchedule)
+	adminRoutes.PUT("/fx/rates", server.upsertFxRate)
+	adminRoutes.GET("/risk-decisions", server.listRiskDecisions)
+	adminRoutes.GET("/risk-decisions/:id", server.getRiskDecision)
+	adminRoutes.GET("/transfer-limits", server.listTransferLimits)
+	adminRoutes.PUT("/transfer-limits", server.upsertTransferLimit)
+	adminRoutes.PUT("/users/:username/tier", server.updateUserTier)
+
+	router.GET(openAPIPath, server.getOpenAPI)
+	router.GET(swaggerPath+"/*any", serveSwaggerUI)
+	server.openAPI = newOpenAPIDocument(router.Routes())
 
 	server.router = router
 }
@@ -63,7 +159,3 @@ func (server *Server) setupRouter() {
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
-
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
-}
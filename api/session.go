@@ -0,0 +1,209 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+)
+
+// A session without its refresh token.
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+func newSessionsResponse(sessions []db.Session) []sessionResponse {
+	rsp := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		rsp[i] = newSessionResponse(session)
+	}
+	return rsp
+}
+
+/**
+ * Blocks the session along with its family, the sessions rotated from
+ * the same login, so that an access token issued before a renewal
+ * still logs out of the device.
+ */
+func (server *Server) blockSessionFamily(ctx *gin.Context, session db.Session) (db.Session, error) {
+	_, err := server.store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		return db.Session{}, err
+	}
+
+	session.IsBlocked = true
+	return session, nil
+}
+
+// A session of the current user, telling the one of the request apart.
+type userSessionResponse struct {
+	sessionResponse
+	Current bool `json:"current"`
+}
+
+// Lists the sessions of the current user, the devices they are logged in on.
+func (server *Server) listSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	sessions, err := server.store.ListSessions(ctx, authPayload.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]userSessionResponse, len(sessions))
+	for i, session := range sessions {
+		rsp[i] = userSessionResponse{
+			sessionResponse: newSessionResponse(session),
+			Current:         session.ID == authPayload.SessionID,
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// Revokes a session of the current user, like logging out on that device.
+func (server *Server) revokeSession(ctx *gin.Context) {
+	var req revokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, uuid.MustParse(req.ID))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Username != authPayload.Username {
+		err := errors.New("session doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	session, err = server.blockSessionFamily(ctx, session)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionResponse(session))
+}
+
+/**
+ * Logs out of the session of the access token.
+ * Its refresh token can no longer be renewed, and the access token
+ * itself stays valid until it expires, so clients should discard it.
+ */
+func (server *Server) logoutUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	session, err := server.store.GetSession(ctx, authPayload.SessionID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	session, err = server.blockSessionFamily(ctx, session)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionResponse(session))
+}
+
+type logoutAllSessionsResponse struct {
+	// Sessions that were still active.
+	BlockedSessions int64 `json:"blocked_sessions"`
+}
+
+// Logs out of every session of the current user, on every device.
+func (server *Server) logoutAllSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	blocked, err := server.store.BlockUserSessions(ctx, db.BlockUserSessionsParams{
+		Username: authPayload.Username,
+		Now:      time.Now(),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, logoutAllSessionsResponse{BlockedSessions: blocked})
+}
+
+type listUserSessionsRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+func (server *Server) listUserSessions(ctx *gin.Context) {
+	var req listUserSessionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	sessions, err := server.store.ListSessions(ctx, req.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionsResponse(sessions))
+}
+
+type blockSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+/**
+ * Blocks a session, its refresh token can no longer be renewed.
+ * The access tokens already issued stay valid until they expire.
+ */
+func (server *Server) blockSession(ctx *gin.Context) {
+	var req blockSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, uuid.MustParse(req.ID))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	session, err = server.blockSessionFamily(ctx, session)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionResponse(session))
+}
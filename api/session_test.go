@@ -0,0 +1,462 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// A session that started its own family, at a login.
+func randomSession(username string) db.Session {
+	id := uuid.New()
+	return db.Session{
+		ID:           id,
+		FamilyID:     id,
+		Username:     username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "Go-http-client/1.1",
+		ClientIp:     "192.0.2.1",
+		ExpiresAt:    time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Authorizes the request as a customer, with an access token of the session.
+func addSessionAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	username string,
+	sessionID uuid.UUID,
+) {
+	accessToken, _, err := tokenMaker.CreateToken(username, util.CustomerRole, sessionID, time.Minute)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+}
+
+func TestListSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	sessions := []db.Session{randomSession(user.Username), randomSession(user.Username)}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, user.Username, sessions[1].ID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "refresh_token")
+
+				var gotSessions []userSessionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSessions)
+				require.NoError(t, err)
+				require.Len(t, gotSessions, 2)
+				for i, session := range sessions {
+					require.Equal(t, newSessionResponse(session), gotSessions[i].sessionResponse)
+				}
+				require.False(t, gotSessions[0].Current)
+				require.True(t, gotSessions[1].Current)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, "/v1/sessions", nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestRevokeSessionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	session := randomSession(user.Username)
+
+	blockedSession := session
+	blockedSession.IsBlocked = true
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).
+					Times(1).
+					Return(int64(2), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotSession sessionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSession)
+				require.NoError(t, err)
+				require.Equal(t, newSessionResponse(blockedSession), gotSession)
+			},
+		},
+		{
+			name: "NotOwner",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeNotOwner)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/sessions/%s", session.ID)
+			return http.NewRequest(http.MethodDelete, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestLogoutUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	session := randomSession(user.Username)
+
+	blockedSession := session
+	blockedSession.IsBlocked = true
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, user.Username, session.ID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotSession sessionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSession)
+				require.NoError(t, err)
+				require.Equal(t, session.ID, gotSession.ID)
+				require.True(t, gotSession.IsBlocked)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return http.NewRequest(http.MethodPost, "/v1/users/logout", nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestLogoutAllSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.BlockUserSessionsParams) (int64, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now(), arg.Now, time.Second)
+						return 3, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp logoutAllSessionsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, int64(3), rsp.BlockedSessions)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeInternal)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return http.NewRequest(http.MethodPost, "/v1/users/logout_all", nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestListUserSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	sessions := []db.Session{randomSession(user.Username), randomSession(user.Username)}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "refresh_token")
+
+				var gotSessions []sessionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotSessions)
+				require.NoError(t, err)
+				require.Equal(t, newSessionsResponse(sessions), gotSessions)
+			},
+		},
+		{
+			name: "Customer",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireErrorCode(t, recorder, codeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/users/%s/sessions", user.Username)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestBlockSessionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	session := randomSession(user.Username)
+
+	blockedSession := session
+	blockedSession.IsBlocked = true
+
+	testCases := []struct {
+		base      baseTestCase
+		sessionID string
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetSession(gomock.Any(), gomock.Eq(session.ID)).
+						Times(1).
+						Return(session, nil)
+
+					store.EXPECT().
+						BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).
+						Times(1).
+						Return(int64(1), nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var gotSession sessionResponse
+					err := json.Unmarshal(recorder.Body.Bytes(), &gotSession)
+					require.NoError(t, err)
+					require.Equal(t, newSessionResponse(blockedSession), gotSession)
+				},
+			},
+			sessionID: session.ID.String(),
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						BlockSessionFamily(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeValidationFailed)
+				},
+			},
+			sessionID: "not-a-uuid",
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetSession(gomock.Any(), gomock.Eq(session.ID)).
+						Times(1).
+						Return(db.Session{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						BlockSessionFamily(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeNotFound)
+				},
+			},
+			sessionID: session.ID.String(),
+		},
+		{
+			base: baseTestCase{
+				name: "Customer",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						BlockSessionFamily(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+			sessionID: session.ID.String(),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/sessions/%s/block", tc.sessionID)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
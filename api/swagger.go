@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+const swaggerPath = "/swagger"
+
+// Replaces the initializer bundled with Swagger UI, which loads the petstore example.
+var swaggerInitializer = []byte(`window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + openAPIPath + `",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`)
+
+// Serves the embedded Swagger UI, pointed at the OpenAPI document.
+func serveSwaggerUI(ctx *gin.Context) {
+	name := ctx.Param("any")
+	switch name {
+	case "", "/":
+		name = "/index.html"
+	case "/swagger-initializer.js":
+		ctx.Data(http.StatusOK, "application/javascript; charset=utf-8", swaggerInitializer)
+		return
+	}
+
+	file, err := swaggerFiles.HTTP.Open(name)
+	if err != nil {
+		ctx.Error(newAPIError(codeNotFound, err))
+		return
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if stat.IsDir() {
+		ctx.Error(newAPIError(codeNotFound, errors.New("file not found")))
+		return
+	}
+
+	http.ServeContent(ctx.Writer, ctx.Request, stat.Name(), stat.ModTime(), file)
+}
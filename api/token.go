@@ -1,82 +1,148 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
 )
 
 type renewAccessTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// The refresh token is rotated, the one of the request can't be used again.
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
 	session, err := server.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
-	if session.IsBlocked {
-		err := fmt.Errorf("blocked session")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
 	if session.Username != refreshPayload.Username {
 		err := fmt.Errorf("incorrect session user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
 	if session.RefreshToken != req.RefreshToken {
 		err := fmt.Errorf("mismatched session token")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
+		return
+	}
+
+	// Only a thief would present a refresh token that was already rotated.
+	if session.RotatedAt.Valid {
+		server.revokeSessionFamily(ctx, session)
+		return
+	}
+
+	if session.IsBlocked {
+		err := fmt.Errorf("blocked session")
+		ctx.Error(newAPIError(codeUnauthenticated, err))
 		return
 	}
 
 	if time.Now().After(session.ExpiresAt) {
 		err := fmt.Errorf("expired session")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, err))
+		return
+	}
+
+	// The role may have changed since the login, the new tokens carry the current one.
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Rotation doesn't extend the family, it expires with the login.
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		uuid.Nil,
+		time.Until(session.ExpiresAt),
+	)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
-		refreshPayload.Username,
+		user.Username,
+		user.Role,
+		newRefreshPayload.ID,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
+		return
+	}
+
+	newSession, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     session.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		// Another renewal with the same refresh token won the race.
+		if errors.Is(err, db.ErrSessionRotated) {
+			server.revokeSessionFamily(ctx, session)
+			return
+		}
+		ctx.Error(err)
 		return
 	}
 
 	rsp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             newSession.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+// Blocks every session of the family of a reused refresh token.
+func (server *Server) revokeSessionFamily(ctx *gin.Context, session db.Session) {
+	_, err := server.blockSessionFamily(ctx, session)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = errors.New("refresh token was already used, its sessions are revoked")
+	ctx.Error(newAPIError(codeUnauthenticated, err))
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const renewAccessTokenURI = "/v1/tokens/renew_access"
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	username := util.RandomOwner()
+
+	// The session of the refresh token, as stored at login.
+	sessionOf := func(refreshToken string, payload *token.Payload) db.Session {
+		return db.Session{
+			ID:           payload.ID,
+			FamilyID:     payload.ID,
+			Username:     username,
+			RefreshToken: refreshToken,
+			ExpiresAt:    payload.ExpiredAt,
+		}
+	}
+
+	// The user as stored now, it was a customer at login.
+	user := db.User{Username: username, Role: util.SupportRole}
+	buildUserStub := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetUser(gomock.Any(), gomock.Eq(username)).
+			Times(1).
+			Return(user, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := sessionOf(refreshToken, payload)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+				buildUserStub(store)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.SessionID)
+						require.NotEqual(t, session.ID, arg.NewSession.ID)
+						require.Equal(t, username, arg.NewSession.Username)
+						require.NotEqual(t, refreshToken, arg.NewSession.RefreshToken)
+						require.WithinDuration(t, session.ExpiresAt, arg.NewSession.ExpiresAt, time.Second)
+
+						return db.Session{
+							ID:           arg.NewSession.ID,
+							FamilyID:     session.FamilyID,
+							Username:     arg.NewSession.Username,
+							RefreshToken: arg.NewSession.RefreshToken,
+							ExpiresAt:    arg.NewSession.ExpiresAt,
+						}, nil
+					})
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				// Both tokens belong to the new session.
+				refreshPayload, err := tokenMaker.VerifyToken(rsp.RefreshToken)
+				require.NoError(t, err)
+				require.Equal(t, rsp.SessionID, refreshPayload.ID)
+
+				accessPayload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, rsp.SessionID, accessPayload.SessionID)
+
+				// The role granted since the login is picked up.
+				require.Equal(t, util.SupportRole, refreshPayload.Role)
+				require.Equal(t, util.SupportRole, accessPayload.Role)
+			},
+		},
+		{
+			name: "ReusedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := sessionOf(refreshToken, payload)
+				session.FamilyID = uuid.New()
+				session.RotatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+		{
+			name: "ConcurrentRotation",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(sessionOf(refreshToken, payload), nil)
+				buildUserStub(store)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrSessionRotated)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(int64(2), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := sessionOf(refreshToken, payload)
+				session.IsBlocked = true
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := sessionOf(refreshToken, payload)
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(sessionOf("another token", payload), nil)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeUnauthenticated)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireErrorCode(t, recorder, codeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, payload, err := server.tokenMaker.CreateToken(username, util.CustomerRole, uuid.Nil, time.Hour)
+			require.NoError(t, err)
+			tc.buildStubs(store, refreshToken, payload)
+
+			recorder := httptest.NewRecorder()
+			request, err := newJSONRequest(http.MethodPost, renewAccessTokenURI, gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
@@ -5,23 +5,111 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/risk"
 	"github.com/wiliamhw/simplebank/token"
 )
 
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1,nefield=ToAccountID"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64         `json:"from_account_id" binding:"required,min=1,nefield=ToAccountID"`
+	ToAccountID   int64         `json:"to_account_id" binding:"required,min=1"`
+	Amount        requestAmount `json:"amount" binding:"required"`
+	Currency      string        `json:"currency" binding:"required,currency"`
+}
+
+type transferResponse struct {
+	ID            int64      `json:"id"`
+	FromAccountID int64      `json:"from_account_id"`
+	ToAccountID   int64      `json:"to_account_id"`
+	Amount        moneyField `json:"amount"`
+	Currency      string     `json:"currency"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReversalOf    *int64     `json:"reversal_of"`
+	FeeOf         *int64     `json:"fee_of"`
+}
+
+// The currency is the one of both accounts of the transfer.
+func newTransferResponse(transfer db.Transfer, currency string, minorUnits bool) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        newMoneyField(transfer.Amount, currency, minorUnits),
+		Currency:      currency,
+		CreatedAt:     transfer.CreatedAt,
+		ReversalOf:    nullInt64(transfer.ReversalOf),
+		FeeOf:         nullInt64(transfer.FeeOf),
+	}
+}
+
+type transferFeeResponse struct {
+	Transfer  transferResponse `json:"transfer"`
+	FromEntry entryResponse    `json:"from_entry"`
+	ToEntry   entryResponse    `json:"to_entry"`
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse     `json:"transfer"`
+	FromAccount accountResponse      `json:"from_account"`
+	ToAccount   accountResponse      `json:"to_account"`
+	FromEntry   entryResponse        `json:"from_entry"`
+	ToEntry     entryResponse        `json:"to_entry"`
+	Fee         *transferFeeResponse `json:"fee,omitempty"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult, currency string, minorUnits bool) transferTxResponse {
+	rsp := transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, currency, minorUnits),
+		FromAccount: newAccountResponse(result.FromAccount, minorUnits),
+		ToAccount:   newAccountResponse(result.ToAccount, minorUnits),
+		FromEntry:   newEntryResponse(result.FromEntry, currency, minorUnits),
+		ToEntry:     newEntryResponse(result.ToEntry, currency, minorUnits),
+	}
+	if result.Fee != nil {
+		rsp.Fee = &transferFeeResponse{
+			Transfer:  newTransferResponse(result.Fee.Transfer, currency, minorUnits),
+			FromEntry: newEntryResponse(result.Fee.FromEntry, currency, minorUnits),
+			ToEntry:   newEntryResponse(result.Fee.ToEntry, currency, minorUnits),
+		}
+	}
+	return rsp
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	amount, err := parseAmount(ctx, req.Amount, req.Currency)
+	if err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("%s header must not exceed %d characters",
+			idempotencyKeyHeader, maxIdempotencyKeyLength,
+		)
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if strings.HasPrefix(idempotencyKey, db.ScheduledRunKeyPrefix) {
+		err := fmt.Errorf("%s header must not start with %q, it is reserved for scheduled transfers",
+			idempotencyKeyHeader, db.ScheduledRunKeyPrefix,
+		)
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
@@ -33,7 +121,7 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
 		err := errors.New("source account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeNotOwner, err))
 		return
 	}
 
@@ -45,16 +133,57 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		Amount:        amount.Amount(),
+	}
+	if len(idempotencyKey) > 0 {
+		arg.Idempotency = &db.IdempotencyParams{
+			Username: authPayload.Username,
+			Key:      idempotencyKey,
+		}
+
+		// A retry gets the stored response, it was assessed the first time.
+		result, err := server.store.GetIdempotentTransfer(ctx, arg)
+		if err == nil {
+			ctx.Header(idempotencyReplayedHeader, "true")
+			ctx.JSON(http.StatusOK, newTransferTxResponse(result, req.Currency, minorUnits(ctx)))
+			return
+		}
+		if err != sql.ErrNoRows {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	decision, valid := server.assessTransfer(ctx, authPayload, req.FromAccountID, req.ToAccountID, amount)
+	if !valid {
+		return
+	}
+	if decision != nil && decision.Action == string(risk.ActionDeny) {
+		ctx.Error(transferDeniedError(decision))
+		return
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	if decision != nil {
+		_, err = server.store.UpdateRiskDecisionTransfer(ctx, db.UpdateRiskDecisionTransferParams{
+			ID:         decision.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	if result.Replayed {
+		ctx.Header(idempotencyReplayedHeader, "true")
+	}
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result, req.Currency, minorUnits(ctx)))
 }
 
 func (server *Server) validAccount(
@@ -64,11 +193,7 @@ func (server *Server) validAccount(
 ) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return account, false
 	}
 
@@ -76,8 +201,228 @@ func (server *Server) validAccount(
 		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s",
 			account.ID, account.Currency, currency,
 		)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return account, false
 	}
 	return account, true
 }
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// The transfer must touch at least one account of the current user.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if account.Owner == authPayload.Username {
+			ctx.JSON(http.StatusOK, newTransferResponse(transfer, account.Currency, minorUnits(ctx)))
+			return
+		}
+	}
+
+	err = errors.New("transfer doesn't belong to the authenticated user")
+	ctx.Error(newAPIError(codeNotOwner, err))
+}
+
+const (
+	directionIn  = "in"
+	directionOut = "out"
+	directionAll = "all"
+)
+
+type listTransferRequest struct {
+	AccountID int64     `form:"account_id" binding:"omitempty,numeric,min=1"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out all"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageID    int32     `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize  int32     `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+func (server *Server) listTransfer(ctx *gin.Context) {
+	var req listTransferRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if req.Direction == "" {
+		req.Direction = directionAll
+	}
+	if req.To.IsZero() {
+		req.To = time.Now()
+	}
+	if !req.From.Before(req.To) {
+		err := errors.New("from must be before to")
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.AccountID != 0 {
+		account, err := server.store.GetAccount(ctx, req.AccountID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if account.Owner != authPayload.Username {
+			err := errors.New("account doesn't belong to the authenticated user")
+			ctx.Error(newAPIError(codeNotOwner, err))
+			return
+		}
+	}
+
+	arg := db.ListOwnerTransfersParams{
+		IncludeOut: req.Direction != directionIn,
+		IncludeIn:  req.Direction != directionOut,
+		Owner:      authPayload.Username,
+		AccountID:  req.AccountID,
+		FromTime:   req.From,
+		ToTime:     req.To,
+		Limit:      req.PageSize,
+		Offset:     (req.PageID - 1) * req.PageSize,
+	}
+
+	transfers, err := server.store.ListOwnerTransfers(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		rsp[i] = newTransferResponse(db.Transfer{
+			ID:            transfer.ID,
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+			CreatedAt:     transfer.CreatedAt,
+			ReversalOf:    transfer.ReversalOf,
+			FeeOf:         transfer.FeeOf,
+		}, transfer.Currency, minorUnits(ctx))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type reverseTransferRequest struct {
+	uri  reverseTransferRequestUri
+	body reverseTransferRequestBody
+}
+
+type reverseTransferRequestUri struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+type reverseTransferRequestBody struct {
+	// Optional, what is left of the transfer is reversed when omitted.
+	Amount requestAmount `json:"amount"`
+}
+
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	transfer, toAccount, body, ok := server.bindReverseTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	// The reversal debits the recipient, so only they can give the money back.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username {
+		err := errors.New("transfer wasn't received by the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return
+	}
+
+	server.executeReversal(ctx, transfer, toAccount, body, true)
+}
+
+// Reverses any transfer, for support to undo a mistaken one.
+func (server *Server) adminReverseTransfer(ctx *gin.Context) {
+	transfer, toAccount, body, ok := server.bindReverseTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	server.executeReversal(ctx, transfer, toAccount, body, false)
+}
+
+/**
+ * Binds a reversal request and loads the transfer with its recipient.
+ * Errors are added to ctx, in which case ok is false.
+ */
+func (server *Server) bindReverseTransferRequest(ctx *gin.Context) (
+	transfer db.Transfer, toAccount db.Account, body reverseTransferRequestBody, ok bool,
+) {
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	// The body is optional, without it the rest of the transfer is reversed.
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req.body); err != nil {
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	toAccount, err = server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	return transfer, toAccount, req.body, true
+}
+
+func (server *Server) executeReversal(
+	ctx *gin.Context,
+	transfer db.Transfer,
+	toAccount db.Account,
+	body reverseTransferRequestBody,
+	byRecipient bool,
+) {
+	arg := db.ReverseTransferTxParams{
+		TransferID:  transfer.ID,
+		ByRecipient: byRecipient,
+	}
+	if body.Amount != nil {
+		amount, err := parseAmount(ctx, body.Amount, toAccount.Currency)
+		if err != nil {
+			ctx.Error(invalidRequestError(err))
+			return
+		}
+		arg.Amount = amount.Amount()
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result, toAccount.Currency, minorUnits(ctx)))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+func (server *Server) listTransferLimits(ctx *gin.Context) {
+	limits, err := server.store.ListTransferLimits(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limits)
+}
+
+// Zero disables a limit.
+type upsertTransferLimitRequest struct {
+	Tier            string `json:"tier" binding:"required,alphanum"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	MaxPerTransfer  int64  `json:"max_per_transfer" binding:"min=0"`
+	MaxPerDay       int64  `json:"max_per_day" binding:"min=0"`
+	MaxPer30Days    int64  `json:"max_per_30_days" binding:"min=0"`
+	MaxCountPerHour int32  `json:"max_count_per_hour" binding:"min=0"`
+}
+
+func (server *Server) upsertTransferLimit(ctx *gin.Context) {
+	var req upsertTransferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	limit, err := server.store.UpsertTransferLimit(ctx, db.UpsertTransferLimitParams{
+		Tier:            req.Tier,
+		Currency:        req.Currency,
+		MaxPerTransfer:  req.MaxPerTransfer,
+		MaxPerDay:       req.MaxPerDay,
+		MaxPer30Days:    req.MaxPer30Days,
+		MaxCountPerHour: req.MaxCountPerHour,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, limit)
+}
+
+type updateUserTierRequest struct {
+	uri  updateUserTierRequestUri
+	body updateUserTierRequestBody
+}
+
+type updateUserTierRequestUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserTierRequestBody struct {
+	Tier string `json:"tier" binding:"required,alphanum"`
+}
+
+func (server *Server) updateUserTier(ctx *gin.Context) {
+	var req updateUserTierRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req.body); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserTier(ctx, db.UpdateUserTierParams{
+		Username: req.uri.Username,
+		Tier:     req.body.Tier,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
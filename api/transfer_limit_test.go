@@ -0,0 +1,226 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestListTransferLimitsAPI(t *testing.T) {
+	limits := []db.TransferLimit{
+		{Tier: "premium", Currency: util.USD, MaxPerDay: 100000},
+		{Tier: db.DefaultUserTier, Currency: util.USD, MaxPerDay: 10000, MaxCountPerHour: 10},
+	}
+
+	testCase := baseTestCase{
+		name: "OK",
+		setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+		},
+		buildStubs: func(store *mockdb.MockStore) {
+			store.EXPECT().
+				ListTransferLimits(gomock.Any()).
+				Times(1).
+				Return(limits, nil)
+		},
+		checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var gotLimits []db.TransferLimit
+			err := json.Unmarshal(recorder.Body.Bytes(), &gotLimits)
+			require.NoError(t, err)
+			require.Equal(t, limits, gotLimits)
+		},
+	}
+
+	testCase.runTestCase(t, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, "/v1/admin/transfer-limits", nil)
+	})
+}
+
+func TestUpsertTransferLimitAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpsertTransferLimitParams{
+						Tier:            db.DefaultUserTier,
+						Currency:        util.EUR,
+						MaxPerTransfer:  5000,
+						MaxPerDay:       10000,
+						MaxPer30Days:    100000,
+						MaxCountPerHour: 10,
+					}
+					store.EXPECT().
+						UpsertTransferLimit(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.TransferLimit{Tier: db.DefaultUserTier, Currency: util.EUR}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"tier":               db.DefaultUserTier,
+				"currency":           util.EUR,
+				"max_per_transfer":   5000,
+				"max_per_day":        10000,
+				"max_per_30_days":    100000,
+				"max_count_per_hour": 10,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NegativeLimit",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertTransferLimit(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"tier":        db.DefaultUserTier,
+				"currency":    util.EUR,
+				"max_per_day": -1,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotAdmin",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpsertTransferLimit(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"tier":        db.DefaultUserTier,
+				"currency":    util.EUR,
+				"max_per_day": 10000,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPut, "/v1/admin/transfer-limits", tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestUpdateUserTierAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	premium := user
+	premium.Tier = "premium"
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpdateUserTierParams{
+						Username: user.Username,
+						Tier:     premium.Tier,
+					}
+					store.EXPECT().
+						UpdateUserTier(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(premium, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchUser(t, recorder.Body, premium)
+				},
+			},
+			body: gin.H{"tier": premium.Tier},
+		},
+		{
+			base: baseTestCase{
+				name: "UserNotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserTier(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.User{}, sql.ErrNoRows)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			body: gin.H{"tier": premium.Tier},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidTier",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserTier(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{"tier": "not a tier"},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/users/%s/tier", user.Username)
+			return newJSONRequest(http.MethodPut, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
@@ -2,10 +2,14 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -14,11 +18,12 @@ import (
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/wiliamhw/simplebank/db/mock"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/money"
 	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
-const transferURI = "/transfers"
+const transferURI = "/v1/transfers"
 
 func TestTransferAPI(t *testing.T) {
 	amount := int64(10)
@@ -35,9 +40,13 @@ func TestTransferAPI(t *testing.T) {
 	account2.Currency = util.USD
 	account3.Currency = util.EUR
 
+	idempotencyKey := util.RandomString(16)
+	limitResetsAt := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
 	testCases := []struct {
-		base baseTestCase
-		body gin.H
+		base           baseTestCase
+		body           gin.H
+		idempotencyKey string
 	}{
 		{
 			base: baseTestCase{
@@ -63,16 +72,27 @@ func TestTransferAPI(t *testing.T) {
 					}
 					store.EXPECT().
 						TransferTx(gomock.Any(), gomock.Eq(arg)).
-						Times(1)
+						Times(1).
+						Return(db.TransferTxResult{
+							Transfer:    randomTransfer(account1.ID, account2.ID),
+							FromAccount: account1,
+							ToAccount:   account2,
+						}, nil)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					fromAccount := rsp["from_account"].(map[string]interface{})
+					require.Equal(t, money.New(account1.Balance, util.USD).String(), fromAccount["balance"])
 				},
 			},
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -103,7 +123,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -128,7 +148,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -154,7 +174,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account1.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -185,7 +205,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -217,7 +237,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -249,7 +269,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account3.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -281,7 +301,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account3.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -308,7 +328,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        "XYZ",
 			},
 		},
@@ -335,7 +355,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          -amount,
+				"amount":          decimalAmount(-amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -363,7 +383,7 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
@@ -396,31 +416,1145 @@ func TestTransferAPI(t *testing.T) {
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
-				"amount":          amount,
+				"amount":          decimalAmount(amount, util.USD),
 				"currency":        util.USD,
 			},
 		},
-	}
+		{
+			base: baseTestCase{
+				name: "InsufficientFunds",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
 
-	for i := range testCases {
-		tc := testCases[i]
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
 
-		getRequest := func() (*http.Request, error) {
-			// Marshal body data to JSON
-			data, err := json.Marshal(tc.body)
-			if err != nil {
-				return nil, err
-			}
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, &db.InsufficientFundsError{
+							AccountID:        account1.ID,
+							AvailableBalance: amount - 1,
+							Amount:           amount,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeInsufficientFunds)
+					require.Equal(t, float64(amount-1), body.Metadata["available_balance"])
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AccountNotActive",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
 
-			request, err := http.NewRequest(
-				http.MethodPost, transferURI, bytes.NewReader(data),
-			)
-			if err != nil {
-				return nil, err
-			}
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
 
-			return request, err
-		}
-		tc.base.runTestCase(t, getRequest)
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, &db.AccountNotActiveError{
+							AccountID: account2.ID,
+							Status:    db.AccountStatusFrozen,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "FeeOverflow",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, db.ErrFeeOverflow)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "TransferLimitExceeded",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, &db.TransferLimitError{
+							Limit:    db.LimitPerDay,
+							Max:      100,
+							Used:     95,
+							ResetsAt: &limitResetsAt,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					body := requireErrorCode(t, recorder, codeTransferLimitExceeded)
+					require.Equal(t, db.LimitPerDay, body.Metadata["limit"])
+					require.Equal(t, float64(100), body.Metadata["max"])
+					require.Equal(t, float64(95), body.Metadata["used"])
+
+					resetsAt, err := time.Parse(time.RFC3339Nano, body.Metadata["resets_at"].(string))
+					require.NoError(t, err)
+					require.True(t, limitResetsAt.Equal(resetsAt))
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "IdempotentReplay",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					arg := db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+						Idempotency: &db.IdempotencyParams{
+							Username: user1.Username,
+							Key:      idempotencyKey,
+						},
+					}
+					store.EXPECT().
+						GetIdempotentTransfer(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.TransferTxResult{
+							FromAccount: account1,
+							ToAccount:   account2,
+							Replayed:    true,
+						}, nil)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Equal(t, "true", recorder.Header().Get(idempotencyReplayedHeader))
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+			idempotencyKey: idempotencyKey,
+		},
+		{
+			base: baseTestCase{
+				name: "IdempotencyKeyFirstUse",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						GetIdempotentTransfer(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, sql.ErrNoRows)
+
+					arg := db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+						Idempotency: &db.IdempotencyParams{
+							Username: user1.Username,
+							Key:      idempotencyKey,
+						},
+					}
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(db.TransferTxResult{
+							FromAccount: account1,
+							ToAccount:   account2,
+						}, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Empty(t, recorder.Header().Get(idempotencyReplayedHeader))
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+			idempotencyKey: idempotencyKey,
+		},
+		{
+			base: baseTestCase{
+				name: "IdempotencyKeyMismatch",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					store.EXPECT().
+						GetIdempotentTransfer(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, db.ErrIdempotencyKeyMismatch)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+			idempotencyKey: idempotencyKey,
+		},
+		{
+			base: baseTestCase{
+				name: "IdempotencyKeyTooLong",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+			idempotencyKey: util.RandomString(maxIdempotencyKeyLength + 1),
+		},
+		{
+			base: baseTestCase{
+				name: "ScheduledRunIdempotencyKey",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetIdempotentTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						TransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount(amount, util.USD),
+				"currency":        util.USD,
+			},
+			// Would replay the first run of scheduled transfer 7.
+			idempotencyKey: db.ScheduledRunKeyPrefix + "7:0",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			if err != nil {
+				return nil, err
+			}
+
+			request, err := http.NewRequest(
+				http.MethodPost, transferURI, bytes.NewReader(data),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(tc.idempotencyKey) > 0 {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+			return request, err
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func randomTransfer(fromAccountID, toAccountID int64) db.Transfer {
+	return db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        util.RandomInt(1, 100),
+	}
+}
+
+func TestGetTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.Currency = account1.Currency
+	transfer := randomTransfer(account1.ID, account2.ID)
+
+	testCases := []struct {
+		base       baseTestCase
+		transferID int64
+	}{
+		{
+			base: baseTestCase{
+				name: "OKSender",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(transfer, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchTransfer(t, recorder.Body, transfer, account1.Currency)
+				},
+			},
+			transferID: transfer.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "OKRecipient",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(transfer, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+						Times(1).
+						Return(account1, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchTransfer(t, recorder.Body, transfer, account1.Currency)
+				},
+			},
+			transferID: transfer.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(transfer, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(2).
+						DoAndReturn(func(_ context.Context, id int64) (db.Account, error) {
+							if id == account1.ID {
+								return account1, nil
+							}
+							return account2, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			transferID: transfer.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			transferID: transfer.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(db.Transfer{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+			transferID: transfer.ID,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidID",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			transferID: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v", transferURI, tc.transferID)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestListTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	n := 5
+	transfers := make([]db.ListOwnerTransfersRow, n)
+	for i := range transfers {
+		transfer := randomTransfer(account.ID, util.RandomInt(1001, 2000))
+		transfers[i] = db.ListOwnerTransfersRow{
+			ID:            transfer.ID,
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+			Currency:      account.Currency,
+		}
+	}
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	testCases := []struct {
+		base  baseTestCase
+		query url.Values
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					arg := db.ListOwnerTransfersParams{
+						IncludeOut: true,
+						IncludeIn:  false,
+						Owner:      user.Username,
+						AccountID:  account.ID,
+						FromTime:   from,
+						ToTime:     to,
+						Limit:      int32(n),
+						Offset:     0,
+					}
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(transfers, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var gotTransfers []gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &gotTransfers)
+					require.NoError(t, err)
+					require.Len(t, gotTransfers, n)
+					for i, transfer := range transfers {
+						require.Equal(t, float64(transfer.ID), gotTransfers[i]["id"])
+						require.Equal(t, money.New(transfer.Amount, account.Currency).String(), gotTransfers[i]["amount"])
+						require.Equal(t, account.Currency, gotTransfers[i]["currency"])
+					}
+				},
+			},
+			query: url.Values{
+				"account_id": {fmt.Sprint(account.ID)},
+				"direction":  {directionOut},
+				"from":       {from.Format(time.RFC3339)},
+				"to":         {to.Format(time.RFC3339)},
+				"page_id":    {"1"},
+				"page_size":  {fmt.Sprint(n)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AllAccounts",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ context.Context, arg db.ListOwnerTransfersParams) ([]db.ListOwnerTransfersRow, error) {
+							require.True(t, arg.IncludeIn)
+							require.True(t, arg.IncludeOut)
+							require.Zero(t, arg.AccountID)
+							require.Equal(t, user.Username, arg.Owner)
+							require.Equal(t, int32(n), arg.Offset)
+							return transfers, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			query: url.Values{
+				"page_id":   {"2"},
+				"page_size": {fmt.Sprint(n)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AccountOfAnotherUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+						Times(1).
+						Return(account, nil)
+
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			query: url.Values{
+				"account_id": {fmt.Sprint(account.ID)},
+				"page_id":    {"1"},
+				"page_size":  {fmt.Sprint(n)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidDirection",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			query: url.Values{
+				"direction": {"sideways"},
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListOwnerTransfers(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return([]db.ListOwnerTransfersRow{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v?%v", transferURI, tc.query.Encode())
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = account2.Currency
+	transfer := randomTransfer(account1.ID, account2.ID)
+	reversal := db.TransferTxResult{
+		Transfer:    randomTransfer(account2.ID, account1.ID),
+		FromAccount: account2,
+		ToAccount:   account1,
+	}
+
+	// Stubs for loading the transfer and its recipient.
+	getTransfer := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+			Times(1).
+			Return(transfer, nil)
+
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+			Times(1).
+			Return(account2, nil)
+	}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					arg := db.ReverseTransferTxParams{
+						TransferID:  transfer.ID,
+						ByRecipient: true,
+					}
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(reversal, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "Partial",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					arg := db.ReverseTransferTxParams{
+						TransferID:  transfer.ID,
+						Amount:      1,
+						ByRecipient: true,
+					}
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(reversal, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "FromHouseAccount",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, db.ErrReversalNotAllowed)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusForbidden, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "SenderCannotReverse",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "NotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(db.Transfer{}, sql.ErrNoRows)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusNotFound, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidAmount",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(-1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "AlreadyReversed",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, db.ErrTransferAlreadyReversed)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusConflict, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "ExceedsTransfer",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, db.ErrReversalExceedsTransfer)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(transfer.Amount+1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InsufficientFunds",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, &db.InsufficientFundsError{
+							AccountID: account2.ID,
+							Amount:    transfer.Amount,
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				},
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalError",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					getTransfer(store)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.TransferTxResult{}, sql.ErrConnDone)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%v/%v/reversals", transferURI, transfer.ID)
+			if tc.body == nil {
+				return http.NewRequest(http.MethodPost, url, nil)
+			}
+
+			data, err := json.Marshal(tc.body)
+			if err != nil {
+				return nil, err
+			}
+			return http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestAdminReverseTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = account2.Currency
+	transfer := randomTransfer(account1.ID, account2.ID)
+	reversal := db.TransferTxResult{
+		Transfer:    randomTransfer(account2.ID, account1.ID),
+		FromAccount: account2,
+		ToAccount:   account1,
+	}
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "SupportReverses",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).
+						Times(1).
+						Return(transfer, nil)
+
+					store.EXPECT().
+						GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+						Times(1).
+						Return(account2, nil)
+
+					arg := db.ReverseTransferTxParams{
+						TransferID: transfer.ID,
+						Amount:     1,
+					}
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(reversal, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"amount": decimalAmount(1, account2.Currency),
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "Customer",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetTransfer(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					store.EXPECT().
+						ReverseTransferTx(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/transfers/%d/reversals", transfer.ID)
+			return newJSONRequest(http.MethodPost, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func requireBodyMatchTransfer(t *testing.T, body *bytes.Buffer, transfer db.Transfer, currency string) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotTransfer gin.H
+	err = json.Unmarshal(data, &gotTransfer)
+	require.NoError(t, err)
+	require.Equal(t, float64(transfer.ID), gotTransfer["id"])
+	require.Equal(t, money.New(transfer.Amount, currency).String(), gotTransfer["amount"])
+	require.Equal(t, currency, gotTransfer["currency"])
+	if transfer.ReversalOf.Valid {
+		require.Equal(t, float64(transfer.ReversalOf.Int64), gotTransfer["reversal_of"])
+	} else {
+		require.Nil(t, gotTransfer["reversal_of"])
 	}
+	require.Nil(t, gotTransfer["fee_of"])
 }
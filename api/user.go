@@ -1,14 +1,14 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
@@ -25,6 +25,8 @@ type userResponse struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Tier              string    `json:"tier"`
+	Role              string    `json:"role"`
 }
 
 func newUserResponse(user db.User) userResponse {
@@ -34,19 +36,21 @@ func newUserResponse(user db.User) userResponse {
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		Tier:              user.Tier,
+		Role:              user.Role,
 	}
 }
 
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
@@ -57,16 +61,9 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Email:          req.Email,
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	user, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
@@ -91,41 +88,42 @@ type loginUserResponse struct {
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(invalidRequestError(err))
 		return
 	}
 
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(newAPIError(codeUnauthenticated, errors.New("incorrect password")))
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+	// The ID of the refresh token is the ID of the session.
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.AccessTokenDuration,
+		user.Role,
+		uuid.Nil,
+		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.RefreshTokenDuration,
+		user.Role,
+		refreshPayload.ID,
+		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
@@ -137,9 +135,11 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		// A login starts a new family of refresh tokens.
+		FamilyID: refreshPayload.ID,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
@@ -153,3 +153,82 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+type listUsersRequest struct {
+	Role     string `form:"role" binding:"omitempty,oneof=customer support admin"`
+	PageID   int32  `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+func (server *Server) listUsers(ctx *gin.Context) {
+	var req listUsersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	users, err := server.store.ListUsers(ctx, db.ListUsersParams{
+		Role:   req.Role,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]userResponse, len(users))
+	for i, user := range users {
+		rsp[i] = newUserResponse(user)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateUserRoleRequest struct {
+	uri  updateUserRoleRequestUri
+	body updateUserRoleRequestBody
+}
+
+type updateUserRoleRequestUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRoleRequestBody struct {
+	Role string `json:"role" binding:"required,oneof=customer support admin"`
+}
+
+/**
+ * Grants a role to a user. The role is carried by the tokens,
+ * so it takes effect when the user renews its access token or logs in again.
+ * Access tokens issued before keep the old role until they expire.
+ */
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req.body); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	// Keeps at least one admin, the one making the request.
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.uri.Username == authPayload.Username {
+		err := errors.New("cannot change your own role")
+		ctx.Error(newAPIError(codeForbidden, err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: req.uri.Username,
+		Role:     req.body.Role,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -8,8 +8,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -17,10 +19,11 @@ import (
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/wiliamhw/simplebank/db/mock"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
 	"github.com/wiliamhw/simplebank/util"
 )
 
-const userURI = "/users"
+const userURI = "/v1/users"
 
 func randomUser(t *testing.T) (user db.User, password string) {
 	password = util.RandomString(6)
@@ -32,6 +35,8 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName:       util.RandomOwner(),
 		Email:          util.RandomEmail(),
+		Tier:           db.DefaultUserTier,
+		Role:           util.CustomerRole,
 	}
 	return
 }
@@ -89,7 +94,7 @@ func TestCreateUserAPI(t *testing.T) {
 					}
 
 					store.EXPECT().
-						CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+						CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 						Times(1).
 						Return(user, nil)
 				},
@@ -105,7 +110,7 @@ func TestCreateUserAPI(t *testing.T) {
 				name: "InternalError",
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						CreateUser(gomock.Any(), gomock.Any()).
+						CreateUserTx(gomock.Any(), gomock.Any()).
 						Times(1).
 						Return(db.User{}, sql.ErrConnDone)
 				},
@@ -120,7 +125,7 @@ func TestCreateUserAPI(t *testing.T) {
 				name: "DuplicateUsername",
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						CreateUser(gomock.Any(), gomock.Any()).
+						CreateUserTx(gomock.Any(), gomock.Any()).
 						Times(1).
 						Return(db.User{}, &pq.Error{Code: "23505"})
 				},
@@ -135,7 +140,7 @@ func TestCreateUserAPI(t *testing.T) {
 				name: "InvalidUsername",
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						CreateUser(gomock.Any(), gomock.Any()).
+						CreateUserTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -154,7 +159,7 @@ func TestCreateUserAPI(t *testing.T) {
 				name: "InvalidEmail",
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						CreateUser(gomock.Any(), gomock.Any()).
+						CreateUserTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -173,7 +178,7 @@ func TestCreateUserAPI(t *testing.T) {
 				name: "TooShortPassword",
 				buildStubs: func(store *mockdb.MockStore) {
 					store.EXPECT().
-						CreateUser(gomock.Any(), gomock.Any()).
+						CreateUserTx(gomock.Any(), gomock.Any()).
 						Times(0)
 				},
 				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -334,6 +339,216 @@ func TestLoginUserAPI(t *testing.T) {
 	}
 }
 
+func TestListUsersAPI(t *testing.T) {
+	users := make([]db.User, 5)
+	for i := range users {
+		users[i], _ = randomUser(t)
+	}
+
+	testCases := []struct {
+		base  baseTestCase
+		query url.Values
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.ListUsersParams{
+						Role:   util.CustomerRole,
+						Limit:  5,
+						Offset: 5,
+					}
+					store.EXPECT().
+						ListUsers(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(users, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var gotUsers []db.User
+					err := json.Unmarshal(recorder.Body.Bytes(), &gotUsers)
+					require.NoError(t, err)
+					require.Len(t, gotUsers, len(users))
+					for i, user := range users {
+						require.Equal(t, user.Username, gotUsers[i].Username)
+						require.Equal(t, user.Role, gotUsers[i].Role)
+						require.Empty(t, gotUsers[i].HashedPassword)
+					}
+				},
+			},
+			query: url.Values{"role": {util.CustomerRole}, "page_id": {"2"}, "page_size": {"5"}},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidRole",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListUsers(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeValidationFailed)
+				},
+			},
+			query: url.Values{"role": {"root"}, "page_id": {"1"}, "page_size": {"5"}},
+		},
+		{
+			base: baseTestCase{
+				name: "Customer",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, users[0].Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListUsers(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+			query: url.Values{"page_id": {"1"}, "page_size": {"5"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, "/v1/admin/users?"+tc.query.Encode(), nil)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestUpdateUserRoleAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	supportUser := user
+	supportUser.Role = util.SupportRole
+
+	testCases := []struct {
+		base     baseTestCase
+		username string
+		body     gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					arg := db.UpdateUserRoleParams{
+						Username: user.Username,
+						Role:     util.SupportRole,
+					}
+					store.EXPECT().
+						UpdateUserRole(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(supportUser, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					requireBodyMatchUser(t, recorder.Body, supportUser)
+				},
+			},
+			username: user.Username,
+			body:     gin.H{"role": util.SupportRole},
+		},
+		{
+			base: baseTestCase{
+				name: "OwnRole",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserRole(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+			username: testAdminUsername,
+			body:     gin.H{"role": util.CustomerRole},
+		},
+		{
+			base: baseTestCase{
+				name: "SupportCannotGrantRoles",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.SupportRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserRole(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeForbidden)
+				},
+			},
+			username: user.Username,
+			body:     gin.H{"role": util.AdminRole},
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidRole",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserRole(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeValidationFailed)
+				},
+			},
+			username: user.Username,
+			body:     gin.H{"role": "root"},
+		},
+		{
+			base: baseTestCase{
+				name: "UserNotFound",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addRoleAuthorization(t, request, tokenMaker, authorizationTypeBearer, testAdminUsername, util.AdminRole, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateUserRole(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.User{}, sql.ErrNoRows)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					requireErrorCode(t, recorder, codeNotFound)
+				},
+			},
+			username: user.Username,
+			body:     gin.H{"role": util.SupportRole},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("/v1/admin/users/%s/role", tc.username)
+			return newJSONRequest(http.MethodPut, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
 func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
@@ -345,5 +560,7 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.Username, gotUser.Username)
 	require.Equal(t, user.FullName, gotUser.FullName)
 	require.Equal(t, user.Email, gotUser.Email)
+	require.Equal(t, user.Tier, gotUser.Tier)
+	require.Equal(t, user.Role, gotUser.Role)
 	require.Empty(t, gotUser.HashedPassword)
 }
@@ -1,13 +1,77 @@
 package api
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/wiliamhw/simplebank/util"
 )
 
+// Accepts the enabled currencies, looked up through the currency cache.
 var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	if currency, ok := fl.Field().Interface().(string); ok {
 		return util.IsSupportedCurrency(currency)
 	}
 	return false
 }
+
+// Names fields in validation errors the way clients send them, from the json, form or uri tag.
+func validationFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Describes a failed binding rule to the client.
+func violationMessage(fieldErr validator.FieldError) string {
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", fieldErr.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", fieldErr.Param(), unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fieldErr.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+	case "alpha":
+		return "must contain only letters"
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "uppercase":
+		return "must be upper case"
+	case "numeric":
+		return "must be numeric"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	case "currency":
+		return "must be an enabled currency"
+	case "nefield":
+		return fmt.Sprintf("must differ from %s", fieldErr.Param())
+	}
+	return fmt.Sprintf("failed on the %s rule", fieldErr.Tag())
+}
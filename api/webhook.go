@@ -0,0 +1,377 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Returned when redelivering a delivery that is being sent.
+var errWebhookDeliveryLocked = errors.New("webhook delivery is being sent")
+
+// Where events are sent, and which of them. No event type means all of them.
+type webhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"omitempty,dive,oneof=transfer.created account.created user.created"`
+}
+
+/**
+ * Checks the subscription and fills in its defaults.
+ * URLs obviously pointing into our own network are refused early,
+ * the dispatcher checks the address again each time it connects.
+ */
+func (req *webhookRequest) validate() error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); (ip != nil && !util.IsPublicIP(ip)) ||
+		host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url must point to a public address")
+	}
+	if req.EventTypes == nil {
+		req.EventTypes = []string{}
+	}
+	return nil
+}
+
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// The secret is left out, it is only returned when the subscription is created.
+func newWebhookResponse(subscription db.WebhookSubscription) webhookResponse {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return webhookResponse{
+		ID:         subscription.ID,
+		Owner:      subscription.Owner,
+		URL:        subscription.Url,
+		EventTypes: eventTypes,
+		Enabled:    subscription.Enabled,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	// Key of the signatures of the deliveries.
+	Secret string `json:"secret"`
+}
+
+// Generates the key the deliveries of a subscription are signed with.
+func newWebhookSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	subscription, err := server.store.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:      authPayload.Username,
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{
+		webhookResponse: newWebhookResponse(subscription),
+		Secret:          subscription.Secret,
+	})
+}
+
+type listWebhookRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+func (server *Server) listWebhook(ctx *gin.Context) {
+	var req listWebhookRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	subscriptions, err := server.store.ListWebhookSubscriptions(ctx, db.ListWebhookSubscriptionsParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]webhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		rsp[i] = newWebhookResponse(subscription)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type webhookUriRequest struct {
+	ID int64 `uri:"id" binding:"required,numeric,min=1"`
+}
+
+func (server *Server) getWebhook(ctx *gin.Context) {
+	var req webhookUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	subscription, valid := server.ownedWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+type updateWebhookRequest struct {
+	uri  webhookUriRequest
+	body updateWebhookRequestBody
+}
+
+type updateWebhookRequestBody struct {
+	webhookRequest
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// Replaces the URL, the event types and the enabled flag of a subscription.
+// Deliveries of a disabled subscription are dead until they are redelivered.
+func (server *Server) updateWebhook(ctx *gin.Context) {
+	var req updateWebhookRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req.body); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := req.body.webhookRequest.validate(); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if _, valid := server.ownedWebhook(ctx, req.uri.ID); !valid {
+		return
+	}
+
+	subscription, err := server.store.UpdateWebhookSubscription(ctx, db.UpdateWebhookSubscriptionParams{
+		ID:         req.uri.ID,
+		Url:        req.body.URL,
+		EventTypes: req.body.EventTypes,
+		Enabled:    *req.body.Enabled,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+// Deletes a subscription along with its deliveries.
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var req webhookUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	subscription, valid := server.ownedWebhook(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	if err := server.store.DeleteWebhookSubscription(ctx, req.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+/**
+ * A delivery as shown to the owner of its subscription.
+ * The status of the endpoint's responses is left out, the dispatcher
+ * only keeps the kind of failure in last_error.
+ */
+type webhookDeliveryResponse struct {
+	ID             int64      `json:"id"`
+	EventID        int64      `json:"event_id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      *string    `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	return webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		SubscriptionID: delivery.SubscriptionID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      nullString(delivery.LastError),
+		DeliveredAt:    nullTime(delivery.DeliveredAt),
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+type listWebhookDeliveryRequest struct {
+	uri   webhookUriRequest
+	query listWebhookDeliveryQuery
+}
+
+type listWebhookDeliveryQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	PageID   int32  `form:"page_id" binding:"required,numeric,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,numeric,min=5,max=10"`
+}
+
+// Lists the deliveries of a subscription, most recent first.
+func (server *Server) listWebhookDelivery(ctx *gin.Context) {
+	var req listWebhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req.uri); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req.query); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if _, valid := server.ownedWebhook(ctx, req.uri.ID); !valid {
+		return
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: req.uri.ID,
+		Status:         req.query.Status,
+		Limit:          req.query.PageSize,
+		Offset:         (req.query.PageID - 1) * req.query.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		rsp[i] = newWebhookDeliveryResponse(delivery)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type webhookDeliveryUriRequest struct {
+	ID         int64 `uri:"id" binding:"required,numeric,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,numeric,min=1"`
+}
+
+/**
+ * Sends a delivery again as soon as possible, with a fresh set of attempts.
+ * Dead deliveries are revived, delivered ones are sent once more.
+ */
+func (server *Server) redeliverWebhookDelivery(ctx *gin.Context) {
+	var req webhookDeliveryUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(invalidRequestError(err))
+		return
+	}
+
+	if _, valid := server.ownedWebhook(ctx, req.ID); !valid {
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if delivery.SubscriptionID != req.ID {
+		ctx.Error(sql.ErrNoRows)
+		return
+	}
+
+	delivery, err = server.store.RedeliverWebhookDelivery(ctx, req.DeliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errWebhookDeliveryLocked
+		}
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookDeliveryResponse(delivery))
+}
+
+// Loads a webhook subscription of the current user.
+func (server *Server) ownedWebhook(ctx *gin.Context, id int64) (db.WebhookSubscription, bool) {
+	subscription, err := server.store.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return subscription, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if subscription.Owner != authPayload.Username {
+		err := errors.New("webhook doesn't belong to the authenticated user")
+		ctx.Error(newAPIError(codeNotOwner, err))
+		return subscription, false
+	}
+	return subscription, true
+}
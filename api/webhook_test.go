@@ -0,0 +1,662 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+const webhookURI = "/v1/webhooks"
+
+func randomWebhook(owner string) db.WebhookSubscription {
+	return db.WebhookSubscription{
+		ID:         util.RandomInt(1, 1000),
+		Owner:      owner,
+		Url:        "https://example.com/" + util.RandomString(6),
+		Secret:     "whsec_" + util.RandomString(32),
+		EventTypes: []string{db.EventTransferCreated},
+		Enabled:    true,
+		CreatedAt:  time.Now().Truncate(time.Second).UTC(),
+		UpdatedAt:  time.Now().Truncate(time.Second).UTC(),
+	}
+}
+
+func TestCreateWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+							require.Equal(t, user.Username, arg.Owner)
+							require.Equal(t, webhook.Url, arg.Url)
+							require.Equal(t, webhook.EventTypes, arg.EventTypes)
+							require.True(t, strings.HasPrefix(arg.Secret, "whsec_"))
+							require.Len(t, arg.Secret, len("whsec_")+64)
+
+							created := webhook
+							created.Secret = arg.Secret
+							return created, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var rsp gin.H
+					err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+					require.NoError(t, err)
+					require.Equal(t, webhook.Url, rsp["url"])
+					require.True(t, strings.HasPrefix(rsp["secret"].(string), "whsec_"))
+				},
+			},
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": webhook.EventTypes,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "DefaultsToAllEvents",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+							require.NotNil(t, arg.EventTypes)
+							require.Empty(t, arg.EventTypes)
+							return webhook, nil
+						})
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": webhook.Url,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "UnsupportedScheme",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": "ftp://example.com/hooks",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "InternalURL",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": "http://169.254.169.254/latest/meta-data",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "LocalhostURL",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": "http://localhost:8080/hooks",
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "UnknownEventType",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url":         webhook.Url,
+				"event_types": []string{"transfer.deleted"},
+			},
+		},
+		{
+			base: baseTestCase{
+				name:      "NoAuthorization",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						CreateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": webhook.Url,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			return newJSONRequest(http.MethodPost, webhookURI, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestGetWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				expected, err := json.Marshal(newWebhookResponse(webhook))
+				require.NoError(t, err)
+				require.JSONEq(t, string(expected), recorder.Body.String())
+				require.NotContains(t, recorder.Body.String(), webhook.Secret)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(db.WebhookSubscription{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", webhookURI, webhook.ID)
+			return http.NewRequest(http.MethodGet, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestUpdateWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	testCases := []struct {
+		base baseTestCase
+		body gin.H
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+						Times(1).
+						Return(webhook, nil)
+
+					arg := db.UpdateWebhookSubscriptionParams{
+						ID:         webhook.ID,
+						Url:        "https://example.com/new",
+						EventTypes: []string{db.EventAccountCreated, db.EventUserCreated},
+						Enabled:    false,
+					}
+					store.EXPECT().
+						UpdateWebhookSubscription(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(webhook, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url":         "https://example.com/new",
+				"event_types": []string{db.EventAccountCreated, db.EventUserCreated},
+				"enabled":     false,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "MissingEnabled",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						UpdateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url": webhook.Url,
+			},
+		},
+		{
+			base: baseTestCase{
+				name: "UnauthorizedUser",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+						Times(1).
+						Return(webhook, nil)
+
+					store.EXPECT().
+						UpdateWebhookSubscription(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				},
+			},
+			body: gin.H{
+				"url":     webhook.Url,
+				"enabled": true,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", webhookURI, webhook.ID)
+			return newJSONRequest(http.MethodPut, url, tc.body)
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestDeleteWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				store.EXPECT().
+					DeleteWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				store.EXPECT().
+					DeleteWebhookSubscription(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d", webhookURI, webhook.ID)
+			return http.NewRequest(http.MethodDelete, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
+
+func TestListWebhookDeliveryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	deliveries := []db.WebhookDelivery{
+		{
+			ID:             2,
+			EventID:        9,
+			SubscriptionID: webhook.ID,
+			Status:         db.DeliveryStatusDead,
+			Attempts:       8,
+			LastError:      sql.NullString{String: "endpoint responded with an error status", Valid: true},
+			ResponseStatus: sql.NullInt32{Int32: http.StatusNotFound, Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		base   baseTestCase
+		status string
+	}{
+		{
+			base: baseTestCase{
+				name: "OK",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+						Times(1).
+						Return(webhook, nil)
+
+					arg := db.ListWebhookDeliveriesParams{
+						SubscriptionID: webhook.ID,
+						Status:         db.DeliveryStatusDead,
+						Limit:          5,
+						Offset:         0,
+					}
+					store.EXPECT().
+						ListWebhookDeliveries(gomock.Any(), gomock.Eq(arg)).
+						Times(1).
+						Return(deliveries, nil)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusOK, recorder.Code)
+
+					var got []webhookDeliveryResponse
+					err := json.Unmarshal(recorder.Body.Bytes(), &got)
+					require.NoError(t, err)
+					require.Equal(t, []webhookDeliveryResponse{newWebhookDeliveryResponse(deliveries[0])}, got)
+					require.Equal(t, deliveries[0].LastError.String, *got[0].LastError)
+
+					// What the endpoint answered stays with the dispatcher.
+					require.NotContains(t, recorder.Body.String(), "response_status")
+					require.NotContains(t, recorder.Body.String(), "locked_until")
+				},
+			},
+			status: db.DeliveryStatusDead,
+		},
+		{
+			base: baseTestCase{
+				name: "InvalidStatus",
+				setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+					addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				},
+				buildStubs: func(store *mockdb.MockStore) {
+					store.EXPECT().
+						ListWebhookDeliveries(gomock.Any(), gomock.Any()).
+						Times(0)
+				},
+				checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+					require.Equal(t, http.StatusBadRequest, recorder.Code)
+				},
+			},
+			status: "failed",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d/deliveries", webhookURI, webhook.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			q := request.URL.Query()
+			q.Add("status", tc.status)
+			q.Add("page_id", "1")
+			q.Add("page_size", "5")
+			request.URL.RawQuery = q.Encode()
+			return request, nil
+		}
+		tc.base.runTestCase(t, getRequest)
+	}
+}
+
+func TestRedeliverWebhookDeliveryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	webhook := randomWebhook(user.Username)
+
+	delivery := db.WebhookDelivery{
+		ID:             util.RandomInt(1, 1000),
+		EventID:        util.RandomInt(1, 1000),
+		SubscriptionID: webhook.ID,
+		Status:         db.DeliveryStatusDead,
+		Attempts:       8,
+	}
+
+	testCases := []baseTestCase{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+
+				redelivered := delivery
+				redelivered.Status = db.DeliveryStatusPending
+				redelivered.Attempts = 0
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(redelivered, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got webhookDeliveryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.DeliveryStatusPending, got.Status)
+				require.Zero(t, got.Attempts)
+			},
+		},
+		{
+			name: "OtherSubscription",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				other := delivery
+				other.SubscriptionID = webhook.ID + 1
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(other, nil)
+
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BeingSent",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(db.WebhookDelivery{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookSubscription(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		getRequest := func() (*http.Request, error) {
+			url := fmt.Sprintf("%s/%d/deliveries/%d/redeliver", webhookURI, webhook.ID, delivery.ID)
+			return http.NewRequest(http.MethodPost, url, nil)
+		}
+		tc.runTestCase(t, getRequest)
+	}
+}
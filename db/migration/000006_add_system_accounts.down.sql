@@ -0,0 +1,7 @@
+DELETE FROM "entries" WHERE "account_id" IN (
+  SELECT "id" FROM "accounts" WHERE "owner" = 'system_cash'
+);
+
+DELETE FROM "accounts" WHERE "owner" = 'system_cash';
+
+DELETE FROM "users" WHERE "username" = 'system_cash';
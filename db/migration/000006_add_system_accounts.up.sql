@@ -0,0 +1,10 @@
+-- System users can't log in (empty password hash) and their usernames
+-- can't be registered through the API, which only accepts alphanumeric names.
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email") VALUES
+  ('system_cash', '', 'Cash settlement', 'system_cash@simplebank.internal');
+
+-- Counterparty of every deposit and withdrawal, one per currency.
+INSERT INTO "accounts" ("owner", "balance", "currency") VALUES
+  ('system_cash', 0, 'EUR'),
+  ('system_cash', 0, 'USD'),
+  ('system_cash', 0, 'CAD');
@@ -0,0 +1,19 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that posted the entry';
+
+-- Entries and their transfer were created in the same transaction,
+-- so they share the same now() timestamp.
+UPDATE "entries" AS e
+SET "transfer_id" = t."id"
+FROM "transfers" AS t
+WHERE e."transfer_id" IS NULL
+  AND e."created_at" = t."created_at"
+  AND (
+    (e."account_id" = t."from_account_id" AND e."amount" = -t."amount") OR
+    (e."account_id" = t."to_account_id" AND e."amount" = t."amount")
+  );
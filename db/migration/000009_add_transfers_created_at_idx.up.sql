@@ -0,0 +1,3 @@
+CREATE INDEX "transfers_from_account_id_created_at_idx" ON "transfers" ("from_account_id", "created_at");
+
+CREATE INDEX "transfers_to_account_id_created_at_idx" ON "transfers" ("to_account_id", "created_at");
@@ -0,0 +1,8 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+-- A transfer can be reversed several times, up to its amount.
+CREATE INDEX ON "transfers" ("reversal_of");
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'transfer reversed by this one';
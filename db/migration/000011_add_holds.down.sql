@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "holds";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "held_balance_non_negative";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "held_balance";
@@ -0,0 +1,35 @@
+ALTER TABLE "accounts" ADD COLUMN "held_balance" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_balance_non_negative" CHECK ("held_balance" >= 0);
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'sum of the active holds on the account';
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_status_valid" CHECK ("status" IN ('active', 'captured', 'voided', 'expired'));
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'active, captured, voided or expired';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer that captured the hold';
@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status_valid" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "fx_quotes";
+
+DROP TABLE IF EXISTS "fx_rates";
+
+DELETE FROM "entries" WHERE "account_id" IN (
+  SELECT "id" FROM "accounts" WHERE "owner" = 'system_fx'
+);
+
+DELETE FROM "accounts" WHERE "owner" = 'system_fx';
+
+DELETE FROM "users" WHERE "username" = 'system_fx';
@@ -0,0 +1,49 @@
+CREATE TABLE "fx_rates" (
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("from_currency", "to_currency")
+);
+
+ALTER TABLE "fx_rates" ADD CONSTRAINT "fx_rate_positive" CHECK ("rate" > 0);
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'units of to_currency bought by one unit of from_currency';
+
+CREATE TABLE "fx_quotes" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "from_amount" bigint NOT NULL,
+  "to_amount" bigint NOT NULL,
+  "debit_transfer_id" bigint,
+  "credit_transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("debit_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("credit_transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "fx_quotes" ("owner");
+
+COMMENT ON COLUMN "fx_quotes"."rate" IS 'rate locked in when the quote was created';
+
+COMMENT ON COLUMN "fx_quotes"."debit_transfer_id" IS 'transfer from the customer to the house account';
+
+COMMENT ON COLUMN "fx_quotes"."credit_transfer_id" IS 'transfer from the house account to the customer';
+
+-- Counterparty of every conversion, one house account per currency.
+-- Like system_cash, the user can't log in.
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email") VALUES
+  ('system_fx', '', 'Currency exchange', 'system_fx@simplebank.internal');
+
+INSERT INTO "accounts" ("owner", "balance", "currency") VALUES
+  ('system_fx', 0, 'EUR'),
+  ('system_fx', 0, 'USD'),
+  ('system_fx', 0, 'CAD');
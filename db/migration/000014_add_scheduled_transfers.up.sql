@@ -0,0 +1,53 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "recurrence" varchar NOT NULL DEFAULT 'once',
+  "day_of_month" integer NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "next_run_at" timestamptz NOT NULL,
+  "run_count" integer NOT NULL DEFAULT 0,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "retry_at" timestamptz,
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "last_transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfer_recurrence_valid" CHECK ("recurrence" IN ('once', 'weekly', 'monthly'));
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfer_status_valid" CHECK ("status" IN ('active', 'completed', 'failed', 'cancelled'));
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("last_transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "next_run_at");
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."recurrence" IS 'once, weekly or monthly';
+
+COMMENT ON COLUMN "scheduled_transfers"."day_of_month" IS 'day of monthly runs, clamped to the length of the month';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active, completed, failed or cancelled';
+
+COMMENT ON COLUMN "scheduled_transfers"."run_count" IS 'successful runs, part of the idempotency key of the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts of the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."retry_at" IS 'earliest time of the next attempt after a failure';
+
+COMMENT ON COLUMN "scheduled_transfers"."locked_until" IS 'lease of the worker executing the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."last_transfer_id" IS 'transfer made by the last successful run';
@@ -0,0 +1,17 @@
+DELETE FROM "entries" WHERE "transfer_id" IN (
+  SELECT "id" FROM "transfers" WHERE "fee_of" IS NOT NULL
+);
+
+DELETE FROM "transfers" WHERE "fee_of" IS NOT NULL;
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee_of";
+
+DROP TABLE IF EXISTS "fee_schedules";
+
+DELETE FROM "entries" WHERE "account_id" IN (
+  SELECT "id" FROM "accounts" WHERE "owner" = 'system_fees'
+);
+
+DELETE FROM "accounts" WHERE "owner" = 'system_fees';
+
+DELETE FROM "users" WHERE "username" = 'system_fees';
@@ -0,0 +1,38 @@
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percentage_bps" integer NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedule_non_negative" CHECK ("min_amount" >= 0 AND "flat_fee" >= 0);
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fee_schedule_percentage_valid" CHECK ("percentage_bps" BETWEEN 0 AND 10000);
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "min_amount");
+
+COMMENT ON COLUMN "fee_schedules"."min_amount" IS 'smallest transfer amount the tier applies to';
+
+COMMENT ON COLUMN "fee_schedules"."percentage_bps" IS 'percentage of the amount in basis points, added to the flat fee';
+
+ALTER TABLE "transfers" ADD COLUMN "fee_of" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_of") REFERENCES "transfers" ("id");
+
+-- A transfer is charged at most one fee.
+CREATE UNIQUE INDEX ON "transfers" ("fee_of");
+
+COMMENT ON COLUMN "transfers"."fee_of" IS 'transfer charged by this fee';
+
+-- Collects the transfer fees, one revenue account per currency.
+-- Like system_cash, the user can't log in.
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email") VALUES
+  ('system_fees', '', 'Fee revenue', 'system_fees@simplebank.internal');
+
+INSERT INTO "accounts" ("owner", "balance", "currency") VALUES
+  ('system_fees', 0, 'EUR'),
+  ('system_fees', 0, 'USD'),
+  ('system_fees', 0, 'CAD');
@@ -0,0 +1,26 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+COMMENT ON COLUMN "users"."tier" IS 'selects the transfer limits of the user';
+
+CREATE TABLE "transfer_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "max_per_transfer" bigint NOT NULL DEFAULT 0,
+  "max_per_day" bigint NOT NULL DEFAULT 0,
+  "max_per_30_days" bigint NOT NULL DEFAULT 0,
+  "max_count_per_hour" integer NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency")
+);
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limit_non_negative" CHECK (
+  "max_per_transfer" >= 0 AND "max_per_day" >= 0 AND "max_per_30_days" >= 0 AND "max_count_per_hour" >= 0
+);
+
+COMMENT ON COLUMN "transfer_limits"."max_per_transfer" IS 'zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_per_day" IS 'total sent per UTC calendar day, zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_per_30_days" IS 'total sent over the last 30 days, zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_count_per_hour" IS 'transfers sent over the last hour, zero means no limit';
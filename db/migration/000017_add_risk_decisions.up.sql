@@ -0,0 +1,34 @@
+CREATE TABLE "risk_decisions" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "session_id" uuid,
+  "client_ip" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "matches" jsonb NOT NULL DEFAULT ('[]'),
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "risk_decisions" ("username");
+
+CREATE INDEX ON "risk_decisions" ("action", "created_at");
+
+COMMENT ON COLUMN "risk_decisions"."session_id" IS 'session the transfer was requested from, if known';
+
+COMMENT ON COLUMN "risk_decisions"."action" IS 'allow, deny or flag';
+
+COMMENT ON COLUMN "risk_decisions"."matches" IS 'rules that matched, with their action and reason';
+
+COMMENT ON COLUMN "risk_decisions"."transfer_id" IS 'transfer made after the decision, if any';
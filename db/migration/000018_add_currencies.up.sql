@@ -0,0 +1,25 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" integer NOT NULL,
+  "symbol" varchar NOT NULL DEFAULT '',
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "currency_code_valid" CHECK ("code" ~ '^[A-Z]{3}$');
+
+ALTER TABLE "currencies" ADD CONSTRAINT "currency_exponent_valid" CHECK ("exponent" BETWEEN 0 AND 4);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'decimal places of the minor unit amounts are expressed in';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'disabled currencies are rejected by new requests';
+
+INSERT INTO "currencies" ("code", "exponent", "symbol") VALUES
+  ('EUR', 2, '€'),
+  ('USD', 2, '$'),
+  ('CAD', 2, 'CA$');
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
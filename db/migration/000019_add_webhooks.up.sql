@@ -0,0 +1,66 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "usernames" varchar[] NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "dispatched_at" timestamptz
+);
+
+CREATE TABLE "webhook_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT ('{}'),
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "event_id" bigint NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "response_status" integer,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD CONSTRAINT "webhook_delivery_status_valid" CHECK ("status" IN ('pending', 'delivered', 'dead'));
+
+ALTER TABLE "webhook_subscriptions" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "outbox_events" ("dispatched_at", "id");
+
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("event_id", "subscription_id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "next_attempt_at");
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id", "id");
+
+COMMENT ON COLUMN "outbox_events"."usernames" IS 'users the event concerns, whose subscriptions receive it';
+
+COMMENT ON COLUMN "outbox_events"."dispatched_at" IS 'when deliveries were created for the subscriptions';
+
+COMMENT ON COLUMN "webhook_subscriptions"."secret" IS 'key of the HMAC-SHA256 signature of deliveries';
+
+COMMENT ON COLUMN "webhook_subscriptions"."event_types" IS 'empty means every event type';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, delivered or dead';
+
+COMMENT ON COLUMN "webhook_deliveries"."locked_until" IS 'lease of the dispatcher sending the delivery';
+
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status of the last attempt, if a response was received';
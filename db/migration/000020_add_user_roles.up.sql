@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "users" ADD CONSTRAINT "user_role_valid" CHECK ("role" IN ('customer', 'support', 'admin'));
+
+COMMENT ON COLUMN "users"."role" IS 'customer, support or admin';
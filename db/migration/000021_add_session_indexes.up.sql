@@ -0,0 +1,3 @@
+CREATE INDEX "sessions_username_idx" ON "sessions" ("username");
+
+CREATE INDEX "sessions_expires_at_idx" ON "sessions" ("expires_at");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "sessions_family_id_idx";
+
+ALTER TABLE IF EXISTS "sessions" DROP COLUMN IF EXISTS "rotated_at";
+
+ALTER TABLE IF EXISTS "sessions" DROP COLUMN IF EXISTS "family_id";
@@ -0,0 +1,13 @@
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "rotated_at" timestamptz;
+
+CREATE INDEX "sessions_family_id_idx" ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'ID of the session opened at login, shared by every rotation of its refresh token';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'when the refresh token was exchanged for a new one, presenting it again revokes the family';
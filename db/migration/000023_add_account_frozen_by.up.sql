@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" ADD COLUMN "frozen_by" varchar;
+
+COMMENT ON COLUMN "accounts"."frozen_by" IS 'username of who froze the account, only set while it is frozen';
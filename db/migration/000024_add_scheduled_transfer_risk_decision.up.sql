@@ -0,0 +1,5 @@
+ALTER TABLE "scheduled_transfers" ADD COLUMN "risk_decision_id" bigint;
+
+COMMENT ON COLUMN "scheduled_transfers"."risk_decision_id" IS 'assessment of the last create or edit, linked to the transfer of the next run';
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("risk_decision_id") REFERENCES "risk_decisions" ("id");
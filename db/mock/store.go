@@ -6,7 +6,9 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -51,6 +53,156 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
 }
 
+// AddAccountHeldBalance mocks base method.
+func (m *MockStore) AddAccountHeldBalance(arg0 context.Context, arg1 db.AddAccountHeldBalanceParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountHeldBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountHeldBalance indicates an expected call of AddAccountHeldBalance.
+func (mr *MockStoreMockRecorder) AddAccountHeldBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountHeldBalance", reflect.TypeOf((*MockStore)(nil).AddAccountHeldBalance), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockSessionFamily mocks base method.
+func (m *MockStore) BlockSessionFamily(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSessionFamily", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSessionFamily indicates an expected call of BlockSessionFamily.
+func (mr *MockStoreMockRecorder) BlockSessionFamily(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSessionFamily", reflect.TypeOf((*MockStore)(nil).BlockSessionFamily), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 db.BlockUserSessionsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// CancelScheduledTransfer mocks base method.
+func (m *MockStore) CancelScheduledTransfer(arg0 context.Context, arg1 int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockStoreMockRecorder) CancelScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CancelScheduledTransfer), arg0, arg1)
+}
+
+// CaptureHoldTx mocks base method.
+func (m *MockStore) CaptureHoldTx(arg0 context.Context, arg1 db.CaptureHoldTxParams) (db.CaptureHoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CaptureHoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHoldTx indicates an expected call of CaptureHoldTx.
+func (mr *MockStoreMockRecorder) CaptureHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHoldTx", reflect.TypeOf((*MockStore)(nil).CaptureHoldTx), arg0, arg1)
+}
+
+// ClaimScheduledTransfer mocks base method.
+func (m *MockStore) ClaimScheduledTransfer(arg0 context.Context, arg1 db.ClaimScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimScheduledTransfer indicates an expected call of ClaimScheduledTransfer.
+func (mr *MockStoreMockRecorder) ClaimScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimScheduledTransfer", reflect.TypeOf((*MockStore)(nil).ClaimScheduledTransfer), arg0, arg1)
+}
+
+// ClaimWebhookDelivery mocks base method.
+func (m *MockStore) ClaimWebhookDelivery(arg0 context.Context, arg1 db.ClaimWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDelivery indicates an expected call of ClaimWebhookDelivery.
+func (mr *MockStoreMockRecorder) ClaimWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDelivery", reflect.TypeOf((*MockStore)(nil).ClaimWebhookDelivery), arg0, arg1)
+}
+
+// CloseAccountTx mocks base method.
+func (m *MockStore) CloseAccountTx(arg0 context.Context, arg1 db.CloseAccountTxParams) (db.CloseAccountTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CloseAccountTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseAccountTx indicates an expected call of CloseAccountTx.
+func (mr *MockStoreMockRecorder) CloseAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAccountTx", reflect.TypeOf((*MockStore)(nil).CloseAccountTx), arg0, arg1)
+}
+
+// ConvertTx mocks base method.
+func (m *MockStore) ConvertTx(arg0 context.Context, arg1 db.ConvertTxParams) (db.ConvertTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ConvertTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertTx indicates an expected call of ConvertTx.
+func (mr *MockStoreMockRecorder) ConvertTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertTx", reflect.TypeOf((*MockStore)(nil).ConvertTx), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +218,51 @@ func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
 }
 
+// CreateAccountTx mocks base method.
+func (m *MockStore) CreateAccountTx(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockStoreMockRecorder) CreateAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockStore)(nil).CreateAccountTx), arg0, arg1)
+}
+
+// CreateCurrency mocks base method.
+func (m *MockStore) CreateCurrency(arg0 context.Context, arg1 db.CreateCurrencyParams) (db.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCurrency", arg0, arg1)
+	ret0, _ := ret[0].(db.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCurrency indicates an expected call of CreateCurrency.
+func (mr *MockStoreMockRecorder) CreateCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCurrency", reflect.TypeOf((*MockStore)(nil).CreateCurrency), arg0, arg1)
+}
+
+// CreateCurrencyTx mocks base method.
+func (m *MockStore) CreateCurrencyTx(arg0 context.Context, arg1 db.CreateCurrencyParams) (db.CreateCurrencyTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCurrencyTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateCurrencyTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCurrencyTx indicates an expected call of CreateCurrencyTx.
+func (mr *MockStoreMockRecorder) CreateCurrencyTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCurrencyTx", reflect.TypeOf((*MockStore)(nil).CreateCurrencyTx), arg0, arg1)
+}
+
 // CreateEntry mocks base method.
 func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
 	m.ctrl.T.Helper()
@@ -81,226 +278,1302 @@ func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
 }
 
+// CreateFeeTransfer mocks base method.
+func (m *MockStore) CreateFeeTransfer(arg0 context.Context, arg1 db.CreateFeeTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeeTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFeeTransfer indicates an expected call of CreateFeeTransfer.
+func (mr *MockStoreMockRecorder) CreateFeeTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeeTransfer", reflect.TypeOf((*MockStore)(nil).CreateFeeTransfer), arg0, arg1)
+}
+
+// CreateFxQuote mocks base method.
+func (m *MockStore) CreateFxQuote(arg0 context.Context, arg1 db.CreateFxQuoteParams) (db.FxQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFxQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.FxQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFxQuote indicates an expected call of CreateFxQuote.
+func (mr *MockStoreMockRecorder) CreateFxQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFxQuote", reflect.TypeOf((*MockStore)(nil).CreateFxQuote), arg0, arg1)
+}
+
+// CreateHold mocks base method.
+func (m *MockStore) CreateHold(arg0 context.Context, arg1 db.CreateHoldParams) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockStoreMockRecorder) CreateHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockStore)(nil).CreateHold), arg0, arg1)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(arg0 context.Context, arg1 db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), arg0, arg1)
+}
+
+// CreateOutboxEvent mocks base method.
+func (m *MockStore) CreateOutboxEvent(arg0 context.Context, arg1 db.CreateOutboxEventParams) (db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockStoreMockRecorder) CreateOutboxEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockStore)(nil).CreateOutboxEvent), arg0, arg1)
+}
+
+// CreateReversalTransfer mocks base method.
+func (m *MockStore) CreateReversalTransfer(arg0 context.Context, arg1 db.CreateReversalTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReversalTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReversalTransfer indicates an expected call of CreateReversalTransfer.
+func (mr *MockStoreMockRecorder) CreateReversalTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReversalTransfer", reflect.TypeOf((*MockStore)(nil).CreateReversalTransfer), arg0, arg1)
+}
+
+// CreateRiskDecision mocks base method.
+func (m *MockStore) CreateRiskDecision(arg0 context.Context, arg1 db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRiskDecision", arg0, arg1)
+	ret0, _ := ret[0].(db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRiskDecision indicates an expected call of CreateRiskDecision.
+func (mr *MockStoreMockRecorder) CreateRiskDecision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRiskDecision", reflect.TypeOf((*MockStore)(nil).CreateRiskDecision), arg0, arg1)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(arg0 context.Context, arg1 db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), arg0, arg1)
+}
+
 // CreateSession mocks base method.
 func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// CreateWebhookDeliveries mocks base method.
+func (m *MockStore) CreateWebhookDeliveries(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries.
+func (mr *MockStoreMockRecorder) CreateWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).CreateWebhookDeliveries), arg0, arg1)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockStore) CreateWebhookSubscription(arg0 context.Context, arg1 db.CreateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockStoreMockRecorder) CreateWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockStore)(nil).CreateWebhookSubscription), arg0, arg1)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+}
+
+// DeleteEntry mocks base method.
+func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEntry indicates an expected call of DeleteEntry.
+func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockStore) DeleteExpiredSessions(arg0 context.Context, arg1 db.DeleteExpiredSessionsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockStoreMockRecorder) DeleteExpiredSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSessions), arg0, arg1)
+}
+
+// DeleteFeeSchedule mocks base method.
+func (m *MockStore) DeleteFeeSchedule(arg0 context.Context, arg1 int64) (db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeeSchedule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFeeSchedule indicates an expected call of DeleteFeeSchedule.
+func (mr *MockStoreMockRecorder) DeleteFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeeSchedule", reflect.TypeOf((*MockStore)(nil).DeleteFeeSchedule), arg0, arg1)
+}
+
+// DeleteTransfer mocks base method.
+func (m *MockStore) DeleteTransfer(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTransfer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTransfer indicates an expected call of DeleteTransfer.
+func (mr *MockStoreMockRecorder) DeleteTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransfer", reflect.TypeOf((*MockStore)(nil).DeleteTransfer), arg0, arg1)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockStore) DeleteWebhookSubscription(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockStoreMockRecorder) DeleteWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockStore)(nil).DeleteWebhookSubscription), arg0, arg1)
+}
+
+// DepositTx mocks base method.
+func (m *MockStore) DepositTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockStoreMockRecorder) DepositTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockStore)(nil).DepositTx), arg0, arg1)
+}
+
+// DispatchEventsTx mocks base method.
+func (m *MockStore) DispatchEventsTx(arg0 context.Context, arg1 int32) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchEventsTx", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DispatchEventsTx indicates an expected call of DispatchEventsTx.
+func (mr *MockStoreMockRecorder) DispatchEventsTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchEventsTx", reflect.TypeOf((*MockStore)(nil).DispatchEventsTx), arg0, arg1)
+}
+
+// ExpireHoldTx mocks base method.
+func (m *MockStore) ExpireHoldTx(arg0 context.Context, arg1 int64) (db.HoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.HoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHoldTx indicates an expected call of ExpireHoldTx.
+func (mr *MockStoreMockRecorder) ExpireHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHoldTx", reflect.TypeOf((*MockStore)(nil).ExpireHoldTx), arg0, arg1)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountByOwnerAndCurrency mocks base method.
+func (m *MockStore) GetAccountByOwnerAndCurrency(arg0 context.Context, arg1 db.GetAccountByOwnerAndCurrencyParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByOwnerAndCurrency", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByOwnerAndCurrency indicates an expected call of GetAccountByOwnerAndCurrency.
+func (mr *MockStoreMockRecorder) GetAccountByOwnerAndCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByOwnerAndCurrency", reflect.TypeOf((*MockStore)(nil).GetAccountByOwnerAndCurrency), arg0, arg1)
+}
+
+// GetAccountForUpdate mocks base method.
+func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
+func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+}
+
+// GetAccountTransferLimit mocks base method.
+func (m *MockStore) GetAccountTransferLimit(arg0 context.Context, arg1 db.GetAccountTransferLimitParams) (db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountTransferLimit indicates an expected call of GetAccountTransferLimit.
+func (mr *MockStoreMockRecorder) GetAccountTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTransferLimit", reflect.TypeOf((*MockStore)(nil).GetAccountTransferLimit), arg0, arg1)
+}
+
+// GetCurrency mocks base method.
+func (m *MockStore) GetCurrency(arg0 context.Context, arg1 string) (db.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrency", arg0, arg1)
+	ret0, _ := ret[0].(db.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrency indicates an expected call of GetCurrency.
+func (mr *MockStoreMockRecorder) GetCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrency", reflect.TypeOf((*MockStore)(nil).GetCurrency), arg0, arg1)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+}
+
+// GetFeeScheduleForAmount mocks base method.
+func (m *MockStore) GetFeeScheduleForAmount(arg0 context.Context, arg1 db.GetFeeScheduleForAmountParams) (db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeeScheduleForAmount", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeeScheduleForAmount indicates an expected call of GetFeeScheduleForAmount.
+func (mr *MockStoreMockRecorder) GetFeeScheduleForAmount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeeScheduleForAmount", reflect.TypeOf((*MockStore)(nil).GetFeeScheduleForAmount), arg0, arg1)
+}
+
+// GetFxQuote mocks base method.
+func (m *MockStore) GetFxQuote(arg0 context.Context, arg1 int64) (db.FxQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFxQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.FxQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFxQuote indicates an expected call of GetFxQuote.
+func (mr *MockStoreMockRecorder) GetFxQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFxQuote", reflect.TypeOf((*MockStore)(nil).GetFxQuote), arg0, arg1)
+}
+
+// GetFxQuoteForUpdate mocks base method.
+func (m *MockStore) GetFxQuoteForUpdate(arg0 context.Context, arg1 int64) (db.FxQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFxQuoteForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.FxQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFxQuoteForUpdate indicates an expected call of GetFxQuoteForUpdate.
+func (mr *MockStoreMockRecorder) GetFxQuoteForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFxQuoteForUpdate", reflect.TypeOf((*MockStore)(nil).GetFxQuoteForUpdate), arg0, arg1)
+}
+
+// GetFxRate mocks base method.
+func (m *MockStore) GetFxRate(arg0 context.Context, arg1 db.GetFxRateParams) (db.FxRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFxRate", arg0, arg1)
+	ret0, _ := ret[0].(db.FxRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFxRate indicates an expected call of GetFxRate.
+func (mr *MockStoreMockRecorder) GetFxRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFxRate", reflect.TypeOf((*MockStore)(nil).GetFxRate), arg0, arg1)
+}
+
+// GetHold mocks base method.
+func (m *MockStore) GetHold(arg0 context.Context, arg1 int64) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockStoreMockRecorder) GetHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockStore)(nil).GetHold), arg0, arg1)
+}
+
+// GetHoldForUpdate mocks base method.
+func (m *MockStore) GetHoldForUpdate(arg0 context.Context, arg1 int64) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHoldForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHoldForUpdate indicates an expected call of GetHoldForUpdate.
+func (mr *MockStoreMockRecorder) GetHoldForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHoldForUpdate", reflect.TypeOf((*MockStore)(nil).GetHoldForUpdate), arg0, arg1)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(arg0 context.Context, arg1 db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), arg0, arg1)
+}
+
+// GetIdempotentTransfer mocks base method.
+func (m *MockStore) GetIdempotentTransfer(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotentTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotentTransfer indicates an expected call of GetIdempotentTransfer.
+func (mr *MockStoreMockRecorder) GetIdempotentTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotentTransfer", reflect.TypeOf((*MockStore)(nil).GetIdempotentTransfer), arg0, arg1)
+}
+
+// GetLastAccountEntryID mocks base method.
+func (m *MockStore) GetLastAccountEntryID(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastAccountEntryID", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastAccountEntryID indicates an expected call of GetLastAccountEntryID.
+func (mr *MockStoreMockRecorder) GetLastAccountEntryID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastAccountEntryID", reflect.TypeOf((*MockStore)(nil).GetLastAccountEntryID), arg0, arg1)
+}
+
+// GetOutboxEvent mocks base method.
+func (m *MockStore) GetOutboxEvent(arg0 context.Context, arg1 int64) (db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOutboxEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOutboxEvent indicates an expected call of GetOutboxEvent.
+func (mr *MockStoreMockRecorder) GetOutboxEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOutboxEvent", reflect.TypeOf((*MockStore)(nil).GetOutboxEvent), arg0, arg1)
+}
+
+// GetRiskDecision mocks base method.
+func (m *MockStore) GetRiskDecision(arg0 context.Context, arg1 int64) (db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskDecision", arg0, arg1)
+	ret0, _ := ret[0].(db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskDecision indicates an expected call of GetRiskDecision.
+func (mr *MockStoreMockRecorder) GetRiskDecision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskDecision", reflect.TypeOf((*MockStore)(nil).GetRiskDecision), arg0, arg1)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(arg0 context.Context, arg1 int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTransferForUpdate mocks base method.
+func (m *MockStore) GetTransferForUpdate(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferForUpdate), arg0, arg1)
+}
+
+// GetTransferLimitResetTime mocks base method.
+func (m *MockStore) GetTransferLimitResetTime(arg0 context.Context, arg1 db.GetTransferLimitResetTimeParams) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimitResetTime", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimitResetTime indicates an expected call of GetTransferLimitResetTime.
+func (mr *MockStoreMockRecorder) GetTransferLimitResetTime(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimitResetTime", reflect.TypeOf((*MockStore)(nil).GetTransferLimitResetTime), arg0, arg1)
+}
+
+// GetTransferVelocity mocks base method.
+func (m *MockStore) GetTransferVelocity(arg0 context.Context, arg1 db.GetTransferVelocityParams) (db.GetTransferVelocityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferVelocity", arg0, arg1)
+	ret0, _ := ret[0].(db.GetTransferVelocityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferVelocity indicates an expected call of GetTransferVelocity.
+func (mr *MockStoreMockRecorder) GetTransferVelocity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferVelocity", reflect.TypeOf((*MockStore)(nil).GetTransferVelocity), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockStore) GetWebhookDelivery(arg0 context.Context, arg1 int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockStoreMockRecorder) GetWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockStore)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockStore) GetWebhookSubscription(arg0 context.Context, arg1 int64) (db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockStoreMockRecorder) GetWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockStore)(nil).GetWebhookSubscription), arg0, arg1)
+}
+
+// HasTransferBetween mocks base method.
+func (m *MockStore) HasTransferBetween(arg0 context.Context, arg1 db.HasTransferBetweenParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasTransferBetween", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasTransferBetween indicates an expected call of HasTransferBetween.
+func (mr *MockStoreMockRecorder) HasTransferBetween(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasTransferBetween", reflect.TypeOf((*MockStore)(nil).HasTransferBetween), arg0, arg1)
+}
+
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
+}
+
+// ListAccountEntriesAfter mocks base method.
+func (m *MockStore) ListAccountEntriesAfter(arg0 context.Context, arg1 db.ListAccountEntriesAfterParams) ([]db.ListAccountEntriesAfterRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountEntriesAfterRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntriesAfter indicates an expected call of ListAccountEntriesAfter.
+func (mr *MockStoreMockRecorder) ListAccountEntriesAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListAccountEntriesAfter), arg0, arg1)
+}
+
+// ListAccountEntryTotals mocks base method.
+func (m *MockStore) ListAccountEntryTotals(arg0 context.Context, arg1 db.ListAccountEntryTotalsParams) ([]db.ListAccountEntryTotalsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntryTotals", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountEntryTotalsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntryTotals indicates an expected call of ListAccountEntryTotals.
+func (mr *MockStoreMockRecorder) ListAccountEntryTotals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntryTotals", reflect.TypeOf((*MockStore)(nil).ListAccountEntryTotals), arg0, arg1)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+}
+
+// ListCurrencies mocks base method.
+func (m *MockStore) ListCurrencies(arg0 context.Context) ([]db.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCurrencies", arg0)
+	ret0, _ := ret[0].([]db.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCurrencies indicates an expected call of ListCurrencies.
+func (mr *MockStoreMockRecorder) ListCurrencies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCurrencies", reflect.TypeOf((*MockStore)(nil).ListCurrencies), arg0)
+}
+
+// ListDueScheduledTransfers mocks base method.
+func (m *MockStore) ListDueScheduledTransfers(arg0 context.Context, arg1 db.ListDueScheduledTransfersParams) ([]db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueScheduledTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueScheduledTransfers indicates an expected call of ListDueScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListDueScheduledTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListDueScheduledTransfers), arg0, arg1)
+}
+
+// ListDueWebhookDeliveries mocks base method.
+func (m *MockStore) ListDueWebhookDeliveries(arg0 context.Context, arg1 db.ListDueWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueWebhookDeliveries indicates an expected call of ListDueWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListDueWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListDueWebhookDeliveries), arg0, arg1)
+}
+
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+}
+
+// ListExpiredHolds mocks base method.
+func (m *MockStore) ListExpiredHolds(arg0 context.Context, arg1 db.ListExpiredHoldsParams) ([]db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredHolds", arg0, arg1)
+	ret0, _ := ret[0].([]db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredHolds indicates an expected call of ListExpiredHolds.
+func (mr *MockStoreMockRecorder) ListExpiredHolds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredHolds", reflect.TypeOf((*MockStore)(nil).ListExpiredHolds), arg0, arg1)
+}
+
+// ListFeeSchedules mocks base method.
+func (m *MockStore) ListFeeSchedules(arg0 context.Context) ([]db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeeSchedules", arg0)
+	ret0, _ := ret[0].([]db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeeSchedules indicates an expected call of ListFeeSchedules.
+func (mr *MockStoreMockRecorder) ListFeeSchedules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeSchedules", reflect.TypeOf((*MockStore)(nil).ListFeeSchedules), arg0)
+}
+
+// ListFxRates mocks base method.
+func (m *MockStore) ListFxRates(arg0 context.Context) ([]db.FxRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFxRates", arg0)
+	ret0, _ := ret[0].([]db.FxRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFxRates indicates an expected call of ListFxRates.
+func (mr *MockStoreMockRecorder) ListFxRates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFxRates", reflect.TypeOf((*MockStore)(nil).ListFxRates), arg0)
+}
+
+// ListOrphanEntries mocks base method.
+func (m *MockStore) ListOrphanEntries(arg0 context.Context, arg1 db.ListOrphanEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrphanEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrphanEntries indicates an expected call of ListOrphanEntries.
+func (mr *MockStoreMockRecorder) ListOrphanEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrphanEntries", reflect.TypeOf((*MockStore)(nil).ListOrphanEntries), arg0, arg1)
+}
+
+// ListOwnerTransfers mocks base method.
+func (m *MockStore) ListOwnerTransfers(arg0 context.Context, arg1 db.ListOwnerTransfersParams) ([]db.ListOwnerTransfersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwnerTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListOwnerTransfersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwnerTransfers indicates an expected call of ListOwnerTransfers.
+func (mr *MockStoreMockRecorder) ListOwnerTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwnerTransfers", reflect.TypeOf((*MockStore)(nil).ListOwnerTransfers), arg0, arg1)
+}
+
+// ListRiskDecisions mocks base method.
+func (m *MockStore) ListRiskDecisions(arg0 context.Context, arg1 db.ListRiskDecisionsParams) ([]db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRiskDecisions", arg0, arg1)
+	ret0, _ := ret[0].([]db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRiskDecisions indicates an expected call of ListRiskDecisions.
+func (mr *MockStoreMockRecorder) ListRiskDecisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRiskDecisions", reflect.TypeOf((*MockStore)(nil).ListRiskDecisions), arg0, arg1)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(arg0 context.Context, arg1 db.ListScheduledTransfersParams) ([]db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), arg0, arg1)
+}
+
+// ListSessions mocks base method.
+func (m *MockStore) ListSessions(arg0 context.Context, arg1 string) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", arg0, arg1)
+	ret0, _ := ret[0].([]db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockStoreMockRecorder) ListSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockStore)(nil).ListSessions), arg0, arg1)
+}
+
+// ListTransferEntryCounts mocks base method.
+func (m *MockStore) ListTransferEntryCounts(arg0 context.Context, arg1 db.ListTransferEntryCountsParams) ([]db.ListTransferEntryCountsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferEntryCounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListTransferEntryCountsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferEntryCounts indicates an expected call of ListTransferEntryCounts.
+func (mr *MockStoreMockRecorder) ListTransferEntryCounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferEntryCounts", reflect.TypeOf((*MockStore)(nil).ListTransferEntryCounts), arg0, arg1)
+}
+
+// ListTransferLimits mocks base method.
+func (m *MockStore) ListTransferLimits(arg0 context.Context) ([]db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferLimits", arg0)
+	ret0, _ := ret[0].([]db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferLimits indicates an expected call of ListTransferLimits.
+func (mr *MockStoreMockRecorder) ListTransferLimits(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferLimits", reflect.TypeOf((*MockStore)(nil).ListTransferLimits), arg0)
+}
+
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+}
+
+// ListUndispatchedOutboxEvents mocks base method.
+func (m *MockStore) ListUndispatchedOutboxEvents(arg0 context.Context, arg1 int32) ([]db.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUndispatchedOutboxEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUndispatchedOutboxEvents indicates an expected call of ListUndispatchedOutboxEvents.
+func (mr *MockStoreMockRecorder) ListUndispatchedOutboxEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUndispatchedOutboxEvents", reflect.TypeOf((*MockStore)(nil).ListUndispatchedOutboxEvents), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateSession indicates an expected call of CreateSession.
-func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(arg0 context.Context, arg1 db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+// ListWebhookSubscriptions mocks base method.
+func (m *MockStore) ListWebhookSubscriptions(arg0 context.Context, arg1 db.ListWebhookSubscriptionsParams) ([]db.WebhookSubscription, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookSubscription)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockStoreMockRecorder) ListWebhookSubscriptions(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockStore)(nil).ListWebhookSubscriptions), arg0, arg1)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+// MarkOutboxEventDispatched mocks base method.
+func (m *MockStore) MarkOutboxEventDispatched(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkOutboxEventDispatched", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+// MarkOutboxEventDispatched indicates an expected call of MarkOutboxEventDispatched.
+func (mr *MockStoreMockRecorder) MarkOutboxEventDispatched(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventDispatched", reflect.TypeOf((*MockStore)(nil).MarkOutboxEventDispatched), arg0, arg1)
 }
 
-// DeleteEntry mocks base method.
-func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+// Notify mocks base method.
+func (m *MockStore) Notify(arg0 context.Context, arg1 db.NotifyParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
+	ret := m.ctrl.Call(m, "Notify", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteEntry indicates an expected call of DeleteEntry.
-func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+// Notify indicates an expected call of Notify.
+func (mr *MockStoreMockRecorder) Notify(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockStore)(nil).Notify), arg0, arg1)
 }
 
-// DeleteTransfer mocks base method.
-func (m *MockStore) DeleteTransfer(arg0 context.Context, arg1 int64) error {
+// PlaceHoldTx mocks base method.
+func (m *MockStore) PlaceHoldTx(arg0 context.Context, arg1 db.PlaceHoldTxParams) (db.HoldTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTransfer", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "PlaceHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.HoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteTransfer indicates an expected call of DeleteTransfer.
-func (mr *MockStoreMockRecorder) DeleteTransfer(arg0, arg1 interface{}) *gomock.Call {
+// PlaceHoldTx indicates an expected call of PlaceHoldTx.
+func (mr *MockStoreMockRecorder) PlaceHoldTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransfer", reflect.TypeOf((*MockStore)(nil).DeleteTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceHoldTx", reflect.TypeOf((*MockStore)(nil).PlaceHoldTx), arg0, arg1)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+// RecordScheduledTransferFailure mocks base method.
+func (m *MockStore) RecordScheduledTransferFailure(arg0 context.Context, arg1 db.RecordScheduledTransferFailureParams) (db.ScheduledTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "RecordScheduledTransferFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+// RecordScheduledTransferFailure indicates an expected call of RecordScheduledTransferFailure.
+func (mr *MockStoreMockRecorder) RecordScheduledTransferFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScheduledTransferFailure", reflect.TypeOf((*MockStore)(nil).RecordScheduledTransferFailure), arg0, arg1)
 }
 
-// GetAccountForUpdate mocks base method.
-func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+// RecordScheduledTransferRun mocks base method.
+func (m *MockStore) RecordScheduledTransferRun(arg0 context.Context, arg1 db.RecordScheduledTransferRunParams) (db.ScheduledTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "RecordScheduledTransferRun", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
-func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+// RecordScheduledTransferRun indicates an expected call of RecordScheduledTransferRun.
+func (mr *MockStoreMockRecorder) RecordScheduledTransferRun(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScheduledTransferRun", reflect.TypeOf((*MockStore)(nil).RecordScheduledTransferRun), arg0, arg1)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+// RecordWebhookDeliveryFailure mocks base method.
+func (m *MockStore) RecordWebhookDeliveryFailure(arg0 context.Context, arg1 db.RecordWebhookDeliveryFailureParams) (db.WebhookDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "RecordWebhookDeliveryFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+// RecordWebhookDeliveryFailure indicates an expected call of RecordWebhookDeliveryFailure.
+func (mr *MockStoreMockRecorder) RecordWebhookDeliveryFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDeliveryFailure", reflect.TypeOf((*MockStore)(nil).RecordWebhookDeliveryFailure), arg0, arg1)
 }
 
-// GetSession mocks base method.
-func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+// RecordWebhookDeliverySuccess mocks base method.
+func (m *MockStore) RecordWebhookDeliverySuccess(arg0 context.Context, arg1 db.RecordWebhookDeliverySuccessParams) (db.WebhookDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "RecordWebhookDeliverySuccess", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSession indicates an expected call of GetSession.
-func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+// RecordWebhookDeliverySuccess indicates an expected call of RecordWebhookDeliverySuccess.
+func (mr *MockStoreMockRecorder) RecordWebhookDeliverySuccess(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDeliverySuccess", reflect.TypeOf((*MockStore)(nil).RecordWebhookDeliverySuccess), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockStore) RedeliverWebhookDelivery(arg0 context.Context, arg1 int64) (db.WebhookDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockStoreMockRecorder) RedeliverWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockStore)(nil).RedeliverWebhookDelivery), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(arg0 context.Context, arg1 db.ReverseTransferTxParams) (db.TransferTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ReverseTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), arg0, arg1)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// RotateSession mocks base method.
+func (m *MockStore) RotateSession(arg0 context.Context, arg1 db.RotateSessionParams) (db.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "RotateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+// RotateSession indicates an expected call of RotateSession.
+func (mr *MockStoreMockRecorder) RotateSession(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MockStore)(nil).RotateSession), arg0, arg1)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+// RotateSessionTx mocks base method.
+func (m *MockStore) RotateSessionTx(arg0 context.Context, arg1 db.RotateSessionTxParams) (db.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "RotateSessionTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+// RotateSessionTx indicates an expected call of RotateSessionTx.
+func (mr *MockStoreMockRecorder) RotateSessionTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionTx", reflect.TypeOf((*MockStore)(nil).RotateSessionTx), arg0, arg1)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+// StatementTx mocks base method.
+func (m *MockStore) StatementTx(arg0 context.Context, arg1 db.StatementTxParams) (db.StatementTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "StatementTx", arg0, arg1)
+	ret0, _ := ret[0].(db.StatementTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+// StatementTx indicates an expected call of StatementTx.
+func (mr *MockStoreMockRecorder) StatementTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatementTx", reflect.TypeOf((*MockStore)(nil).StatementTx), arg0, arg1)
+}
+
+// SumAccountEntries mocks base method.
+func (m *MockStore) SumAccountEntries(arg0 context.Context, arg1 db.SumAccountEntriesParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumAccountEntries indicates an expected call of SumAccountEntries.
+func (mr *MockStoreMockRecorder) SumAccountEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAccountEntries", reflect.TypeOf((*MockStore)(nil).SumAccountEntries), arg0, arg1)
+}
+
+// SumTransferReversals mocks base method.
+func (m *MockStore) SumTransferReversals(arg0 context.Context, arg1 sql.NullInt64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumTransferReversals", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumTransferReversals indicates an expected call of SumTransferReversals.
+func (mr *MockStoreMockRecorder) SumTransferReversals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumTransferReversals", reflect.TypeOf((*MockStore)(nil).SumTransferReversals), arg0, arg1)
 }
 
 // TransferTx mocks base method.
@@ -333,6 +1606,51 @@ func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
 }
 
+// UpdateAccountOverdraftLimit mocks base method.
+func (m *MockStore) UpdateAccountOverdraftLimit(arg0 context.Context, arg1 db.UpdateAccountOverdraftLimitParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountOverdraftLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountOverdraftLimit indicates an expected call of UpdateAccountOverdraftLimit.
+func (mr *MockStoreMockRecorder) UpdateAccountOverdraftLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountOverdraftLimit", reflect.TypeOf((*MockStore)(nil).UpdateAccountOverdraftLimit), arg0, arg1)
+}
+
+// UpdateAccountStatus mocks base method.
+func (m *MockStore) UpdateAccountStatus(arg0 context.Context, arg1 db.UpdateAccountStatusParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockStoreMockRecorder) UpdateAccountStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockStore)(nil).UpdateAccountStatus), arg0, arg1)
+}
+
+// UpdateCurrency mocks base method.
+func (m *MockStore) UpdateCurrency(arg0 context.Context, arg1 db.UpdateCurrencyParams) (db.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCurrency", arg0, arg1)
+	ret0, _ := ret[0].(db.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCurrency indicates an expected call of UpdateCurrency.
+func (mr *MockStoreMockRecorder) UpdateCurrency(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCurrency", reflect.TypeOf((*MockStore)(nil).UpdateCurrency), arg0, arg1)
+}
+
 // UpdateEntry mocks base method.
 func (m *MockStore) UpdateEntry(arg0 context.Context, arg1 db.UpdateEntryParams) (db.Entry, error) {
 	m.ctrl.T.Helper()
@@ -348,6 +1666,81 @@ func (mr *MockStoreMockRecorder) UpdateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntry", reflect.TypeOf((*MockStore)(nil).UpdateEntry), arg0, arg1)
 }
 
+// UpdateFxQuoteTransfers mocks base method.
+func (m *MockStore) UpdateFxQuoteTransfers(arg0 context.Context, arg1 db.UpdateFxQuoteTransfersParams) (db.FxQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFxQuoteTransfers", arg0, arg1)
+	ret0, _ := ret[0].(db.FxQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFxQuoteTransfers indicates an expected call of UpdateFxQuoteTransfers.
+func (mr *MockStoreMockRecorder) UpdateFxQuoteTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFxQuoteTransfers", reflect.TypeOf((*MockStore)(nil).UpdateFxQuoteTransfers), arg0, arg1)
+}
+
+// UpdateHoldStatus mocks base method.
+func (m *MockStore) UpdateHoldStatus(arg0 context.Context, arg1 db.UpdateHoldStatusParams) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHoldStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHoldStatus indicates an expected call of UpdateHoldStatus.
+func (mr *MockStoreMockRecorder) UpdateHoldStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHoldStatus", reflect.TypeOf((*MockStore)(nil).UpdateHoldStatus), arg0, arg1)
+}
+
+// UpdateIdempotencyKeyResponse mocks base method.
+func (m *MockStore) UpdateIdempotencyKeyResponse(arg0 context.Context, arg1 db.UpdateIdempotencyKeyResponseParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIdempotencyKeyResponse", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateIdempotencyKeyResponse indicates an expected call of UpdateIdempotencyKeyResponse.
+func (mr *MockStoreMockRecorder) UpdateIdempotencyKeyResponse(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIdempotencyKeyResponse", reflect.TypeOf((*MockStore)(nil).UpdateIdempotencyKeyResponse), arg0, arg1)
+}
+
+// UpdateRiskDecisionTransfer mocks base method.
+func (m *MockStore) UpdateRiskDecisionTransfer(arg0 context.Context, arg1 db.UpdateRiskDecisionTransferParams) (db.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRiskDecisionTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRiskDecisionTransfer indicates an expected call of UpdateRiskDecisionTransfer.
+func (mr *MockStoreMockRecorder) UpdateRiskDecisionTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRiskDecisionTransfer", reflect.TypeOf((*MockStore)(nil).UpdateRiskDecisionTransfer), arg0, arg1)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockStore) UpdateScheduledTransfer(arg0 context.Context, arg1 db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockStoreMockRecorder) UpdateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).UpdateScheduledTransfer), arg0, arg1)
+}
+
 // UpdateTransfer mocks base method.
 func (m *MockStore) UpdateTransfer(arg0 context.Context, arg1 db.UpdateTransferParams) (db.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -362,3 +1755,123 @@ func (mr *MockStoreMockRecorder) UpdateTransfer(arg0, arg1 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransfer", reflect.TypeOf((*MockStore)(nil).UpdateTransfer), arg0, arg1)
 }
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(arg0 context.Context, arg1 db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), arg0, arg1)
+}
+
+// UpdateUserTier mocks base method.
+func (m *MockStore) UpdateUserTier(arg0 context.Context, arg1 db.UpdateUserTierParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTier", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserTier indicates an expected call of UpdateUserTier.
+func (mr *MockStoreMockRecorder) UpdateUserTier(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTier", reflect.TypeOf((*MockStore)(nil).UpdateUserTier), arg0, arg1)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockStore) UpdateWebhookSubscription(arg0 context.Context, arg1 db.UpdateWebhookSubscriptionParams) (db.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockStoreMockRecorder) UpdateWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockStore)(nil).UpdateWebhookSubscription), arg0, arg1)
+}
+
+// UpsertFeeSchedule mocks base method.
+func (m *MockStore) UpsertFeeSchedule(arg0 context.Context, arg1 db.UpsertFeeScheduleParams) (db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertFeeSchedule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertFeeSchedule indicates an expected call of UpsertFeeSchedule.
+func (mr *MockStoreMockRecorder) UpsertFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertFeeSchedule", reflect.TypeOf((*MockStore)(nil).UpsertFeeSchedule), arg0, arg1)
+}
+
+// UpsertFxRate mocks base method.
+func (m *MockStore) UpsertFxRate(arg0 context.Context, arg1 db.UpsertFxRateParams) (db.FxRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertFxRate", arg0, arg1)
+	ret0, _ := ret[0].(db.FxRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertFxRate indicates an expected call of UpsertFxRate.
+func (mr *MockStoreMockRecorder) UpsertFxRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertFxRate", reflect.TypeOf((*MockStore)(nil).UpsertFxRate), arg0, arg1)
+}
+
+// UpsertTransferLimit mocks base method.
+func (m *MockStore) UpsertTransferLimit(arg0 context.Context, arg1 db.UpsertTransferLimitParams) (db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTransferLimit indicates an expected call of UpsertTransferLimit.
+func (mr *MockStoreMockRecorder) UpsertTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTransferLimit", reflect.TypeOf((*MockStore)(nil).UpsertTransferLimit), arg0, arg1)
+}
+
+// VoidHoldTx mocks base method.
+func (m *MockStore) VoidHoldTx(arg0 context.Context, arg1 int64) (db.HoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.HoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidHoldTx indicates an expected call of VoidHoldTx.
+func (mr *MockStoreMockRecorder) VoidHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHoldTx", reflect.TypeOf((*MockStore)(nil).VoidHoldTx), arg0, arg1)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockStore) WithdrawTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockStoreMockRecorder) WithdrawTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockStore)(nil).WithdrawTx), arg0, arg1)
+}
@@ -14,6 +14,10 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1 
 FOR NO KEY UPDATE;
 
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE owner = $1
@@ -21,6 +25,18 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountEntryTotals :many
+SELECT
+    a.id,
+    a.balance,
+    COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(batch_size);
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
@@ -33,6 +49,26 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status), frozen_by = sqlc.narg(frozen_by)
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status)
+  AND frozen_by IS NOT DISTINCT FROM sqlc.narg(from_frozen_by)
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
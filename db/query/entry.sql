@@ -1,8 +1,8 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
-    account_id, amount
+    account_id, amount, transfer_id
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -15,6 +15,46 @@ ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(from_time)
+    AND created_at < sqlc.arg(to_time)
+    AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListAccountEntriesAfter :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.transfer_id,
+    (a.balance - COALESCE((
+        SELECT SUM(n.amount) FROM entries n
+        WHERE n.account_id = e.account_id AND n.id > e.id
+    ), 0))::bigint AS running_balance
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+WHERE e.account_id = sqlc.arg(account_id) AND e.id > sqlc.arg(after_id)
+ORDER BY e.id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetLastAccountEntryID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id
+FROM entries
+WHERE account_id = $1;
+
+-- name: SumAccountEntries :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(from_time)
+    AND created_at < sqlc.arg(to_time)
+    AND id <= sqlc.arg(max_id);
+
+-- name: ListOrphanEntries :many
+SELECT * FROM entries
+WHERE transfer_id IS NULL AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
 -- name: UpdateEntry :one
 UPDATE entries
 SET amount = $2
@@ -0,0 +1,23 @@
+-- name: DeleteFeeSchedule :one
+DELETE FROM fee_schedules
+WHERE id = $1
+RETURNING *;
+
+-- name: GetFeeScheduleForAmount :one
+SELECT * FROM fee_schedules
+WHERE currency = sqlc.arg(currency) AND min_amount <= sqlc.arg(amount)
+ORDER BY min_amount DESC
+LIMIT 1;
+
+-- name: ListFeeSchedules :many
+SELECT * FROM fee_schedules
+ORDER BY currency, min_amount;
+
+-- name: UpsertFeeSchedule :one
+INSERT INTO fee_schedules (
+    currency, min_amount, flat_fee, percentage_bps
+) VALUES (
+    $1, $2, $3, $4
+) ON CONFLICT (currency, min_amount)
+DO UPDATE SET flat_fee = EXCLUDED.flat_fee, percentage_bps = EXCLUDED.percentage_bps, updated_at = now()
+RETURNING *;
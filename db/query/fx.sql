@@ -0,0 +1,38 @@
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (
+    owner, from_currency, to_currency, rate, from_amount, to_amount, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1;
+
+-- name: GetFxQuoteForUpdate :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetFxRate :one
+SELECT * FROM fx_rates
+WHERE from_currency = $1 AND to_currency = $2 LIMIT 1;
+
+-- name: ListFxRates :many
+SELECT * FROM fx_rates
+ORDER BY from_currency, to_currency;
+
+-- name: UpdateFxQuoteTransfers :one
+UPDATE fx_quotes
+SET debit_transfer_id = $2, credit_transfer_id = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: UpsertFxRate :one
+INSERT INTO fx_rates (
+    from_currency, to_currency, rate
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT (from_currency, to_currency)
+DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+RETURNING *;
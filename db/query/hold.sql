@@ -0,0 +1,27 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    account_id, to_account_id, amount, expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'active' AND expires_at <= sqlc.arg(now)
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $2, transfer_id = $3, updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,32 @@
+-- name: CreateRiskDecision :one
+INSERT INTO risk_decisions (
+  username,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  session_id,
+  client_ip,
+  action,
+  matches
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetRiskDecision :one
+SELECT * FROM risk_decisions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListRiskDecisions :many
+SELECT * FROM risk_decisions
+WHERE (sqlc.arg(action)::varchar = '' OR action = sqlc.arg(action))
+    AND (sqlc.arg(username)::varchar = '' OR username = sqlc.arg(username))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: UpdateRiskDecisionTransfer :one
+UPDATE risk_decisions
+SET transfer_id = sqlc.arg(transfer_id)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,68 @@
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled', updated_at = now()
+WHERE id = $1
+  AND status IN ('active', 'failed')
+  AND (locked_until IS NULL OR locked_until < now())
+RETURNING *;
+
+-- name: ClaimScheduledTransfer :one
+UPDATE scheduled_transfers
+SET locked_until = sqlc.arg(locked_until)
+WHERE id = sqlc.arg(id)
+  AND status = 'active'
+  AND next_run_at <= sqlc.arg(now)
+  AND (retry_at IS NULL OR retry_at <= sqlc.arg(now))
+  AND (locked_until IS NULL OR locked_until < sqlc.arg(now))
+RETURNING *;
+
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner, from_account_id, to_account_id, amount, recurrence, day_of_month, next_run_at, risk_decision_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListDueScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE status = 'active'
+  AND next_run_at <= sqlc.arg(now)
+  AND (retry_at IS NULL OR retry_at <= sqlc.arg(now))
+  AND (locked_until IS NULL OR locked_until < sqlc.arg(now))
+ORDER BY next_run_at
+LIMIT sqlc.arg(limit);
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: RecordScheduledTransferFailure :one
+UPDATE scheduled_transfers
+SET status = $3, attempts = attempts + 1, retry_at = $4, last_error = $5,
+    locked_until = NULL, updated_at = now()
+WHERE id = $1 AND locked_until = $2
+RETURNING *;
+
+-- name: RecordScheduledTransferRun :one
+UPDATE scheduled_transfers
+SET status = $3, next_run_at = $4, last_transfer_id = $5, run_count = run_count + 1,
+    risk_decision_id = $6, attempts = 0, retry_at = NULL, last_error = NULL, locked_until = NULL,
+    updated_at = now()
+WHERE id = $1 AND locked_until = $2
+RETURNING *;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = $2, recurrence = $3, day_of_month = $4, next_run_at = $5, risk_decision_id = $6,
+    status = 'active', attempts = 0, retry_at = NULL, updated_at = now()
+WHERE id = $1
+  AND status IN ('active', 'failed')
+  AND (locked_until IS NULL OR locked_until < now())
+RETURNING *;
@@ -6,11 +6,54 @@ INSERT INTO sessions (
   user_agent,
   client_ip,
   is_blocked,
-  expires_at
+  expires_at,
+  family_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
 WHERE id = $1 LIMIT 1;
+
+-- name: ListSessions :many
+-- Lists the current session of each login, created_at is the time of the login.
+SELECT
+    s.id, s.username, s.refresh_token, s.user_agent, s.client_ip,
+    s.is_blocked, s.expires_at, l.created_at, s.family_id, s.rotated_at
+FROM sessions s
+JOIN sessions l ON l.id = s.family_id
+WHERE s.username = $1 AND s.rotated_at IS NULL
+ORDER BY l.created_at DESC;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+RETURNING *;
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE username = sqlc.arg(username)
+    AND is_blocked = false
+    AND expires_at > sqlc.arg(now);
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT id FROM sessions
+    WHERE expires_at <= sqlc.arg(now)
+    LIMIT sqlc.arg(limit)
+);
+
+-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = sqlc.arg(rotated_at)
+WHERE id = sqlc.arg(id) AND rotated_at IS NULL AND is_blocked = false
+RETURNING *;
+
+-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND is_blocked = false;
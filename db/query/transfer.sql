@@ -5,16 +5,87 @@ INSERT INTO transfers (
     $1, $2, $3
 ) RETURNING *;
 
+-- name: CreateReversalTransfer :one
+INSERT INTO transfers (
+    from_account_id, to_account_id, amount, reversal_of
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: CreateFeeTransfer :one
+INSERT INTO transfers (
+    from_account_id, to_account_id, amount, fee_of
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SumTransferReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE reversal_of = $1;
+
+-- name: HasTransferBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM transfers
+    WHERE from_account_id = $1 AND to_account_id = $2
+);
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2;
 
+-- name: ListOwnerTransfers :many
+SELECT t.*, f.currency FROM transfers t
+JOIN accounts f ON f.id = t.from_account_id
+WHERE (
+        (sqlc.arg(include_out)::boolean AND t.from_account_id IN (
+            SELECT a.id FROM accounts a
+            WHERE a.owner = sqlc.arg(owner)
+                AND (sqlc.arg(account_id)::bigint = 0 OR a.id = sqlc.arg(account_id))
+        ))
+        OR (sqlc.arg(include_in)::boolean AND t.to_account_id IN (
+            SELECT a.id FROM accounts a
+            WHERE a.owner = sqlc.arg(owner)
+                AND (sqlc.arg(account_id)::bigint = 0 OR a.id = sqlc.arg(account_id))
+        ))
+    )
+    AND t.created_at >= sqlc.arg(from_time)
+    AND t.created_at < sqlc.arg(to_time)
+ORDER BY t.id
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: ListTransferEntryCounts :many
+SELECT
+    t.id,
+    t.from_account_id,
+    t.to_account_id,
+    t.amount,
+    COUNT(e.id) FILTER (
+        WHERE e.account_id = t.from_account_id AND e.amount = -t.amount
+    ) AS debit_count,
+    COUNT(e.id) FILTER (
+        WHERE e.account_id = t.to_account_id AND e.amount = t.amount
+    ) AS credit_count,
+    COUNT(e.id) AS entry_count
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+WHERE t.id > sqlc.arg(after_id)
+GROUP BY t.id
+ORDER BY t.id
+LIMIT sqlc.arg(batch_size);
+
 -- name: UpdateTransfer :one
 UPDATE transfers
 SET amount = $2
@@ -0,0 +1,51 @@
+-- name: GetAccountTransferLimit :one
+SELECT l.* FROM transfer_limits l
+JOIN users u ON u.tier = l.tier
+WHERE u.username = sqlc.arg(owner) AND l.currency = sqlc.arg(currency)
+LIMIT 1;
+
+-- name: GetTransferLimitResetTime :one
+SELECT t.created_at FROM (
+    SELECT
+        created_at,
+        SUM(amount) OVER (ORDER BY created_at, id) AS running_total,
+        COUNT(*) OVER (ORDER BY created_at, id) AS running_count
+    FROM transfers
+    WHERE from_account_id = sqlc.arg(account_id)
+        AND created_at >= sqlc.arg(since)
+        AND reversal_of IS NULL
+        AND fee_of IS NULL
+) t
+WHERE t.running_total >= sqlc.arg(min_total)::bigint
+    AND t.running_count >= sqlc.arg(min_count)::bigint
+ORDER BY t.created_at
+LIMIT 1;
+
+-- name: GetTransferVelocity :one
+SELECT
+    COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)), 0)::bigint AS day_total,
+    COALESCE(SUM(amount), 0)::bigint AS rolling_total,
+    COUNT(*) FILTER (WHERE created_at >= sqlc.arg(hour_start)) AS hour_count
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(since)
+    AND reversal_of IS NULL
+    AND fee_of IS NULL;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+ORDER BY tier, currency;
+
+-- name: UpsertTransferLimit :one
+INSERT INTO transfer_limits (
+    tier, currency, max_per_transfer, max_per_day, max_per_30_days, max_count_per_hour
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) ON CONFLICT (tier, currency)
+DO UPDATE SET
+    max_per_transfer = EXCLUDED.max_per_transfer,
+    max_per_day = EXCLUDED.max_per_day,
+    max_per_30_days = EXCLUDED.max_per_30_days,
+    max_count_per_hour = EXCLUDED.max_count_per_hour,
+    updated_at = now()
+RETURNING *;
@@ -10,4 +10,23 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE sqlc.arg(role)::varchar = '' OR role = sqlc.arg(role)
+ORDER BY username
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING *;
@@ -0,0 +1,90 @@
+-- name: ClaimWebhookDelivery :one
+UPDATE webhook_deliveries
+SET locked_until = sqlc.arg(locked_until)
+WHERE id = sqlc.arg(id)
+  AND status = 'pending'
+  AND next_attempt_at <= sqlc.arg(now)
+  AND (locked_until IS NULL OR locked_until < sqlc.arg(now))
+RETURNING *;
+
+-- name: CreateWebhookDeliveries :exec
+INSERT INTO webhook_deliveries (event_id, subscription_id)
+SELECT e.id, s.id
+FROM outbox_events e
+JOIN webhook_subscriptions s ON s.owner = ANY(e.usernames)
+WHERE e.id = $1
+  AND s.enabled
+  AND (cardinality(s.event_types) = 0 OR e.event_type = ANY(s.event_types))
+ON CONFLICT (event_id, subscription_id) DO NOTHING;
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  owner,
+  url,
+  secret,
+  event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: DeleteWebhookSubscription :exec
+DELETE FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending'
+  AND next_attempt_at <= sqlc.arg(now)
+  AND (locked_until IS NULL OR locked_until < sqlc.arg(now))
+ORDER BY next_attempt_at
+LIMIT sqlc.arg(limit);
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+    AND (sqlc.arg(status)::varchar = '' OR status = sqlc.arg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: RecordWebhookDeliveryFailure :one
+UPDATE webhook_deliveries
+SET status = $3, attempts = attempts + 1, next_attempt_at = $4, last_error = $5,
+    response_status = $6, locked_until = NULL, updated_at = now()
+WHERE id = $1 AND locked_until = $2
+RETURNING *;
+
+-- name: RecordWebhookDeliverySuccess :one
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, last_error = NULL,
+    response_status = $3, delivered_at = now(), locked_until = NULL, updated_at = now()
+WHERE id = $1 AND locked_until = $2
+RETURNING *;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL, updated_at = now()
+WHERE id = $1
+  AND (locked_until IS NULL OR locked_until < now())
+RETURNING *;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = $2, event_types = $3, enabled = $4, updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -7,13 +7,14 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +31,39 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
+	)
+	return i, err
+}
+
+const addAccountHeldBalance = `-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
+`
+
+type AddAccountHeldBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
 	)
 	return i, err
 }
@@ -39,7 +73,7 @@ INSERT INTO accounts (
     owner, balance, currency
 ) VALUES (
     $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+) RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
 `
 
 type CreateAccountParams struct {
@@ -57,6 +91,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
 	)
 	return i, err
 }
@@ -72,7 +110,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -85,12 +123,43 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
+	)
+	return i, err
+}
+
+const getAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by FROM accounts
 WHERE id = $1 LIMIT 1 
 FOR NO KEY UPDATE
 `
@@ -104,12 +173,16 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -137,6 +210,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.HeldBalance,
+			&i.Status,
+			&i.FrozenBy,
 		); err != nil {
 			return nil, err
 		}
@@ -151,11 +228,58 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listAccountEntryTotals = `-- name: ListAccountEntryTotals :many
+SELECT
+    a.id,
+    a.balance,
+    COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > $1
+GROUP BY a.id
+ORDER BY a.id
+LIMIT $2
+`
+
+type ListAccountEntryTotalsParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+type ListAccountEntryTotalsRow struct {
+	ID           int64 `json:"id"`
+	Balance      int64 `json:"balance"`
+	EntriesTotal int64 `json:"entries_total"`
+}
+
+func (q *Queries) ListAccountEntryTotals(ctx context.Context, arg ListAccountEntryTotalsParams) ([]ListAccountEntryTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntryTotals, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntryTotalsRow{}
+	for rows.Next() {
+		var i ListAccountEntryTotalsRow
+		if err := rows.Scan(&i.ID, &i.Balance, &i.EntriesTotal); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
 `
 
 type UpdateAccountParams struct {
@@ -172,6 +296,79 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $1, frozen_by = $2
+WHERE id = $3
+  AND status = $4
+  AND frozen_by IS NOT DISTINCT FROM $5
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_balance, status, frozen_by
+`
+
+type UpdateAccountStatusParams struct {
+	Status       string         `json:"status"`
+	FrozenBy     sql.NullString `json:"frozen_by"`
+	ID           int64          `json:"id"`
+	FromStatus   string         `json:"from_status"`
+	FromFrozenBy sql.NullString `json:"from_frozen_by"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus,
+		arg.Status,
+		arg.FrozenBy,
+		arg.ID,
+		arg.FromStatus,
+		arg.FromFrozenBy,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldBalance,
+		&i.Status,
+		&i.FrozenBy,
 	)
 	return i, err
 }
@@ -68,6 +68,72 @@ func TestUpdateAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestUpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Zero(t, account1.OverdraftLimit)
+
+	arg := UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: util.RandomMoney(),
+	}
+	account2, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, account2)
+
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, arg.OverdraftLimit, account2.OverdraftLimit)
+	require.Equal(t, account1.Balance+arg.OverdraftLimit, account2.AvailableBalance())
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Equal(t, AccountStatusActive, account1.Status)
+
+	arg := UpdateAccountStatusParams{
+		ID:         account1.ID,
+		Status:     AccountStatusFrozen,
+		FrozenBy:   sql.NullString{String: account1.Owner, Valid: true},
+		FromStatus: AccountStatusActive,
+	}
+	account2, err := testQueries.UpdateAccountStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, AccountStatusFrozen, account2.Status)
+	require.Equal(t, arg.FrozenBy, account2.FrozenBy)
+
+	// The status already changed, so the same update matches no row.
+	_, err = testQueries.UpdateAccountStatus(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// An operator freezes the account over the owner's freeze.
+	account3, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:           account1.ID,
+		Status:       AccountStatusFrozen,
+		FrozenBy:     sql.NullString{String: "operator", Valid: true},
+		FromStatus:   AccountStatusFrozen,
+		FromFrozenBy: account2.FrozenBy,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "operator", account3.FrozenBy.String)
+
+	// An unfreeze that read the owner's freeze no longer matches.
+	unfreeze := UpdateAccountStatusParams{
+		ID:           account1.ID,
+		Status:       AccountStatusActive,
+		FromStatus:   AccountStatusFrozen,
+		FromFrozenBy: account2.FrozenBy,
+	}
+	_, err = testQueries.UpdateAccountStatus(context.Background(), unfreeze)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// Unfreezing forgets who froze the account.
+	unfreeze.FromFrozenBy = account3.FrozenBy
+	account4, err := testQueries.UpdateAccountStatus(context.Background(), unfreeze)
+	require.NoError(t, err)
+	require.False(t, account4.FrozenBy.Valid)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 	err := testQueries.DeleteAccount(context.Background(), account1.ID)
@@ -100,3 +166,25 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestListAccountEntryTotals(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ListAccountEntryTotals(context.Background(), ListAccountEntryTotalsParams{
+		AfterID:   account.ID - 1,
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	require.Equal(t, account.ID, rows[0].ID)
+	require.Equal(t, account.Balance+10, rows[0].Balance)
+	require.Equal(t, int64(10), rows[0].EntriesTotal)
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Creates a disabled currency with a code that isn't used yet.
+func createRandomCurrency(t *testing.T) Currency {
+	var code string
+	for {
+		code = strings.ToUpper(util.RandomString(3))
+		_, err := testQueries.GetCurrency(context.Background(), code)
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	arg := CreateCurrencyParams{
+		Code:     code,
+		Exponent: int32(util.RandomInt(0, 4)),
+		Symbol:   code[:1],
+		Enabled:  false,
+	}
+
+	currency, err := testQueries.CreateCurrency(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Code, currency.Code)
+	require.Equal(t, arg.Exponent, currency.Exponent)
+	require.Equal(t, arg.Symbol, currency.Symbol)
+	require.Equal(t, arg.Enabled, currency.Enabled)
+	require.WithinDuration(t, time.Now(), currency.CreatedAt, time.Second)
+	return currency
+}
+
+func TestCreateCurrency(t *testing.T) {
+	currency := createRandomCurrency(t)
+
+	_, err := testQueries.CreateCurrency(context.Background(), CreateCurrencyParams{
+		Code:     currency.Code,
+		Exponent: 2,
+	})
+	require.Error(t, err)
+
+	_, err = testQueries.CreateCurrency(context.Background(), CreateCurrencyParams{
+		Code:     "usd",
+		Exponent: 2,
+	})
+	require.Error(t, err)
+
+	_, err = testQueries.CreateCurrency(context.Background(), CreateCurrencyParams{
+		Code:     "XYZ",
+		Exponent: 5,
+	})
+	require.Error(t, err)
+}
+
+func TestGetCurrency(t *testing.T) {
+	for _, code := range []string{util.EUR, util.USD, util.CAD} {
+		currency, err := testQueries.GetCurrency(context.Background(), code)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), currency.Exponent)
+		require.True(t, currency.Enabled)
+	}
+
+	_, err := testQueries.GetCurrency(context.Background(), "xxx")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateCurrency(t *testing.T) {
+	currency1 := createRandomCurrency(t)
+
+	currency2, err := testQueries.UpdateCurrency(context.Background(), UpdateCurrencyParams{
+		Code:    currency1.Code,
+		Symbol:  "$$",
+		Enabled: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, currency1.Exponent, currency2.Exponent)
+	require.Equal(t, "$$", currency2.Symbol)
+	require.True(t, currency2.Enabled)
+	require.False(t, currency2.UpdatedAt.Before(currency1.UpdatedAt))
+
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, currencies, currency2)
+}
+
+func TestCreateAccountUnknownCurrency(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: "xxx",
+	})
+	require.Error(t, err)
+}
@@ -7,29 +7,33 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
-    account_id, amount
+    account_id, amount, transfer_id
 ) VALUES (
-    $1, $2
-) RETURNING id, account_id, amount, created_at
+    $1, $2, $3
+) RETURNING id, account_id, amount, created_at, transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.TransferID)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
@@ -45,7 +49,7 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -57,12 +61,26 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
+const getLastAccountEntryID = `-- name: GetLastAccountEntryID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id
+FROM entries
+WHERE account_id = $1
+`
+
+func (q *Queries) GetLastAccountEntryID(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLastAccountEntryID, accountID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -87,6 +105,159 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
+WHERE account_id = $1
+    AND created_at >= $2
+    AND created_at < $3
+    AND id > $4
+ORDER BY id
+LIMIT $5
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	AfterID   int64     `json:"after_id"`
+	PageSize  int32     `json:"page_size"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesAfter = `-- name: ListAccountEntriesAfter :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.transfer_id,
+    (a.balance - COALESCE((
+        SELECT SUM(n.amount) FROM entries n
+        WHERE n.account_id = e.account_id AND n.id > e.id
+    ), 0))::bigint AS running_balance
+FROM entries e
+JOIN accounts a ON a.id = e.account_id
+WHERE e.account_id = $1 AND e.id > $2
+ORDER BY e.id
+LIMIT $3
+`
+
+type ListAccountEntriesAfterParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	PageSize  int32 `json:"page_size"`
+}
+
+type ListAccountEntriesAfterRow struct {
+	ID             int64         `json:"id"`
+	AccountID      int64         `json:"account_id"`
+	Amount         int64         `json:"amount"`
+	CreatedAt      time.Time     `json:"created_at"`
+	TransferID     sql.NullInt64 `json:"transfer_id"`
+	RunningBalance int64         `json:"running_balance"`
+}
+
+func (q *Queries) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesAfter, arg.AccountID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesAfterRow{}
+	for rows.Next() {
+		var i ListAccountEntriesAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrphanEntries = `-- name: ListOrphanEntries :many
+SELECT id, account_id, amount, created_at, transfer_id FROM entries
+WHERE transfer_id IS NULL AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListOrphanEntriesParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListOrphanEntries(ctx context.Context, arg ListOrphanEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanEntries, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
 		); err != nil {
 			return nil, err
 		}
@@ -101,11 +272,39 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	return items, nil
 }
 
+const sumAccountEntries = `-- name: SumAccountEntries :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+    AND created_at >= $2
+    AND created_at < $3
+    AND id <= $4
+`
+
+type SumAccountEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	MaxID     int64     `json:"max_id"`
+}
+
+func (q *Queries) SumAccountEntries(ctx context.Context, arg SumAccountEntriesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MaxID,
+	)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const updateEntry = `-- name: UpdateEntry :one
 UPDATE entries
 SET amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, transfer_id
 `
 
 type UpdateEntryParams struct {
@@ -121,6 +320,7 @@ func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
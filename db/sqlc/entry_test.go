@@ -92,3 +92,72 @@ func TestListEntries(t *testing.T) {
 		require.NotEmpty(t, entry)
 	}
 }
+
+func TestListOrphanEntries(t *testing.T) {
+	entry := createRandomEntry(t)
+	require.False(t, entry.TransferID.Valid)
+
+	entries, err := testQueries.ListOrphanEntries(context.Background(), ListOrphanEntriesParams{
+		AfterID:   entry.ID - 1,
+		BatchSize: 5,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	require.Equal(t, entry.ID, entries[0].ID)
+
+	for _, orphan := range entries {
+		require.False(t, orphan.TransferID.Valid)
+		require.Greater(t, orphan.ID, entry.ID-1)
+	}
+}
+
+func TestListAccountEntriesAfter(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	lastID, err := testQueries.GetLastAccountEntryID(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, lastID)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := testQueries.ListAccountEntriesAfter(context.Background(), ListAccountEntriesAfterParams{
+		AccountID: account1.ID,
+		AfterID:   lastID,
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(entries), 3)
+
+	// Each entry leaves the balance of the one before plus its amount.
+	balance := int64(100)
+	for _, entry := range entries {
+		require.Equal(t, account1.ID, entry.AccountID)
+		balance += entry.Amount
+		require.Equal(t, balance, entry.RunningBalance)
+	}
+
+	account1, err = testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, balance)
+
+	lastID, err = testQueries.GetLastAccountEntryID(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, entries[len(entries)-1].ID, lastID)
+
+	entries, err = testQueries.ListAccountEntriesAfter(context.Background(), ListAccountEntriesAfterParams{
+		AccountID: account1.ID,
+		AfterID:   lastID,
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
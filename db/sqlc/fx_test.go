@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func createRandomFxQuote(t *testing.T, owner string, expiresAt time.Time) FxQuote {
+	arg := CreateFxQuoteParams{
+		Owner:        owner,
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.92",
+		FromAmount:   100,
+		ToAmount:     92,
+		ExpiresAt:    expiresAt,
+	}
+
+	quote, err := testQueries.CreateFxQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, quote.ID)
+	require.Equal(t, arg.Owner, quote.Owner)
+	require.Equal(t, arg.Rate, quote.Rate)
+	require.Equal(t, arg.FromAmount, quote.FromAmount)
+	require.Equal(t, arg.ToAmount, quote.ToAmount)
+	require.False(t, quote.DebitTransferID.Valid)
+	require.False(t, quote.CreditTransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, quote.ExpiresAt, time.Second)
+	return quote
+}
+
+func TestUpsertFxRate(t *testing.T) {
+	arg := UpsertFxRateParams{
+		FromCurrency: util.CAD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.68",
+	}
+	rate1, err := testQueries.UpsertFxRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, rate1.Rate)
+
+	arg.Rate = "0.69"
+	rate2, err := testQueries.UpsertFxRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, rate2.Rate)
+	require.False(t, rate2.UpdatedAt.Before(rate1.UpdatedAt))
+
+	rate3, err := testQueries.GetFxRate(context.Background(), GetFxRateParams{
+		FromCurrency: util.CAD,
+		ToCurrency:   util.EUR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate2, rate3)
+
+	rates, err := testQueries.ListFxRates(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, rates, rate3)
+}
+
+func TestGetFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote1 := createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute))
+
+	quote2, err := testQueries.GetFxQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.Owner, quote2.Owner)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+}
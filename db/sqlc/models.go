@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,26 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// sum of the active holds on the account
+	HeldBalance int64 `json:"held_balance"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// username of who froze the account, only set while it is frozen
+	FrozenBy sql.NullString `json:"frozen_by"`
+}
+
+type Currency struct {
+	// ISO 4217 code
+	Code string `json:"code"`
+	// decimal places of the minor unit amounts are expressed in
+	Exponent int32  `json:"exponent"`
+	Symbol   string `json:"symbol"`
+	// disabled currencies are rejected by new requests
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Entry struct {
@@ -24,6 +46,129 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that posted the entry
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+type FeeSchedule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// smallest transfer amount the tier applies to
+	MinAmount int64 `json:"min_amount"`
+	FlatFee   int64 `json:"flat_fee"`
+	// percentage of the amount in basis points, added to the flat fee
+	PercentageBps int32     `json:"percentage_bps"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type FxQuote struct {
+	ID           int64  `json:"id"`
+	Owner        string `json:"owner"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// rate locked in when the quote was created
+	Rate       string `json:"rate"`
+	FromAmount int64  `json:"from_amount"`
+	ToAmount   int64  `json:"to_amount"`
+	// transfer from the customer to the house account
+	DebitTransferID sql.NullInt64 `json:"debit_transfer_id"`
+	// transfer from the house account to the customer
+	CreditTransferID sql.NullInt64 `json:"credit_transfer_id"`
+	ExpiresAt        time.Time     `json:"expires_at"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+type FxRate struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// units of to_currency bought by one unit of from_currency
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// active, captured, voided or expired
+	Status string `json:"status"`
+	// transfer that captured the hold
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Username    string          `json:"username"`
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type OutboxEvent struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// users the event concerns, whose subscriptions receive it
+	Usernames []string        `json:"usernames"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// when deliveries were created for the subscriptions
+	DispatchedAt sql.NullTime `json:"dispatched_at"`
+}
+
+type RiskDecision struct {
+	ID            int64  `json:"id"`
+	Username      string `json:"username"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// session the transfer was requested from, if known
+	SessionID uuid.NullUUID `json:"session_id"`
+	ClientIp  string        `json:"client_ip"`
+	// allow, deny or flag
+	Action string `json:"action"`
+	// rules that matched, with their action and reason
+	Matches json.RawMessage `json:"matches"`
+	// transfer made after the decision, if any
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// once, weekly or monthly
+	Recurrence string `json:"recurrence"`
+	// day of monthly runs, clamped to the length of the month
+	DayOfMonth int32 `json:"day_of_month"`
+	// active, completed, failed or cancelled
+	Status    string    `json:"status"`
+	NextRunAt time.Time `json:"next_run_at"`
+	// successful runs, part of the idempotency key of the next run
+	RunCount int32 `json:"run_count"`
+	// failed attempts of the next run
+	Attempts int32 `json:"attempts"`
+	// earliest time of the next attempt after a failure
+	RetryAt sql.NullTime `json:"retry_at"`
+	// lease of the worker executing the next run
+	LockedUntil sql.NullTime   `json:"locked_until"`
+	LastError   sql.NullString `json:"last_error"`
+	// transfer made by the last successful run
+	LastTransferID sql.NullInt64 `json:"last_transfer_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	// assessment of the last create or edit, linked to the transfer of the next run
+	RiskDecisionID sql.NullInt64 `json:"risk_decision_id"`
 }
 
 type Session struct {
@@ -35,6 +180,10 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// ID of the session opened at login, shared by every rotation of its refresh token
+	FamilyID uuid.UUID `json:"family_id"`
+	// when the refresh token was exchanged for a new one, presenting it again revokes the family
+	RotatedAt sql.NullTime `json:"rotated_at"`
 }
 
 type Transfer struct {
@@ -44,6 +193,24 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer reversed by this one
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+	// transfer charged by this fee
+	FeeOf sql.NullInt64 `json:"fee_of"`
+}
+
+type TransferLimit struct {
+	Tier     string `json:"tier"`
+	Currency string `json:"currency"`
+	// zero means no limit
+	MaxPerTransfer int64 `json:"max_per_transfer"`
+	// total sent per UTC calendar day, zero means no limit
+	MaxPerDay int64 `json:"max_per_day"`
+	// total sent over the last 30 days, zero means no limit
+	MaxPer30Days int64 `json:"max_per_30_days"`
+	// transfers sent over the last hour, zero means no limit
+	MaxCountPerHour int32     `json:"max_count_per_hour"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -53,4 +220,39 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// selects the transfer limits of the user
+	Tier string `json:"tier"`
+	// customer, support or admin
+	Role string `json:"role"`
+}
+
+type WebhookDelivery struct {
+	ID             int64 `json:"id"`
+	EventID        int64 `json:"event_id"`
+	SubscriptionID int64 `json:"subscription_id"`
+	// pending, delivered or dead
+	Status        string    `json:"status"`
+	Attempts      int32     `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// lease of the dispatcher sending the delivery
+	LockedUntil sql.NullTime   `json:"locked_until"`
+	LastError   sql.NullString `json:"last_error"`
+	// HTTP status of the last attempt, if a response was received
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	DeliveredAt    sql.NullTime  `json:"delivered_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+type WebhookSubscription struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Url   string `json:"url"`
+	// key of the HMAC-SHA256 signature of deliveries
+	Secret string `json:"secret"`
+	// empty means every event type
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
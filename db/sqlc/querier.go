@@ -6,32 +6,120 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) (int64, error)
+	BlockUserSessions(ctx context.Context, arg BlockUserSessionsParams) (int64, error)
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	ClaimScheduledTransfer(ctx context.Context, arg ClaimScheduledTransferParams) (ScheduledTransfer, error)
+	ClaimWebhookDelivery(ctx context.Context, arg ClaimWebhookDeliveryParams) (WebhookDelivery, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateCurrency(ctx context.Context, arg CreateCurrencyParams) (Currency, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeTransfer(ctx context.Context, arg CreateFeeTransferParams) (Transfer, error)
+	CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error)
+	CreateReversalTransfer(ctx context.Context, arg CreateReversalTransferParams) (Transfer, error)
+	CreateRiskDecision(ctx context.Context, arg CreateRiskDecisionParams) (RiskDecision, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookDeliveries(ctx context.Context, id int64) error
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	DeleteEntry(ctx context.Context, id int64) error
+	DeleteExpiredSessions(ctx context.Context, arg DeleteExpiredSessionsParams) (int64, error)
+	DeleteFeeSchedule(ctx context.Context, id int64) (FeeSchedule, error)
 	DeleteTransfer(ctx context.Context, id int64) error
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountTransferLimit(ctx context.Context, arg GetAccountTransferLimitParams) (TransferLimit, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeScheduleForAmount(ctx context.Context, arg GetFeeScheduleForAmountParams) (FeeSchedule, error)
+	GetFxQuote(ctx context.Context, id int64) (FxQuote, error)
+	GetFxQuoteForUpdate(ctx context.Context, id int64) (FxQuote, error)
+	GetFxRate(ctx context.Context, arg GetFxRateParams) (FxRate, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLastAccountEntryID(ctx context.Context, accountID int64) (int64, error)
+	GetOutboxEvent(ctx context.Context, id int64) (OutboxEvent, error)
+	GetRiskDecision(ctx context.Context, id int64) (RiskDecision, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferLimitResetTime(ctx context.Context, arg GetTransferLimitResetTimeParams) (time.Time, error)
+	GetTransferVelocity(ctx context.Context, arg GetTransferVelocityParams) (GetTransferVelocityRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (WebhookSubscription, error)
+	HasTransferBetween(ctx context.Context, arg HasTransferBetweenParams) (bool, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error)
+	ListAccountEntryTotals(ctx context.Context, arg ListAccountEntryTotalsParams) ([]ListAccountEntryTotalsRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	ListDueScheduledTransfers(ctx context.Context, arg ListDueScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]WebhookDelivery, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredHolds(ctx context.Context, arg ListExpiredHoldsParams) ([]Hold, error)
+	ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error)
+	ListFxRates(ctx context.Context) ([]FxRate, error)
+	ListOrphanEntries(ctx context.Context, arg ListOrphanEntriesParams) ([]Entry, error)
+	ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error)
+	ListRiskDecisions(ctx context.Context, arg ListRiskDecisionsParams) ([]RiskDecision, error)
+	// Lists the current session of each login, created_at is the time of the login.
+	ListSessions(ctx context.Context, username string) ([]Session, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListTransferEntryCounts(ctx context.Context, arg ListTransferEntryCountsParams) ([]ListTransferEntryCountsRow, error)
+	ListTransferLimits(ctx context.Context) ([]TransferLimit, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUndispatchedOutboxEvents(ctx context.Context, limit int32) ([]OutboxEvent, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookSubscriptions(ctx context.Context, arg ListWebhookSubscriptionsParams) ([]WebhookSubscription, error)
+	MarkOutboxEventDispatched(ctx context.Context, id int64) error
+	Notify(ctx context.Context, arg NotifyParams) error
+	RecordScheduledTransferFailure(ctx context.Context, arg RecordScheduledTransferFailureParams) (ScheduledTransfer, error)
+	RecordScheduledTransferRun(ctx context.Context, arg RecordScheduledTransferRunParams) (ScheduledTransfer, error)
+	RecordWebhookDeliveryFailure(ctx context.Context, arg RecordWebhookDeliveryFailureParams) (WebhookDelivery, error)
+	RecordWebhookDeliverySuccess(ctx context.Context, arg RecordWebhookDeliverySuccessParams) (WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	RotateSession(ctx context.Context, arg RotateSessionParams) (Session, error)
+	SumAccountEntries(ctx context.Context, arg SumAccountEntriesParams) (int64, error)
+	SumTransferReversals(ctx context.Context, reversalOf sql.NullInt64) (int64, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateCurrency(ctx context.Context, arg UpdateCurrencyParams) (Currency, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry, error)
+	UpdateFxQuoteTransfers(ctx context.Context, arg UpdateFxQuoteTransfersParams) (FxQuote, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdateRiskDecisionTransfer(ctx context.Context, arg UpdateRiskDecisionTransferParams) (RiskDecision, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
 	UpdateTransfer(ctx context.Context, arg UpdateTransferParams) (Transfer, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error)
+	UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error)
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error)
+	UpsertFxRate(ctx context.Context, arg UpsertFxRateParams) (FxRate, error)
+	UpsertTransferLimit(ctx context.Context, arg UpsertTransferLimitParams) (TransferLimit, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes of transactions that can safely be retried.
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+// Controls how execTx retries transactions
+// that failed because of a serialization failure or a deadlock.
+type RetryPolicy struct {
+	MaxAttempts int           // Total number of attempts, including the first one.
+	BaseDelay   time.Duration // Upper bound of the delay before the first retry.
+	MaxDelay    time.Duration // Upper bound of the delay before any retry.
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    500 * time.Millisecond,
+}
+
+/**
+ * Returns the delay before the given retry attempt (starting from 1).
+ * The delay is drawn uniformly from [0, min(MaxDelay, BaseDelay * 2^(attempt-1))]
+ * so concurrent transactions don't retry in lockstep.
+ */
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// Reports whether the transaction failed with an error that is worth retrying.
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailureCode || pqErr.Code == deadlockDetectedCode
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    40 * time.Millisecond,
+	}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := policy.backoff(attempt)
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, policy.MaxDelay)
+	}
+	require.LessOrEqual(t, policy.backoff(1), policy.BaseDelay)
+	require.Zero(t, RetryPolicy{}.backoff(1))
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	serializationErr := &pq.Error{Code: serializationFailureCode}
+	deadlockErr := &pq.Error{Code: deadlockDetectedCode}
+
+	require.True(t, isRetryableTxError(serializationErr))
+	require.True(t, isRetryableTxError(deadlockErr))
+	require.True(t, isRetryableTxError(fmt.Errorf("tx err: %w, rollback err: %v", deadlockErr, sql.ErrTxDone)))
+	require.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryableTxError(errors.New("some error")))
+	require.False(t, isRetryableTxError(nil))
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStore(testDB, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	})).(*SQLStore)
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+	// Retryable errors are retried until the transaction succeeds.
+	attempts := 0
+	err := store.execTx(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: serializationFailureCode}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	// Attempts are bounded.
+	attempts = 0
+	err = store.execTx(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		return &pq.Error{Code: deadlockDetectedCode}
+	})
+	require.True(t, isRetryableTxError(err))
+	require.Equal(t, 3, attempts)
+
+	// Other errors are returned immediately.
+	attempts = 0
+	err = store.execTx(context.Background(), opts, func(q *Queries) error {
+		attempts++
+		return sql.ErrNoRows
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.Equal(t, 1, attempts)
+}
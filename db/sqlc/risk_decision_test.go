@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRiskDecision(t *testing.T, action string) RiskDecision {
+	transfer := createRandomTransfer(t)
+	account, err := testQueries.GetAccount(context.Background(), transfer.FromAccountID)
+	require.NoError(t, err)
+
+	arg := CreateRiskDecisionParams{
+		Username:      account.Owner,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      account.Currency,
+		SessionID:     uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ClientIp:      "192.0.2.1",
+		Action:        action,
+		Matches:       json.RawMessage(`[{"rule": "fresh_session", "action": "flag", "reason": "session created 1m0s ago"}]`),
+	}
+
+	decision, err := testQueries.CreateRiskDecision(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, decision.ID)
+	require.Equal(t, arg.Username, decision.Username)
+	require.Equal(t, arg.FromAccountID, decision.FromAccountID)
+	require.Equal(t, arg.ToAccountID, decision.ToAccountID)
+	require.Equal(t, arg.Amount, decision.Amount)
+	require.Equal(t, arg.Currency, decision.Currency)
+	require.Equal(t, arg.SessionID, decision.SessionID)
+	require.Equal(t, arg.ClientIp, decision.ClientIp)
+	require.Equal(t, arg.Action, decision.Action)
+	require.JSONEq(t, string(arg.Matches), string(decision.Matches))
+	require.False(t, decision.TransferID.Valid)
+	require.WithinDuration(t, time.Now(), decision.CreatedAt, time.Second)
+	return decision
+}
+
+func TestGetRiskDecision(t *testing.T) {
+	decision1 := createRandomRiskDecision(t, "flag")
+
+	decision2, err := testQueries.GetRiskDecision(context.Background(), decision1.ID)
+	require.NoError(t, err)
+	require.Equal(t, decision1, decision2)
+}
+
+func TestListRiskDecisions(t *testing.T) {
+	denied := createRandomRiskDecision(t, "deny")
+	flagged := createRandomRiskDecision(t, "flag")
+
+	decisions, err := testQueries.ListRiskDecisions(context.Background(), ListRiskDecisionsParams{
+		Action: "deny",
+		Limit:  100,
+	})
+	require.NoError(t, err)
+	require.Contains(t, decisions, denied)
+	require.NotContains(t, decisions, flagged)
+
+	decisions, err = testQueries.ListRiskDecisions(context.Background(), ListRiskDecisionsParams{
+		Username: flagged.Username,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []RiskDecision{flagged}, decisions)
+}
+
+func TestUpdateRiskDecisionTransfer(t *testing.T) {
+	decision1 := createRandomRiskDecision(t, "allow")
+	transfer := createRandomTransfer(t)
+
+	decision2, err := testQueries.UpdateRiskDecisionTransfer(context.Background(), UpdateRiskDecisionTransferParams{
+		ID:         decision1.ID,
+		TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, decision2.TransferID.Int64)
+	require.Equal(t, decision1.Matches, decision2.Matches)
+}
@@ -0,0 +1,56 @@
+package db
+
+import "time"
+
+// How often a scheduled transfer runs.
+const (
+	RecurrenceOnce    = "once"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// Lifecycle of a scheduled transfer. Only active ones are executed.
+// Failed ones ran out of retries and can be resumed by updating them.
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusFailed    = "failed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+/**
+ * Returns the first run of a recurring transfer after now,
+ * counting from its current next run.
+ * Runs missed while no worker was running are skipped.
+ * Returns false for transfers that only run once.
+ */
+func (st ScheduledTransfer) NextRunAfter(now time.Time) (time.Time, bool) {
+	next := st.NextRunAt.UTC()
+
+	switch st.Recurrence {
+	case RecurrenceWeekly:
+		for !next.After(now) {
+			next = next.AddDate(0, 0, 7)
+		}
+	case RecurrenceMonthly:
+		for !next.After(now) {
+			next = nextMonthDay(next, int(st.DayOfMonth))
+		}
+	default:
+		return next, false
+	}
+	return next, true
+}
+
+// Moves t to the given day of the following month,
+// or to the last day of that month if it is shorter.
+func nextMonthDay(t time.Time, day int) time.Time {
+	year, month, _ := t.Date()
+	first := time.Date(year, month+1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
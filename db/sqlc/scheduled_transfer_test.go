@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, nextRunAt time.Time) ScheduledTransfer {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := CreateScheduledTransferParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Recurrence:    RecurrenceWeekly,
+		NextRunAt:     nextRunAt,
+	}
+
+	st, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, st.ID)
+	require.Equal(t, arg.Owner, st.Owner)
+	require.Equal(t, arg.FromAccountID, st.FromAccountID)
+	require.Equal(t, arg.ToAccountID, st.ToAccountID)
+	require.Equal(t, arg.Amount, st.Amount)
+	require.Equal(t, arg.Recurrence, st.Recurrence)
+	require.Equal(t, ScheduleStatusActive, st.Status)
+	require.Zero(t, st.RunCount)
+	require.Zero(t, st.Attempts)
+	require.False(t, st.LockedUntil.Valid)
+	require.WithinDuration(t, arg.NextRunAt, st.NextRunAt, time.Second)
+	return st
+}
+
+func TestClaimScheduledTransfer(t *testing.T) {
+	st := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+
+	now := time.Now()
+	arg := ClaimScheduledTransferParams{
+		ID:          st.ID,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	}
+	claimed, err := testQueries.ClaimScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, claimed.LockedUntil.Valid)
+
+	// Still leased to the first worker.
+	_, err = testQueries.ClaimScheduledTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	due, err := testQueries.ListDueScheduledTransfers(context.Background(), ListDueScheduledTransfersParams{
+		Now:   now,
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+	for _, d := range due {
+		require.NotEqual(t, st.ID, d.ID)
+	}
+
+	// Not due yet.
+	future := createRandomScheduledTransfer(t, time.Now().Add(time.Hour))
+	arg.ID = future.ID
+	_, err = testQueries.ClaimScheduledTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordScheduledTransferRun(t *testing.T) {
+	st := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+	transfer := createRandomTransfer(t)
+
+	now := time.Now()
+	claimed, err := testQueries.ClaimScheduledTransfer(context.Background(), ClaimScheduledTransferParams{
+		ID:          st.ID,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	nextRunAt, recurring := claimed.NextRunAfter(now)
+	require.True(t, recurring)
+
+	arg := RecordScheduledTransferRunParams{
+		ID:             claimed.ID,
+		LockedUntil:    claimed.LockedUntil,
+		Status:         ScheduleStatusActive,
+		NextRunAt:      nextRunAt,
+		LastTransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+	}
+	run, err := testQueries.RecordScheduledTransferRun(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), run.RunCount)
+	require.Equal(t, arg.LastTransferID, run.LastTransferID)
+	require.False(t, run.LockedUntil.Valid)
+	require.WithinDuration(t, nextRunAt, run.NextRunAt, time.Second)
+
+	// The lease is gone, the run can't be recorded twice.
+	_, err = testQueries.RecordScheduledTransferRun(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordScheduledTransferFailure(t *testing.T) {
+	st := createRandomScheduledTransfer(t, time.Now().Add(-time.Minute))
+
+	now := time.Now()
+	claimed, err := testQueries.ClaimScheduledTransfer(context.Background(), ClaimScheduledTransferParams{
+		ID:          st.ID,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	failed, err := testQueries.RecordScheduledTransferFailure(context.Background(), RecordScheduledTransferFailureParams{
+		ID:          claimed.ID,
+		LockedUntil: claimed.LockedUntil,
+		Status:      ScheduleStatusActive,
+		RetryAt:     sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+		LastError:   sql.NullString{String: ErrInsufficientFunds.Error(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.Attempts)
+	require.Zero(t, failed.RunCount)
+	require.True(t, failed.RetryAt.Valid)
+	require.Equal(t, ErrInsufficientFunds.Error(), failed.LastError.String)
+
+	// Waiting for its retry.
+	_, err = testQueries.ClaimScheduledTransfer(context.Background(), ClaimScheduledTransferParams{
+		ID:          st.ID,
+		Now:         time.Now(),
+		LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	st := createRandomScheduledTransfer(t, time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), st.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleStatusCancelled, cancelled.Status)
+
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), st.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestNextRunAfter(t *testing.T) {
+	start := time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	once := ScheduledTransfer{Recurrence: RecurrenceOnce, NextRunAt: start}
+	_, recurring := once.NextRunAfter(start)
+	require.False(t, recurring)
+
+	weekly := ScheduledTransfer{Recurrence: RecurrenceWeekly, NextRunAt: start}
+	next, recurring := weekly.NextRunAfter(start)
+	require.True(t, recurring)
+	require.Equal(t, start.AddDate(0, 0, 7), next)
+
+	// Missed runs are skipped.
+	next, _ = weekly.NextRunAfter(start.AddDate(0, 0, 20))
+	require.Equal(t, start.AddDate(0, 0, 21), next)
+
+	monthly := ScheduledTransfer{Recurrence: RecurrenceMonthly, DayOfMonth: 31, NextRunAt: start}
+	next, recurring = monthly.NextRunAfter(start)
+	require.True(t, recurring)
+	require.Equal(t, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC), next)
+
+	// Back on the 31st once the month is long enough.
+	monthly.NextRunAt = next
+	next, _ = monthly.NextRunAfter(next)
+	require.Equal(t, time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC), next)
+}
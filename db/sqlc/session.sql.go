@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,10 +21,11 @@ INSERT INTO sessions (
   user_agent,
   client_ip,
   is_blocked,
-  expires_at
+  expires_at,
+  family_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, rotated_at
 `
 
 type CreateSessionParams struct {
@@ -34,6 +36,7 @@ type CreateSessionParams struct {
 	ClientIp     string    `json:"client_ip"`
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
+	FamilyID     uuid.UUID `json:"family_id"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -45,6 +48,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.ClientIp,
 		arg.IsBlocked,
 		arg.ExpiresAt,
+		arg.FamilyID,
 	)
 	var i Session
 	err := row.Scan(
@@ -56,12 +60,14 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, rotated_at FROM sessions
 WHERE id = $1 LIMIT 1
 `
 
@@ -77,6 +83,165 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
+
+const listSessions = `-- name: ListSessions :many
+SELECT
+    s.id, s.username, s.refresh_token, s.user_agent, s.client_ip,
+    s.is_blocked, s.expires_at, l.created_at, s.family_id, s.rotated_at
+FROM sessions s
+JOIN sessions l ON l.id = s.family_id
+WHERE s.username = $1 AND s.rotated_at IS NULL
+ORDER BY l.created_at DESC
+`
+
+// Lists the current session of each login, created_at is the time of the login.
+func (q *Queries) ListSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listSessions, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.RotatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const blockSession = `-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, rotated_at
+`
+
+func (q *Queries) BlockSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, blockSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+    AND is_blocked = false
+    AND expires_at > $2
+`
+
+type BlockUserSessionsParams struct {
+	Username string    `json:"username"`
+	Now      time.Time `json:"now"`
+}
+
+func (q *Queries) BlockUserSessions(ctx context.Context, arg BlockUserSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, blockUserSessions, arg.Username, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE id IN (
+    SELECT id FROM sessions
+    WHERE expires_at <= $1
+    LIMIT $2
+)
+`
+
+type DeleteExpiredSessionsParams struct {
+	Now   time.Time `json:"now"`
+	Limit int32     `json:"limit"`
+}
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context, arg DeleteExpiredSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSessions, arg.Now, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rotateSession = `-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = $1
+WHERE id = $2 AND rotated_at IS NULL AND is_blocked = false
+RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, rotated_at
+`
+
+type RotateSessionParams struct {
+	RotatedAt sql.NullTime `json:"rotated_at"`
+	ID        uuid.UUID    `json:"id"`
+}
+
+func (q *Queries) RotateSession(ctx context.Context, arg RotateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, rotateSession, arg.RotatedAt, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const blockSessionFamily = `-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND is_blocked = false
+`
+
+func (q *Queries) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, blockSessionFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
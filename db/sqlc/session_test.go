@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	return createSessionExpiringAt(t, user, time.Now().Add(time.Hour))
+}
+
+func createSessionExpiringAt(t *testing.T, user User, expiresAt time.Time) Session {
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		FamilyID:     id,
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "192.0.2.1",
+		ExpiresAt:    expiresAt,
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.FamilyID, session.FamilyID)
+	require.False(t, session.IsBlocked)
+	require.False(t, session.RotatedAt.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestListSessions(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+	createRandomSession(t, createRandomUser(t))
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	ids := []uuid.UUID{sessions[0].ID, sessions[1].ID}
+	require.ElementsMatch(t, []uuid.UUID{session1.ID, session2.ID}, ids)
+}
+
+func TestBlockSession(t *testing.T) {
+	session1 := createRandomSession(t, createRandomUser(t))
+
+	session2, err := testQueries.BlockSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.True(t, session2.IsBlocked)
+}
+
+func TestBlockSessionFamily(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	other := createRandomSession(t, user)
+
+	n, err := testQueries.BlockSessionFamily(context.Background(), session.FamilyID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	// Sessions of other logins are left alone.
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsBlocked)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomSession(t, user)
+	createRandomSession(t, user)
+	expired := createSessionExpiringAt(t, user, time.Now().Add(-time.Minute))
+	_, err := testQueries.BlockSession(context.Background(), createRandomSession(t, user).ID)
+	require.NoError(t, err)
+	other := createRandomSession(t, createRandomUser(t))
+
+	// Only the sessions still active are counted.
+	n, err := testQueries.BlockUserSessions(context.Background(), BlockUserSessionsParams{
+		Username: user.Username,
+		Now:      time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	for _, session := range sessions {
+		require.Equal(t, session.ID != expired.ID, session.IsBlocked)
+	}
+
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsBlocked)
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	user := createRandomUser(t)
+	active := createRandomSession(t, user)
+	expired := createSessionExpiringAt(t, user, time.Now().Add(-time.Minute))
+
+	for {
+		n, err := testQueries.DeleteExpiredSessions(context.Background(), DeleteExpiredSessionsParams{
+			Now:   time.Now(),
+			Limit: 100,
+		})
+		require.NoError(t, err)
+		if n < 100 {
+			break
+		}
+	}
+
+	_, err := testQueries.GetSession(context.Background(), expired.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.GetSession(context.Background(), active.ID)
+	require.NoError(t, err)
+}
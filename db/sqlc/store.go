@@ -2,34 +2,177 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"time"
 )
 
+var (
+	// Returned when an idempotency key is replayed with a different request.
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+
+	// Returned when an account can't cover a debit, even with its overdraft.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Describes a debit that was rejected because of insufficient funds.
+// It matches ErrInsufficientFunds with errors.Is.
+type InsufficientFundsError struct {
+	AccountID        int64 `json:"account_id"`
+	AvailableBalance int64 `json:"available_balance"`
+	Amount           int64 `json:"amount"`
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%v: account [%d] has %d available, %d requested",
+		ErrInsufficientFunds, e.AccountID, e.AvailableBalance, e.Amount,
+	)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// Returns the amount that can still be debited from the account.
+// Money reserved by active holds is not available.
+func (account Account) AvailableBalance() int64 {
+	return account.Balance + account.OverdraftLimit - account.HeldBalance
+}
+
+// Checks that the account can be debited by amount.
+func checkFunds(account Account, amount int64) error {
+	if account.AvailableBalance() < amount {
+		return &InsufficientFundsError{
+			AccountID:        account.ID,
+			AvailableBalance: account.AvailableBalance(),
+			Amount:           amount,
+		}
+	}
+	return nil
+}
+
 // Provides all functions to execute db queries and transactions.
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (
 		TransferTxResult, error,
 	)
+	GetIdempotentTransfer(ctx context.Context, arg TransferTxParams) (
+		TransferTxResult, error,
+	)
+	DepositTx(ctx context.Context, arg CashTxParams) (
+		CashTxResult, error,
+	)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (
+		CashTxResult, error,
+	)
+	StatementTx(ctx context.Context, arg StatementTxParams) (
+		StatementTxResult, error,
+	)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (
+		TransferTxResult, error,
+	)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (
+		HoldTxResult, error,
+	)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (
+		CaptureHoldTxResult, error,
+	)
+	VoidHoldTx(ctx context.Context, holdID int64) (
+		HoldTxResult, error,
+	)
+	ExpireHoldTx(ctx context.Context, holdID int64) (
+		HoldTxResult, error,
+	)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (
+		CloseAccountTxResult, error,
+	)
+	ConvertTx(ctx context.Context, arg ConvertTxParams) (
+		ConvertTxResult, error,
+	)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (
+		Account, error,
+	)
+	CreateUserTx(ctx context.Context, arg CreateUserParams) (
+		User, error,
+	)
+	CreateCurrencyTx(ctx context.Context, arg CreateCurrencyParams) (
+		CreateCurrencyTxResult, error,
+	)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (
+		Session, error,
+	)
+	DispatchEventsTx(ctx context.Context, limit int32) (
+		int, error,
+	)
 }
 
 // Provides all functions to execute db queries and transactions.
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db    *sql.DB
+	retry RetryPolicy
+}
+
+// Configures optional behaviour of SQLStore.
+type StoreOption func(*SQLStore)
+
+// Overrides DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) StoreOption {
+	return func(store *SQLStore) {
+		store.retry = policy
+	}
 }
 
-func NewStore(db *sql.DB) Store {
-	return &SQLStore{
+func NewStore(db *sql.DB, options ...StoreOption) Store {
+	store := &SQLStore{
 		db:      db,
 		Queries: New(db),
+		retry:   DefaultRetryPolicy,
+	}
+	for _, option := range options {
+		option(store)
+	}
+	return store
+}
+
+/**
+ * Executes a function within a database transaction.
+ * opts selects the isolation level, nil means the driver's default.
+ * Serialization failures and deadlocks are retried according to the
+ * store's retry policy, so fn must be safe to run more than once.
+ */
+func (store *SQLStore) execTx(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(*Queries) error,
+) error {
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) || attempt >= store.retry.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(store.retry.backoff(attempt)):
+		}
 	}
 }
 
-// Executes a function within a database transaction.
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+// Runs a single attempt of a database transaction.
+func (store *SQLStore) runTx(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(*Queries) error,
+) error {
+	tx, err := store.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -37,7 +180,7 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	q := New(tx)
 	if err := fn(q); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rollback err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rollback err: %v", err, rbErr)
 		}
 		return err
 	}
@@ -50,6 +193,27 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+
+	// Optional. Deduplicates retries of the same transfer.
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// Identifies a client request that must be executed at most once.
+type IdempotencyParams struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+}
+
+// Keys of the scheduled transfer runs start with it, in the namespace of
+// their owner. Clients can't use it, or they could replay a run.
+const ScheduledRunKeyPrefix = "scheduled-transfer:"
+
+// Hash of the fields that make up a transfer request.
+func (arg TransferTxParams) requestHash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf(
+		"%d:%d:%d", arg.FromAccountID, arg.ToAccountID, arg.Amount,
+	)))
+	return hex.EncodeToString(sum[:])
 }
 
 // The result of the transfer transaction.
@@ -59,69 +223,269 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+
+	// Set if the transfer was charged a fee.
+	Fee *TransferFee `json:"fee,omitempty"`
+
+	// True if the result was loaded from a previous request
+	// with the same idempotency key.
+	Replayed bool `json:"-"`
 }
 
 /**
  * Performs a money transfer from one account to the other.
  * It creates a transfer record, add account entries,
  * and update account's balance within a single database transaction.
+ * Both accounts must be active, otherwise an *AccountNotActiveError is returned.
+ * The amount must stay within the limits of the sender's tier,
+ * otherwise a *TransferLimitError is returned.
+ * The fee of the transfer, if its currency has a fee schedule, is charged
+ * to the source account in the same transaction. It is not given back
+ * when the transfer is reversed.
+ * The source account must have enough funds for the amount and the fee,
+ * including its overdraft limit, otherwise an *InsufficientFundsError is returned.
+ * A transfer.created event is written to the outbox with the transfer,
+ * and both accounts are notified on AccountEventsChannel when it commits.
+ * If an idempotency key is given, it is stored in the same transaction
+ * and replays of the key return the stored result.
+ * The transaction runs at SERIALIZABLE and is retried on conflicts.
  */
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (
 	TransferTxResult, error,
 ) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
 		var err error
+		result = TransferTxResult{}
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
-		if err != nil {
-			return err
+		if arg.Idempotency != nil {
+			result, err = claimIdempotencyKey(ctx, q, arg)
+			if err != nil || result.Replayed {
+				return err
+			}
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
 		if err != nil {
 			return err
 		}
-
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
+		if err = executeTransfer(ctx, q, fromAccount, toAccount, arg.Amount, &result); err != nil {
 			return err
 		}
 
-		// Update account's balance.
-		// Update smaller accound id first to avoid deadlock.
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(
-				ctx, q, arg.FromAccountID, -arg.Amount,
-				arg.ToAccountID, arg.Amount,
-			)
-			if err != nil {
-				return err
-			}
-
-			return nil
+		if arg.Idempotency != nil {
+			return saveIdempotencyResponse(ctx, q, *arg.Idempotency, result)
 		}
+		return nil
+	})
+
+	return result, err
+}
+
+/**
+ * Moves amount between two accounts, with all the checks and side effects
+ * of a transfer: both accounts must be active, the amount must stay within
+ * the sender's limits and the sender must cover it along with its fee.
+ * It creates the transfer and its entries into result, records
+ * transfer.created and charges the fee.
+ * Both accounts must already be locked by the caller.
+ * Every transfer between customers goes through it, so they can't drift apart.
+ */
+func executeTransfer(
+	ctx context.Context,
+	q *Queries,
+	fromAccount Account,
+	toAccount Account,
+	amount int64,
+	result *TransferTxResult,
+) error {
+	if err := checkActive(fromAccount, toAccount); err != nil {
+		return err
+	}
+	if err := checkTransferLimits(ctx, q, fromAccount, amount, time.Now()); err != nil {
+		return err
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount.Currency, amount)
+	if err != nil {
+		return err
+	}
+	if amount > math.MaxInt64-fee {
+		return ErrFeeOverflow
+	}
+	if err = checkFunds(fromAccount, amount+fee); err != nil {
+		return err
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = postTransfer(ctx, q, result); err != nil {
+		return err
+	}
+	// Recorded before the fee, whose event refers to it.
+	if err = recordTransferCreated(ctx, q, *result); err != nil {
+		return err
+	}
+	if fee > 0 {
+		return chargeFee(ctx, q, result, fee)
+	}
+	return nil
+}
+
+/**
+ * Creates the entries of result.Transfer and updates both balances.
+ * The accounts must already be locked by the caller.
+ */
+func postTransfer(ctx context.Context, q *Queries, result *TransferTxResult) (err error) {
+	transfer := result.Transfer
+	transferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  transfer.FromAccountID,
+		Amount:     -transfer.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  transfer.ToAccountID,
+		Amount:     transfer.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return
+	}
+
+	// Update account's balance.
+	// Update smaller accound id first to avoid deadlock.
+	if transfer.FromAccountID < transfer.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(
+			ctx, q, transfer.FromAccountID, -transfer.Amount,
+			transfer.ToAccountID, transfer.Amount,
+		)
+	} else {
 		result.ToAccount, result.FromAccount, err = addMoney(
-			ctx, q, arg.ToAccountID, arg.Amount,
-			arg.FromAccountID, -arg.Amount,
+			ctx, q, transfer.ToAccountID, transfer.Amount,
+			transfer.FromAccountID, -transfer.Amount,
 		)
-		if err != nil {
-			return err
+	}
+	return
+}
+
+/**
+ * Locks both accounts of a transfer and returns them.
+ * Rows are locked in ascending id order to avoid deadlock.
+ */
+func lockAccounts(ctx context.Context, q *Queries, accountID1, accountID2 int64) (
+	account1 Account, account2 Account, err error,
+) {
+	if accountID1 < accountID2 {
+		if account1, err = q.GetAccountForUpdate(ctx, accountID1); err != nil {
+			return
 		}
+		account2, err = q.GetAccountForUpdate(ctx, accountID2)
+		return
+	}
 
-		return nil
+	if account2, err = q.GetAccountForUpdate(ctx, accountID2); err != nil {
+		return
+	}
+	account1, err = q.GetAccountForUpdate(ctx, accountID1)
+	return
+}
+
+/**
+ * Inserts the idempotency key of a request.
+ * If the key already exists, the insert waits for the transaction that owns it
+ * and the stored result is returned with Replayed set to true.
+ */
+func claimIdempotencyKey(ctx context.Context, q *Queries, arg TransferTxParams) (
+	result TransferTxResult, err error,
+) {
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:    arg.Idempotency.Username,
+		Key:         arg.Idempotency.Key,
+		RequestHash: arg.requestHash(),
 	})
+	if err != sql.ErrNoRows {
+		return
+	}
 
-	return result, err
+	return replayIdempotencyKey(ctx, q, arg)
+}
+
+/**
+ * Returns the result of a transfer request that was already executed
+ * under its idempotency key, so callers can answer a retry without
+ * running their own checks again.
+ * sql.ErrNoRows is returned if the key is unused, or its transfer
+ * hasn't committed yet, in which case TransferTx settles it.
+ */
+func (store *SQLStore) GetIdempotentTransfer(ctx context.Context, arg TransferTxParams) (
+	TransferTxResult, error,
+) {
+	if arg.Idempotency == nil {
+		return TransferTxResult{}, sql.ErrNoRows
+	}
+	return replayIdempotencyKey(ctx, store.Queries, arg)
+}
+
+// Loads the stored result of an idempotency key used by the same request.
+func replayIdempotencyKey(ctx context.Context, q *Queries, arg TransferTxParams) (
+	result TransferTxResult, err error,
+) {
+	key, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Idempotency.Username,
+		Key:      arg.Idempotency.Key,
+	})
+	if err != nil {
+		return
+	}
+
+	if key.RequestHash != arg.requestHash() {
+		err = ErrIdempotencyKeyMismatch
+		return
+	}
+
+	if err = json.Unmarshal(key.Response, &result); err != nil {
+		return
+	}
+	result.Replayed = true
+	return
+}
+
+// Stores the result of a request under its idempotency key.
+func saveIdempotencyResponse(
+	ctx context.Context,
+	q *Queries,
+	arg IdempotencyParams,
+	result TransferTxResult,
+) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+		Response: response,
+	})
+	return err
 }
 
+// Adds to the balances of two accounts and notifies their event streams.
 func addMoney(
 	ctx context.Context,
 	q *Queries,
@@ -146,5 +510,6 @@ func addMoney(
 		return
 	}
 
+	err = notifyAccounts(ctx, q, accoundID1, accoundID2)
 	return
 }
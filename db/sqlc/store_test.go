@@ -2,21 +2,35 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
 )
 
+// Creates a random account whose balance is set to the given amount.
+func createFundedAccount(t *testing.T, balance int64) Account {
+	account := createRandomAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
 	// Run n concurrent transfer transactions
 	n := 5
 	amount := int64(10)
 
+	account1 := createFundedAccount(t, int64(n)*amount)
+	account2 := createRandomAccount(t)
+
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 
@@ -59,6 +73,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
+		require.Equal(t, transfer.ID, fromEntry.TransferID.Int64)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -69,6 +84,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toEntry)
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
+		require.Equal(t, transfer.ID, toEntry.TransferID.Int64)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -111,13 +127,13 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
 	// Run n concurrent transfer transactions
 	n := 10
 	amount := int64(10)
 
+	account1 := createFundedAccount(t, int64(n)*amount)
+	account2 := createFundedAccount(t, int64(n)*amount)
+
 	errs := make(chan error)
 
 	for i := 0; i < n; i++ {
@@ -156,3 +172,88 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updateAccount1.Balance)
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 }
+
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, int64(10))
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        int64(10),
+		Idempotency: &IdempotencyParams{
+			Username: account1.Owner,
+			Key:      util.RandomString(16),
+		},
+	}
+
+	_, err := store.GetIdempotentTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	result1, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+
+	// Replay with the same request returns the stored result.
+	result2, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+
+	// It can be looked up before running a transfer again.
+	stored, err := store.GetIdempotentTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, stored.Replayed)
+	require.Equal(t, result1.Transfer.ID, stored.Transfer.ID)
+
+	// Replay with a different request is rejected.
+	arg.Amount = int64(20)
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+	_, err = store.GetIdempotentTransfer(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+
+	// Money moved exactly once.
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-int64(10), updatedAccount1.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := createFundedAccount(t, amount-1)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	var fundsErr *InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, account1.ID, fundsErr.AccountID)
+	require.Equal(t, amount-1, fundsErr.AvailableBalance)
+
+	// The overdraft limit extends the available balance.
+	_, err = testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 1,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), result.FromAccount.Balance)
+}
@@ -7,14 +7,86 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const createFeeTransfer = `-- name: CreateFeeTransfer :one
+INSERT INTO transfers (
+    from_account_id, to_account_id, amount, fee_of
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of
+`
+
+type CreateFeeTransferParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	FeeOf         sql.NullInt64 `json:"fee_of"`
+}
+
+func (q *Queries) CreateFeeTransfer(ctx context.Context, arg CreateFeeTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createFeeTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.FeeOf,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
+	)
+	return i, err
+}
+
+const createReversalTransfer = `-- name: CreateReversalTransfer :one
+INSERT INTO transfers (
+    from_account_id, to_account_id, amount, reversal_of
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of
+`
+
+type CreateReversalTransferParams struct {
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	ReversalOf    sql.NullInt64 `json:"reversal_of"`
+}
+
+func (q *Queries) CreateReversalTransfer(ctx context.Context, arg CreateReversalTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createReversalTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ReversalOf,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id, to_account_id, amount
 ) VALUES (
     $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+) RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of
 `
 
 type CreateTransferParams struct {
@@ -32,6 +104,8 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
 	)
 	return i, err
 }
@@ -47,7 +121,7 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -60,12 +134,54 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
 	)
 	return i, err
 }
 
+const hasTransferBetween = `-- name: HasTransferBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM transfers
+    WHERE from_account_id = $1 AND to_account_id = $2
+)
+`
+
+type HasTransferBetweenParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+}
+
+func (q *Queries) HasTransferBetween(ctx context.Context, arg HasTransferBetweenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasTransferBetween, arg.FromAccountID, arg.ToAccountID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -91,6 +207,160 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ReversalOf,
+			&i.FeeOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOwnerTransfers = `-- name: ListOwnerTransfers :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.reversal_of, t.fee_of, f.currency FROM transfers t
+JOIN accounts f ON f.id = t.from_account_id
+WHERE (
+        ($1::boolean AND t.from_account_id IN (
+            SELECT a.id FROM accounts a
+            WHERE a.owner = $2
+                AND ($3::bigint = 0 OR a.id = $3)
+        ))
+        OR ($4::boolean AND t.to_account_id IN (
+            SELECT a.id FROM accounts a
+            WHERE a.owner = $2
+                AND ($3::bigint = 0 OR a.id = $3)
+        ))
+    )
+    AND t.created_at >= $5
+    AND t.created_at < $6
+ORDER BY t.id
+LIMIT $7
+OFFSET $8
+`
+
+type ListOwnerTransfersParams struct {
+	IncludeOut bool      `json:"include_out"`
+	Owner      string    `json:"owner"`
+	AccountID  int64     `json:"account_id"`
+	IncludeIn  bool      `json:"include_in"`
+	FromTime   time.Time `json:"from_time"`
+	ToTime     time.Time `json:"to_time"`
+	Limit      int32     `json:"limit"`
+	Offset     int32     `json:"offset"`
+}
+
+type ListOwnerTransfersRow struct {
+	ID            int64         `json:"id"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+	ReversalOf    sql.NullInt64 `json:"reversal_of"`
+	FeeOf         sql.NullInt64 `json:"fee_of"`
+	Currency      string        `json:"currency"`
+}
+
+func (q *Queries) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOwnerTransfers,
+		arg.IncludeOut,
+		arg.Owner,
+		arg.AccountID,
+		arg.IncludeIn,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOwnerTransfersRow{}
+	for rows.Next() {
+		var i ListOwnerTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ReversalOf,
+			&i.FeeOf,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferEntryCounts = `-- name: ListTransferEntryCounts :many
+SELECT
+    t.id,
+    t.from_account_id,
+    t.to_account_id,
+    t.amount,
+    COUNT(e.id) FILTER (
+        WHERE e.account_id = t.from_account_id AND e.amount = -t.amount
+    ) AS debit_count,
+    COUNT(e.id) FILTER (
+        WHERE e.account_id = t.to_account_id AND e.amount = t.amount
+    ) AS credit_count,
+    COUNT(e.id) AS entry_count
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+WHERE t.id > $1
+GROUP BY t.id
+ORDER BY t.id
+LIMIT $2
+`
+
+type ListTransferEntryCountsParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+type ListTransferEntryCountsRow struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	DebitCount    int64 `json:"debit_count"`
+	CreditCount   int64 `json:"credit_count"`
+	EntryCount    int64 `json:"entry_count"`
+}
+
+func (q *Queries) ListTransferEntryCounts(ctx context.Context, arg ListTransferEntryCountsParams) ([]ListTransferEntryCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferEntryCounts, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransferEntryCountsRow{}
+	for rows.Next() {
+		var i ListTransferEntryCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.DebitCount,
+			&i.CreditCount,
+			&i.EntryCount,
 		); err != nil {
 			return nil, err
 		}
@@ -105,11 +375,24 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	return items, nil
 }
 
+const sumTransferReversals = `-- name: SumTransferReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE reversal_of = $1
+`
+
+func (q *Queries) SumTransferReversals(ctx context.Context, reversalOf sql.NullInt64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumTransferReversals, reversalOf)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
 SET amount = $2
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, reversal_of, fee_of
 `
 
 type UpdateTransferParams struct {
@@ -126,6 +409,8 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversalOf,
+		&i.FeeOf,
 	)
 	return i, err
 }
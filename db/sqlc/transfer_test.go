@@ -52,6 +52,24 @@ func TestGetTransfer(t *testing.T) {
 	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
 }
 
+func TestHasTransferBetween(t *testing.T) {
+	transfer := createRandomTransfer(t)
+
+	exists, err := testQueries.HasTransferBetween(context.Background(), HasTransferBetweenParams{
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+	})
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = testQueries.HasTransferBetween(context.Background(), HasTransferBetweenParams{
+		FromAccountID: transfer.ToAccountID,
+		ToAccountID:   transfer.FromAccountID,
+	})
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
 func TestUpdateTransfer(t *testing.T) {
 	transfer1 := createRandomTransfer(t)
 
@@ -100,3 +118,87 @@ func TestListTransfer(t *testing.T) {
 		require.NotEmpty(t, transfer)
 	}
 }
+
+func TestListTransferEntryCounts(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ListTransferEntryCounts(context.Background(), ListTransferEntryCountsParams{
+		AfterID:   result.Transfer.ID - 1,
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	require.Equal(t, result.Transfer.ID, rows[0].ID)
+	require.Equal(t, int64(1), rows[0].DebitCount)
+	require.Equal(t, int64(1), rows[0].CreditCount)
+	require.Equal(t, int64(2), rows[0].EntryCount)
+}
+
+func TestListOwnerTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 30)
+	account2 := createFundedAccount(t, 30)
+
+	from := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	to := time.Now().Add(time.Minute)
+
+	arg := ListOwnerTransfersParams{
+		IncludeOut: true,
+		IncludeIn:  true,
+		Owner:      account1.Owner,
+		FromTime:   from,
+		ToTime:     to,
+		Limit:      10,
+		Offset:     0,
+	}
+	transfers, err := testQueries.ListOwnerTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+	currencies := map[int64]string{
+		account1.ID: account1.Currency,
+		account2.ID: account2.Currency,
+	}
+	for _, transfer := range transfers {
+		require.Equal(t, currencies[transfer.FromAccountID], transfer.Currency)
+	}
+
+	arg.IncludeIn = false
+	arg.AccountID = account1.ID
+	transfers, err = testQueries.ListOwnerTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+	}
+
+	arg.ToTime = from
+	transfers, err = testQueries.ListOwnerTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
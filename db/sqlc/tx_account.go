@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Lifecycle of an account. Only active accounts can move money.
+// Frozen accounts can be unfrozen, closed accounts stay closed.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+var (
+	// Returned when money would move to or from a frozen or closed account.
+	ErrAccountNotActive = errors.New("account is not active")
+
+	// Returned when closing an account that still holds money and no sweep account is given.
+	ErrAccountBalanceNotZero = errors.New("account balance must be zero to close it")
+
+	// Returned when closing an account that still has active holds.
+	ErrAccountHasActiveHolds = errors.New("account has active holds")
+)
+
+// Describes an account that can't be used because of its status.
+// It matches ErrAccountNotActive with errors.Is.
+type AccountNotActiveError struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+func (e *AccountNotActiveError) Error() string {
+	return fmt.Sprintf("%v: account [%d] is %s", ErrAccountNotActive, e.AccountID, e.Status)
+}
+
+func (e *AccountNotActiveError) Is(target error) bool {
+	return target == ErrAccountNotActive
+}
+
+// Checks that all accounts are active.
+func checkActive(accounts ...Account) error {
+	for _, account := range accounts {
+		if account.Status != AccountStatusActive {
+			return &AccountNotActiveError{
+				AccountID: account.ID,
+				Status:    account.Status,
+			}
+		}
+	}
+	return nil
+}
+
+// Contains the input parameter of the close account transaction.
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+
+	// Optional. Receives the remaining balance before the account is closed.
+	SweepToAccountID int64 `json:"sweep_to_account_id"`
+}
+
+// The result of the close account transaction.
+type CloseAccountTxResult struct {
+	Account Account `json:"account"`
+
+	// Set if a remaining balance was swept to another account.
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+/**
+ * Closes an active account.
+ * The balance must be zero, unless a sweep account is given,
+ * in which case a positive balance is first transferred to it,
+ * and published as a transfer.created event.
+ * Accounts with active holds or a negative balance can't be closed.
+ */
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (
+	CloseAccountTxResult, error,
+) {
+	var result CloseAccountTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = CloseAccountTxResult{}
+
+		var account, sweepAccount Account
+		var err error
+		if arg.SweepToAccountID > 0 {
+			account, sweepAccount, err = lockAccounts(ctx, q, arg.AccountID, arg.SweepToAccountID)
+			if err != nil {
+				return err
+			}
+			err = checkActive(account, sweepAccount)
+		} else {
+			account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+			if err != nil {
+				return err
+			}
+			err = checkActive(account)
+		}
+		if err != nil {
+			return err
+		}
+
+		if account.HeldBalance > 0 {
+			return ErrAccountHasActiveHolds
+		}
+		if account.Balance < 0 || (account.Balance > 0 && arg.SweepToAccountID == 0) {
+			return ErrAccountBalanceNotZero
+		}
+
+		if account.Balance > 0 {
+			sweep := TransferTxResult{}
+			sweep.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+				FromAccountID: account.ID,
+				ToAccountID:   sweepAccount.ID,
+				Amount:        account.Balance,
+			})
+			if err != nil {
+				return err
+			}
+			if err = postTransfer(ctx, q, &sweep); err != nil {
+				return err
+			}
+			if err = recordTransferCreated(ctx, q, sweep); err != nil {
+				return err
+			}
+			result.Sweep = &sweep
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:         account.ID,
+			Status:     AccountStatusClosed,
+			FromStatus: AccountStatusActive,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createFundedAccount(t, 0)
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID: account.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Nil(t, result.Sweep)
+
+	// A closed account stays closed.
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID: account.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
+
+func TestCloseAccountTxSweep(t *testing.T) {
+	store := NewStore(testDB)
+
+	balance := int64(10)
+	account := createFundedAccount(t, balance)
+
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID: account.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	sweepAccount := createFundedAccount(t, 0)
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        account.ID,
+		SweepToAccountID: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, account.ID, result.Sweep.Transfer.FromAccountID)
+	require.Equal(t, sweepAccount.ID, result.Sweep.Transfer.ToAccountID)
+	require.Equal(t, balance, result.Sweep.Transfer.Amount)
+	require.Equal(t, balance, result.Sweep.ToAccount.Balance)
+}
+
+func TestCloseAccountTxActiveHolds(t *testing.T) {
+	store := NewStore(testDB)
+
+	placed, _ := placeRandomHold(t, 10, time.Now().Add(time.Hour))
+	sweepAccount := createFundedAccount(t, 0)
+
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:        placed.Account.ID,
+		SweepToAccountID: sweepAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountHasActiveHolds)
+}
+
+func TestTransferTxAccountNotActive(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 10)
+	account2 := createRandomAccount(t)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:         account2.ID,
+		Status:     AccountStatusFrozen,
+		FromStatus: AccountStatusActive,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	var notActiveErr *AccountNotActiveError
+	require.ErrorAs(t, err, &notActiveErr)
+	require.Equal(t, account2.ID, notActiveErr.AccountID)
+	require.Equal(t, AccountStatusFrozen, notActiveErr.Status)
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Owner of the system accounts that settle deposits and withdrawals.
+const SystemCashOwner = "system_cash"
+
+// Contains the input parameter of deposit and withdrawal transactions.
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// The result of deposit and withdrawal transactions.
+type CashTxResult struct {
+	Transfer Transfer `json:"transfer"`
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+
+	// The system side of the movement, not exposed to customers.
+	CashAccount Account `json:"-"`
+	CashEntry   Entry   `json:"-"`
+}
+
+/**
+ * Adds money to an account.
+ * It is recorded as a transfer from the system cash account
+ * of the same currency, within a single database transaction,
+ * along with its transfer.created event.
+ */
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (
+	CashTxResult, error,
+) {
+	return store.cashTx(ctx, arg.AccountID, arg.Amount)
+}
+
+/**
+ * Takes money out of an account.
+ * It is recorded as a transfer to the system cash account
+ * of the same currency, within a single database transaction,
+ * along with its transfer.created event.
+ * Withdrawals count against the transfer limits of the account's owner,
+ * a *TransferLimitError is returned if one would be exceeded.
+ * Returns an *InsufficientFundsError if the account can't cover it.
+ */
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (
+	CashTxResult, error,
+) {
+	return store.cashTx(ctx, arg.AccountID, -arg.Amount)
+}
+
+// Posts amount to the account and -amount to its system cash account.
+func (store *SQLStore) cashTx(ctx context.Context, accountID int64, amount int64) (
+	CashTxResult, error,
+) {
+	var result CashTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = CashTxResult{}
+
+		account, err := q.GetAccount(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		cashAccount, err := q.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+			Owner:    SystemCashOwner,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot get %s cash account: %w", account.Currency, err)
+		}
+
+		account, _, err = lockAccounts(ctx, q, account.ID, cashAccount.ID)
+		if err != nil {
+			return err
+		}
+		if err = checkActive(account); err != nil {
+			return err
+		}
+		if amount < 0 {
+			if err = checkTransferLimits(ctx, q, account, -amount, time.Now()); err != nil {
+				return err
+			}
+			if err = checkFunds(account, -amount); err != nil {
+				return err
+			}
+		}
+
+		transferArg := CreateTransferParams{
+			FromAccountID: cashAccount.ID,
+			ToAccountID:   account.ID,
+			Amount:        amount,
+		}
+		if amount < 0 {
+			transferArg = CreateTransferParams{
+				FromAccountID: account.ID,
+				ToAccountID:   cashAccount.ID,
+				Amount:        -amount,
+			}
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, transferArg)
+		if err != nil {
+			return err
+		}
+		transferID := sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  account.ID,
+			Amount:     amount,
+			TransferID: transferID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.CashEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  cashAccount.ID,
+			Amount:     -amount,
+			TransferID: transferID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Update smaller accound id first to avoid deadlock.
+		if account.ID < cashAccount.ID {
+			result.Account, result.CashAccount, err = addMoney(
+				ctx, q, account.ID, amount,
+				cashAccount.ID, -amount,
+			)
+		} else {
+			result.CashAccount, result.Account, err = addMoney(
+				ctx, q, cashAccount.ID, -amount,
+				account.ID, amount,
+			)
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordTransferCreated(ctx, q, result.transferResult())
+	})
+
+	return result, err
+}
+
+// Returns the movement as a transfer between the account and the cash account.
+func (result CashTxResult) transferResult() TransferTxResult {
+	if result.Transfer.FromAccountID == result.Account.ID {
+		return TransferTxResult{
+			Transfer:    result.Transfer,
+			FromAccount: result.Account,
+			ToAccount:   result.CashAccount,
+			FromEntry:   result.Entry,
+			ToEntry:     result.CashEntry,
+		}
+	}
+	return TransferTxResult{
+		Transfer:    result.Transfer,
+		FromAccount: result.CashAccount,
+		ToAccount:   result.Account,
+		FromEntry:   result.CashEntry,
+		ToEntry:     result.Entry,
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getCashAccount(t *testing.T, currency string) Account {
+	cashAccount, err := testQueries.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    SystemCashOwner,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return cashAccount
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	cashAccount := getCashAccount(t, account.Currency)
+	amount := int64(10)
+
+	result, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, cashAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, result.Transfer.ID, result.Entry.TransferID.Int64)
+	require.Equal(t, result.Transfer.ID, result.CashEntry.TransferID.Int64)
+
+	require.Equal(t, account.ID, result.Account.ID)
+	require.Equal(t, account.Balance+amount, result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, amount, result.Entry.Amount)
+
+	// The credit is balanced by the cash account.
+	require.Equal(t, cashAccount.ID, result.CashEntry.AccountID)
+	require.Equal(t, -amount, result.CashEntry.Amount)
+	require.Equal(t, cashAccount.ID, result.CashAccount.ID)
+	require.Equal(t, cashAccount.Balance-amount, result.CashAccount.Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account := createFundedAccount(t, amount)
+	cashAccount := getCashAccount(t, account.Currency)
+
+	result, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Transfer.FromAccountID)
+	require.Equal(t, cashAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Zero(t, result.Account.Balance)
+	require.Equal(t, -amount, result.Entry.Amount)
+	require.Equal(t, cashAccount.ID, result.CashEntry.AccountID)
+	require.Equal(t, amount, result.CashEntry.Amount)
+
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount.Balance)
+}
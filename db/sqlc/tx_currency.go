@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// Owners of the accounts every currency needs before it can be used.
+var systemOwners = []string{SystemCashOwner, SystemFxOwner, SystemFeeOwner}
+
+// Tells whether an account is one of the house accounts of its currency.
+func isSystemOwner(owner string) bool {
+	for _, systemOwner := range systemOwners {
+		if owner == systemOwner {
+			return true
+		}
+	}
+	return false
+}
+
+// The result of the create currency transaction.
+type CreateCurrencyTxResult struct {
+	Currency Currency `json:"currency"`
+
+	// The cash, fx and fee accounts opened for the currency.
+	SystemAccounts []Account `json:"-"`
+}
+
+/**
+ * Adds a currency along with its system accounts.
+ * Deposits, conversions and fees all settle against an account
+ * of the system owners in the same currency,
+ * so they are opened with a zero balance in the same transaction.
+ */
+func (store *SQLStore) CreateCurrencyTx(ctx context.Context, arg CreateCurrencyParams) (
+	CreateCurrencyTxResult, error,
+) {
+	var result CreateCurrencyTxResult
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		result = CreateCurrencyTxResult{}
+
+		var err error
+		result.Currency, err = q.CreateCurrency(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		for _, owner := range systemOwners {
+			account, err := q.CreateAccount(ctx, CreateAccountParams{
+				Owner:    owner,
+				Currency: result.Currency.Code,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot create %s account of %s: %w", owner, arg.Code, err)
+			}
+			result.SystemAccounts = append(result.SystemAccounts, account)
+		}
+		return nil
+	})
+
+	return result, err
+}
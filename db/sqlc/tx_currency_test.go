@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestCreateCurrencyTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	var code string
+	for {
+		code = strings.ToUpper(util.RandomString(3))
+		_, err := testQueries.GetCurrency(context.Background(), code)
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	result, err := store.CreateCurrencyTx(context.Background(), CreateCurrencyParams{
+		Code:     code,
+		Exponent: 2,
+		Symbol:   code[:1],
+		Enabled:  true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, code, result.Currency.Code)
+	require.Len(t, result.SystemAccounts, 3)
+	for _, account := range result.SystemAccounts {
+		require.Equal(t, code, account.Currency)
+		require.Zero(t, account.Balance)
+	}
+
+	// Money can be deposited in the new currency right away.
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: code,
+	})
+	require.NoError(t, err)
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), deposit.Account.Balance)
+	require.Equal(t, getCashAccount(t, code).ID, deposit.CashAccount.ID)
+	require.Equal(t, int64(-10), deposit.CashAccount.Balance)
+
+	_, err = store.CreateCurrencyTx(context.Background(), CreateCurrencyParams{
+		Code:     code,
+		Exponent: 2,
+	})
+	require.Error(t, err)
+}
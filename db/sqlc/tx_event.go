@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+/**
+ * Types of the domain events written to the outbox.
+ * Events are inserted in the transaction of the change they describe,
+ * so they are published if and only if the change is committed.
+ */
+const (
+	EventTransferCreated = "transfer.created"
+	EventAccountCreated  = "account.created"
+	EventUserCreated     = "user.created"
+)
+
+// Every event type, the ones webhook subscriptions can filter on.
+var EventTypes = []string{
+	EventTransferCreated,
+	EventAccountCreated,
+	EventUserCreated,
+}
+
+/**
+ * Channel notified with the id of an account when its balance changes.
+ * Notifications are sent on commit, so listeners only ever see entries
+ * that are there to be read.
+ */
+const AccountEventsChannel = "account_events"
+
+// Lifecycle of a webhook delivery. Pending deliveries are sent until they
+// succeed, or become dead once they run out of attempts.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+// Payload of transfer.created. The amount is in minor units of the currency.
+type TransferCreatedEvent struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// Set if the transfer reverses another one.
+	ReversalOf *int64 `json:"reversal_of,omitempty"`
+	// Set if the transfer is the fee charged for another one.
+	FeeOf     *int64    `json:"fee_of,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Payload of account.created.
+type AccountCreatedEvent struct {
+	Account Account `json:"account"`
+}
+
+// Payload of user.created. The hashed password is left out.
+type UserCreatedEvent struct {
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Tier      string    `json:"tier"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+/**
+ * Writes an event to the outbox within the transaction of q.
+ * usernames are the users the event concerns: their webhook
+ * subscriptions receive it. Duplicates are removed.
+ */
+func recordEvent(ctx context.Context, q *Queries, eventType string, usernames []string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	unique := make([]string, 0, len(usernames))
+	seen := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		if !seen[username] {
+			seen[username] = true
+			unique = append(unique, username)
+		}
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType: eventType,
+		Usernames: unique,
+		Payload:   payload,
+	})
+	return err
+}
+
+// Records transfer.created for the transfer of a result, concerning both owners.
+func recordTransferCreated(ctx context.Context, q *Queries, result TransferTxResult) error {
+	transfer := result.Transfer
+	event := TransferCreatedEvent{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      result.FromAccount.Currency,
+		CreatedAt:     transfer.CreatedAt,
+	}
+	if transfer.ReversalOf.Valid {
+		event.ReversalOf = &transfer.ReversalOf.Int64
+	}
+	if transfer.FeeOf.Valid {
+		event.FeeOf = &transfer.FeeOf.Int64
+	}
+
+	return recordEvent(ctx, q, EventTransferCreated,
+		[]string{result.FromAccount.Owner, result.ToAccount.Owner},
+		event,
+	)
+}
+
+// Creates an account and records account.created in the same transaction.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, q, EventAccountCreated,
+			[]string{account.Owner},
+			AccountCreatedEvent{Account: account},
+		)
+	})
+
+	return account, err
+}
+
+// Creates a user and records user.created in the same transaction.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, q, EventUserCreated,
+			[]string{user.Username},
+			UserCreatedEvent{
+				Username:  user.Username,
+				FullName:  user.FullName,
+				Email:     user.Email,
+				Tier:      user.Tier,
+				CreatedAt: user.CreatedAt,
+			},
+		)
+	})
+
+	return user, err
+}
+
+// Wakes up the event streams of the accounts once the transaction commits.
+func notifyAccounts(ctx context.Context, q *Queries, accountIDs ...int64) error {
+	for _, id := range accountIDs {
+		err := q.Notify(ctx, NotifyParams{
+			Channel: AccountEventsChannel,
+			Payload: strconv.FormatInt(id, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+ * Creates the webhook deliveries of events that weren't dispatched yet,
+ * one per enabled subscription of the users each event concerns,
+ * and returns how many events were dispatched.
+ * Events are locked with SKIP LOCKED, so concurrent dispatchers
+ * work on different events.
+ */
+func (store *SQLStore) DispatchEventsTx(ctx context.Context, limit int32) (int, error) {
+	var dispatched int
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		dispatched = 0
+
+		events, err := q.ListUndispatchedOutboxEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err = q.CreateWebhookDeliveries(ctx, event.ID); err != nil {
+				return err
+			}
+			if err = q.MarkOutboxEventDispatched(ctx, event.ID); err != nil {
+				return err
+			}
+		}
+		dispatched = len(events)
+		return nil
+	})
+
+	return dispatched, err
+}
@@ -0,0 +1,305 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Dispatches every pending event, so the next ones can be told apart.
+func drainOutbox(t *testing.T, store Store) {
+	for {
+		n, err := store.DispatchEventsTx(context.Background(), 1000)
+		require.NoError(t, err)
+		if n == 0 {
+			return
+		}
+	}
+}
+
+// Returns the only event written since the outbox was drained.
+func requireOneEvent(t *testing.T, eventType string) OutboxEvent {
+	events, err := testQueries.ListUndispatchedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, eventType, events[0].EventType)
+	return events[0]
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	drainOutbox(t, store)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	event := requireOneEvent(t, EventUserCreated)
+	require.Equal(t, []string{user.Username}, event.Usernames)
+	require.NotContains(t, string(event.Payload), "hashed_password")
+
+	var payload UserCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, user.Username, payload.Username)
+	require.Equal(t, user.Email, payload.Email)
+}
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	drainOutbox(t, store)
+
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	event := requireOneEvent(t, EventAccountCreated)
+	require.Equal(t, []string{user.Username}, event.Usernames)
+
+	var payload AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, account.ID, payload.Account.ID)
+}
+
+func TestTransferTxEvent(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	drainOutbox(t, store)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	event := requireOneEvent(t, EventTransferCreated)
+	require.Equal(t, []string{account1.Owner, account2.Owner}, event.Usernames)
+
+	var payload TransferCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.ID)
+	require.Equal(t, int64(10), payload.Amount)
+	require.Equal(t, account1.Currency, payload.Currency)
+	require.Nil(t, payload.ReversalOf)
+}
+
+func TestTransferTxFeeEvent(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	createFeeSchedule(t, UpsertFeeScheduleParams{
+		Currency: account1.Currency,
+		FlatFee:  1,
+	})
+	drainOutbox(t, store)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Fee)
+
+	// The transfer first, then its fee.
+	events, err := testQueries.ListUndispatchedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	var payload TransferCreatedEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Transfer.ID, payload.ID)
+	require.Nil(t, payload.FeeOf)
+
+	require.Equal(t, EventTransferCreated, events[1].EventType)
+	require.Equal(t, []string{account1.Owner, SystemFeeOwner}, events[1].Usernames)
+	payload = TransferCreatedEvent{}
+	require.NoError(t, json.Unmarshal(events[1].Payload, &payload))
+	require.Equal(t, result.Fee.Transfer.ID, payload.ID)
+	require.Equal(t, int64(1), payload.Amount)
+	require.Equal(t, &result.Transfer.ID, payload.FeeOf)
+}
+
+func TestCashTxEvent(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	drainOutbox(t, store)
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	event := requireOneEvent(t, EventTransferCreated)
+	require.Equal(t, []string{SystemCashOwner, account.Owner}, event.Usernames)
+
+	var payload TransferCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, deposit.Transfer.ID, payload.ID)
+	require.Equal(t, account.ID, payload.ToAccountID)
+	require.Equal(t, account.Currency, payload.Currency)
+	drainOutbox(t, store)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	event = requireOneEvent(t, EventTransferCreated)
+	require.Equal(t, []string{account.Owner, SystemCashOwner}, event.Usernames)
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, withdrawal.Transfer.ID, payload.ID)
+	require.Equal(t, account.ID, payload.FromAccountID)
+}
+
+func TestConvertTxEvent(t *testing.T) {
+	store := NewStore(testDB)
+	user, usdAccount, eurAccount := createFxAccounts(t, 100)
+	quote := createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute))
+	drainOutbox(t, store)
+
+	result, err := store.ConvertTx(context.Background(), ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: usdAccount.ID,
+		ToAccountID:   eurAccount.ID,
+	})
+	require.NoError(t, err)
+
+	// One event per leg, each in the currency of its accounts.
+	events, err := testQueries.ListUndispatchedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	legs := []TransferTxResult{result.Debit, result.Credit}
+	for i, event := range events {
+		require.Equal(t, EventTransferCreated, event.EventType)
+
+		var payload TransferCreatedEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		require.Equal(t, legs[i].Transfer.ID, payload.ID)
+		require.Equal(t, legs[i].FromAccount.Currency, payload.Currency)
+	}
+}
+
+func TestTransferTxEventRolledBack(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 0)
+	account2 := createRandomAccount(t)
+	drainOutbox(t, store)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	events, err := testQueries.ListUndispatchedOutboxEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestTransferTxNotify(t *testing.T) {
+	// Listen blocks while the listener reconnects, so the test fails
+	// instead of hanging when the database can't be reached.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener := pq.NewListener(dbSource, time.Second, time.Minute, nil)
+	defer listener.Close()
+
+	listening := make(chan error, 1)
+	go func() {
+		listening <- listener.Listen(AccountEventsChannel)
+	}()
+	select {
+	case err := <-listening:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("cannot listen to account events")
+	}
+
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// Other tests may notify their accounts too.
+	notified := make(map[string]bool)
+	for !notified[strconv.FormatInt(account1.ID, 10)] || !notified[strconv.FormatInt(account2.ID, 10)] {
+		select {
+		case n := <-listener.Notify:
+			if n != nil {
+				notified[n.Extra] = true
+			}
+		case <-ctx.Done():
+			t.Fatal("accounts were not notified")
+		}
+	}
+}
+
+func TestDispatchEventsTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	subscription1 := createRandomWebhookSubscription(t, account1.Owner, []string{})
+	subscription2 := createRandomWebhookSubscription(t, account2.Owner, []string{EventTransferCreated})
+	drainOutbox(t, store)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	event := requireOneEvent(t, EventTransferCreated)
+
+	n, err := store.DispatchEventsTx(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	dispatched, err := testQueries.GetOutboxEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.True(t, dispatched.DispatchedAt.Valid)
+
+	for _, subscription := range []WebhookSubscription{subscription1, subscription2} {
+		deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+			SubscriptionID: subscription.ID,
+			Status:         DeliveryStatusPending,
+			Limit:          10,
+		})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, event.ID, deliveries[0].EventID)
+	}
+
+	n, err = store.DispatchEventsTx(context.Background(), 10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Owner of the system accounts that collect transfer fees.
+const SystemFeeOwner = "system_fees"
+
+// Percentages of fee schedules are expressed in basis points, 10000 is 100%.
+const bpsDenominator = 10000
+
+// Returned when the fee, or the amount plus the fee, doesn't fit in an int64.
+var ErrFeeOverflow = errors.New("transfer fee overflows")
+
+// The fee charged for a transfer, posted as a transfer of its own
+// from the sender to the fee revenue account.
+type TransferFee struct {
+	Transfer  Transfer `json:"transfer"`
+	FromEntry Entry    `json:"from_entry"`
+	ToEntry   Entry    `json:"to_entry"`
+}
+
+/**
+ * Returns the fee of the tier for a transfer of amount:
+ * the flat fee plus the percentage of the amount, rounded down.
+ * Flat, percentage and tiered fees are all expressed this way,
+ * a tiered schedule being a set of tiers with different minimum amounts.
+ */
+func (schedule FeeSchedule) Fee(amount int64) (int64, error) {
+	if amount < 0 {
+		return 0, fmt.Errorf("amount must not be negative, got %d", amount)
+	}
+
+	// Split the amount so the multiplication can't overflow.
+	bps := int64(schedule.PercentageBps)
+	percentage := amount/bpsDenominator*bps + amount%bpsDenominator*bps/bpsDenominator
+
+	if percentage > math.MaxInt64-schedule.FlatFee {
+		return 0, ErrFeeOverflow
+	}
+	return schedule.FlatFee + percentage, nil
+}
+
+// Returns the fee of a transfer, zero if no tier of the currency applies.
+func transferFee(ctx context.Context, q *Queries, currency string, amount int64) (int64, error) {
+	schedule, err := q.GetFeeScheduleForAmount(ctx, GetFeeScheduleForAmountParams{
+		Currency: currency,
+		Amount:   amount,
+	})
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return schedule.Fee(amount)
+}
+
+/**
+ * Debits the fee of result.Transfer from the sender and credits it
+ * to the fee revenue account of the same currency.
+ * The sender must already be locked and its funds checked by the caller.
+ * result.FromAccount is updated with the balance after the fee.
+ * The fee is published as a transfer.created event of its own.
+ */
+func chargeFee(ctx context.Context, q *Queries, result *TransferTxResult, fee int64) error {
+	feeAccount, err := q.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+		Owner:    SystemFeeOwner,
+		Currency: result.FromAccount.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot get %s fee account: %w", result.FromAccount.Currency, err)
+	}
+
+	charge := TransferTxResult{}
+	charge.Transfer, err = q.CreateFeeTransfer(ctx, CreateFeeTransferParams{
+		FromAccountID: result.Transfer.FromAccountID,
+		ToAccountID:   feeAccount.ID,
+		Amount:        fee,
+		FeeOf:         sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if err = postTransfer(ctx, q, &charge); err != nil {
+		return err
+	}
+	if err = recordTransferCreated(ctx, q, charge); err != nil {
+		return err
+	}
+
+	result.FromAccount = charge.FromAccount
+	if result.ToAccount.ID == feeAccount.ID {
+		result.ToAccount = charge.ToAccount
+	}
+	result.Fee = &TransferFee{
+		Transfer:  charge.Transfer,
+		FromEntry: charge.FromEntry,
+		ToEntry:   charge.ToEntry,
+	}
+	return nil
+}
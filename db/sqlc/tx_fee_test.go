@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Sets a fee schedule tier for the duration of the test.
+func createFeeSchedule(t *testing.T, arg UpsertFeeScheduleParams) FeeSchedule {
+	schedule, err := testQueries.UpsertFeeSchedule(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Currency, schedule.Currency)
+	require.Equal(t, arg.MinAmount, schedule.MinAmount)
+	require.Equal(t, arg.FlatFee, schedule.FlatFee)
+	require.Equal(t, arg.PercentageBps, schedule.PercentageBps)
+
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteFeeSchedule(context.Background(), schedule.ID)
+		require.NoError(t, err)
+	})
+	return schedule
+}
+
+func TestFeeScheduleFee(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "Flat",
+			schedule: FeeSchedule{FlatFee: 25},
+			amount:   1000,
+			fee:      25,
+		},
+		{
+			name:     "Percentage",
+			schedule: FeeSchedule{PercentageBps: 150},
+			amount:   1000,
+			fee:      15,
+		},
+		{
+			name:     "RoundedDown",
+			schedule: FeeSchedule{PercentageBps: 150},
+			amount:   99,
+			fee:      1,
+		},
+		{
+			name:     "FlatAndPercentage",
+			schedule: FeeSchedule{FlatFee: 30, PercentageBps: 290},
+			amount:   10000,
+			fee:      320,
+		},
+		{
+			name:     "LargeAmount",
+			schedule: FeeSchedule{PercentageBps: 10000},
+			amount:   math.MaxInt64,
+			fee:      math.MaxInt64,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := tc.schedule.Fee(tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+
+	_, err := FeeSchedule{FlatFee: 1, PercentageBps: 10000}.Fee(math.MaxInt64)
+	require.ErrorIs(t, err, ErrFeeOverflow)
+}
+
+func TestGetFeeScheduleForAmount(t *testing.T) {
+	account := createRandomAccount(t)
+	low := createFeeSchedule(t, UpsertFeeScheduleParams{
+		Currency: account.Currency,
+		FlatFee:  5,
+	})
+	high := createFeeSchedule(t, UpsertFeeScheduleParams{
+		Currency:      account.Currency,
+		MinAmount:     1000,
+		PercentageBps: 10,
+	})
+
+	schedule, err := testQueries.GetFeeScheduleForAmount(context.Background(), GetFeeScheduleForAmountParams{
+		Currency: account.Currency,
+		Amount:   999,
+	})
+	require.NoError(t, err)
+	require.Equal(t, low.ID, schedule.ID)
+
+	schedule, err = testQueries.GetFeeScheduleForAmount(context.Background(), GetFeeScheduleForAmountParams{
+		Currency: account.Currency,
+		Amount:   1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, high.ID, schedule.ID)
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(1000)
+	fee := int64(15)
+
+	account1 := createFundedAccount(t, amount+fee)
+	account2 := createRandomAccount(t)
+	createFeeSchedule(t, UpsertFeeScheduleParams{
+		Currency:      account1.Currency,
+		FlatFee:       5,
+		PercentageBps: 100,
+	})
+
+	feeAccount, err := testQueries.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    SystemFeeOwner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, account2.Balance+amount, result.ToAccount.Balance)
+	require.Zero(t, result.FromAccount.Balance)
+
+	require.NotNil(t, result.Fee)
+	require.Equal(t, fee, result.Fee.Transfer.Amount)
+	require.Equal(t, account1.ID, result.Fee.Transfer.FromAccountID)
+	require.Equal(t, feeAccount.ID, result.Fee.Transfer.ToAccountID)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, result.Fee.Transfer.FeeOf)
+	require.Equal(t, -fee, result.Fee.FromEntry.Amount)
+	require.Equal(t, account1.ID, result.Fee.FromEntry.AccountID)
+	require.Equal(t, fee, result.Fee.ToEntry.Amount)
+	require.Equal(t, feeAccount.ID, result.Fee.ToEntry.AccountID)
+
+	updatedFeeAccount, err := testQueries.GetAccount(context.Background(), feeAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, feeAccount.Balance+fee, updatedFeeAccount.Balance)
+
+	// The fee counts against the available balance.
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account1.ID,
+		Balance: amount + fee - 1,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
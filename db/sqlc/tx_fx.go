@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Owner of the house accounts that take the other side of conversions.
+const SystemFxOwner = "system_fx"
+
+var (
+	// Returned when a quote is used after its expiry time.
+	ErrQuoteExpired = errors.New("quote has expired")
+
+	// Returned when a quote was already used by a conversion.
+	ErrQuoteAlreadyUsed = errors.New("quote has already been used")
+
+	// Returned when an account's currency differs from the quoted one.
+	ErrQuoteCurrencyMismatch = errors.New("account currency doesn't match the quote")
+)
+
+// Contains the input parameter of the convert transaction.
+type ConvertTxParams struct {
+	QuoteID       int64 `json:"quote_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+}
+
+// The result of the convert transaction.
+type ConvertTxResult struct {
+	Quote       FxQuote `json:"quote"`
+	FromAccount Account `json:"from_account"`
+	ToAccount   Account `json:"to_account"`
+	FromEntry   Entry   `json:"from_entry"`
+	ToEntry     Entry   `json:"to_entry"`
+
+	// Both legs of the conversion, including the house side
+	// that is not exposed to customers.
+	Debit  TransferTxResult `json:"-"`
+	Credit TransferTxResult `json:"-"`
+}
+
+/**
+ * Exchanges money between two accounts at the rate of a quote.
+ * The quoted amount is moved from the source account to the house account
+ * of the source currency, and the converted amount from the house account
+ * of the target currency to the target account, within a single transaction.
+ * House accounts may go negative, their balance is the bank's position
+ * in that currency. A quote can only be used once, before it expires,
+ * and the quoted amount must stay within the limits of the source account.
+ * Each leg records its own transfer.created event.
+ */
+func (store *SQLStore) ConvertTx(ctx context.Context, arg ConvertTxParams) (
+	ConvertTxResult, error,
+) {
+	var result ConvertTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = ConvertTxResult{}
+
+		// Lock the quote so concurrent conversions with it queue up.
+		quote, err := q.GetFxQuoteForUpdate(ctx, arg.QuoteID)
+		if err != nil {
+			return err
+		}
+		if quote.DebitTransferID.Valid {
+			return ErrQuoteAlreadyUsed
+		}
+		if quote.ExpiresAt.Before(time.Now()) {
+			return ErrQuoteExpired
+		}
+
+		fromHouse, err := getHouseAccount(ctx, q, quote.FromCurrency)
+		if err != nil {
+			return err
+		}
+		toHouse, err := getHouseAccount(ctx, q, quote.ToCurrency)
+		if err != nil {
+			return err
+		}
+
+		accounts, err := lockAccountSet(ctx, q,
+			arg.FromAccountID, arg.ToAccountID, fromHouse.ID, toHouse.ID,
+		)
+		if err != nil {
+			return err
+		}
+		fromAccount, toAccount := accounts[arg.FromAccountID], accounts[arg.ToAccountID]
+		if fromAccount.Currency != quote.FromCurrency || toAccount.Currency != quote.ToCurrency {
+			return ErrQuoteCurrencyMismatch
+		}
+		if err = checkActive(fromAccount, toAccount); err != nil {
+			return err
+		}
+		// The debit leg counts towards the limits like any other transfer.
+		if err = checkTransferLimits(ctx, q, fromAccount, quote.FromAmount, time.Now()); err != nil {
+			return err
+		}
+		if err = checkFunds(fromAccount, quote.FromAmount); err != nil {
+			return err
+		}
+
+		result.Debit.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   fromHouse.ID,
+			Amount:        quote.FromAmount,
+		})
+		if err != nil {
+			return err
+		}
+		if err = postTransfer(ctx, q, &result.Debit); err != nil {
+			return err
+		}
+		if err = recordTransferCreated(ctx, q, result.Debit); err != nil {
+			return err
+		}
+
+		result.Credit.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID: toHouse.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        quote.ToAmount,
+		})
+		if err != nil {
+			return err
+		}
+		if err = postTransfer(ctx, q, &result.Credit); err != nil {
+			return err
+		}
+		if err = recordTransferCreated(ctx, q, result.Credit); err != nil {
+			return err
+		}
+
+		result.FromAccount = result.Debit.FromAccount
+		result.FromEntry = result.Debit.FromEntry
+		result.ToAccount = result.Credit.ToAccount
+		result.ToEntry = result.Credit.ToEntry
+
+		result.Quote, err = q.UpdateFxQuoteTransfers(ctx, UpdateFxQuoteTransfersParams{
+			ID:               quote.ID,
+			DebitTransferID:  sql.NullInt64{Int64: result.Debit.Transfer.ID, Valid: true},
+			CreditTransferID: sql.NullInt64{Int64: result.Credit.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// Returns the house account of a currency.
+func getHouseAccount(ctx context.Context, q *Queries, currency string) (Account, error) {
+	account, err := q.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+		Owner:    SystemFxOwner,
+		Currency: currency,
+	})
+	if err != nil {
+		return account, fmt.Errorf("cannot get %s house account: %w", currency, err)
+	}
+	return account, nil
+}
+
+/**
+ * Locks any number of accounts and returns them by id.
+ * Rows are locked in ascending id order to avoid deadlock.
+ */
+func lockAccountSet(ctx context.Context, q *Queries, accountIDs ...int64) (
+	map[int64]Account, error,
+) {
+	ids := append([]int64(nil), accountIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Creates a user with a funded USD account and an empty EUR account.
+func createFxAccounts(t *testing.T, balance int64) (User, Account, Account) {
+	user := createRandomUser(t)
+
+	usdAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  balance,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	eurAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+
+	return user, usdAccount, eurAccount
+}
+
+func TestConvertTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user, usdAccount, eurAccount := createFxAccounts(t, 100)
+	quote := createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute))
+
+	arg := ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: usdAccount.ID,
+		ToAccountID:   eurAccount.ID,
+	}
+	result, err := store.ConvertTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, usdAccount.ID, result.FromAccount.ID)
+	require.Equal(t, int64(0), result.FromAccount.Balance)
+	require.Equal(t, -quote.FromAmount, result.FromEntry.Amount)
+
+	require.Equal(t, eurAccount.ID, result.ToAccount.ID)
+	require.Equal(t, quote.ToAmount, result.ToAccount.Balance)
+	require.Equal(t, quote.ToAmount, result.ToEntry.Amount)
+
+	// Both legs go through the house accounts.
+	require.Equal(t, SystemFxOwner, result.Debit.ToAccount.Owner)
+	require.Equal(t, util.USD, result.Debit.ToAccount.Currency)
+	require.Equal(t, SystemFxOwner, result.Credit.FromAccount.Owner)
+	require.Equal(t, util.EUR, result.Credit.FromAccount.Currency)
+
+	require.Equal(t, result.Debit.Transfer.ID, result.Quote.DebitTransferID.Int64)
+	require.Equal(t, result.Credit.Transfer.ID, result.Quote.CreditTransferID.Int64)
+
+	_, err = store.ConvertTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteAlreadyUsed)
+}
+
+func TestConvertTxQuoteExpired(t *testing.T) {
+	store := NewStore(testDB)
+
+	user, usdAccount, eurAccount := createFxAccounts(t, 100)
+	quote := createRandomFxQuote(t, user.Username, time.Now().Add(-time.Second))
+
+	_, err := store.ConvertTx(context.Background(), ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: usdAccount.ID,
+		ToAccountID:   eurAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrQuoteExpired)
+}
+
+func TestConvertTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	user, usdAccount, eurAccount := createFxAccounts(t, 99)
+	quote := createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute))
+
+	_, err := store.ConvertTx(context.Background(), ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: usdAccount.ID,
+		ToAccountID:   eurAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.ConvertTx(context.Background(), ConvertTxParams{
+		QuoteID:       quote.ID,
+		FromAccountID: eurAccount.ID,
+		ToAccountID:   usdAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrQuoteCurrencyMismatch)
+}
+
+func TestConvertTxLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	user, usdAccount, eurAccount := createFxAccounts(t, 300)
+	limitAccount(t, usdAccount, UpsertTransferLimitParams{MaxPerDay: 150})
+
+	arg := ConvertTxParams{
+		FromAccountID: usdAccount.ID,
+		ToAccountID:   eurAccount.ID,
+	}
+	arg.QuoteID = createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute)).ID
+	_, err := store.ConvertTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// The first conversion used 100 of the day.
+	arg.QuoteID = createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute)).ID
+	_, err = store.ConvertTx(context.Background(), arg)
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerDay, limitErr.Limit)
+	require.Equal(t, int64(100), limitErr.Used)
+
+	// The quote is left unused.
+	quote, err := testQueries.GetFxQuote(context.Background(), arg.QuoteID)
+	require.NoError(t, err)
+	require.False(t, quote.DebitTransferID.Valid)
+}
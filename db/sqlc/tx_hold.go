@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Lifecycle of a hold. Only active holds count against the available balance.
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+var (
+	// Returned when a hold was already captured, voided or expired.
+	ErrHoldNotActive = errors.New("hold is not active")
+
+	// Returned when an active hold is used after its expiry time.
+	ErrHoldExpired = errors.New("hold has expired")
+
+	// Returned when a capture asks for more than the held amount.
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+)
+
+// Contains the input parameter of the place hold transaction.
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// The result of the place, void and expire hold transactions.
+type HoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// Contains the input parameter of the capture hold transaction.
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+
+	// Amount to capture, zero captures the whole hold.
+	Amount int64 `json:"amount"`
+}
+
+// The result of the capture hold transaction.
+type CaptureHoldTxResult struct {
+	Hold Hold `json:"hold"`
+	TransferTxResult
+}
+
+/**
+ * Reserves money of an account for a later capture.
+ * The held amount reduces the available balance
+ * but no money moves until the hold is captured.
+ * The transfer limits are checked both here and at the capture,
+ * since other transfers may use up the limits in between.
+ * Returns an *InsufficientFundsError if the account can't cover it.
+ */
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (
+	HoldTxResult, error,
+) {
+	var result HoldTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = HoldTxResult{}
+
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if err = checkActive(account); err != nil {
+			return err
+		}
+		if err = checkTransferLimits(ctx, q, account, arg.Amount, time.Now()); err != nil {
+			return err
+		}
+		if err = checkFunds(account, arg.Amount); err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+/**
+ * Turns an active hold into a transfer to its recipient.
+ * The capture may be partial, the rest of the hold is released.
+ * It goes through the same checks as TransferTx: the limits of the payer
+ * at the time of the capture, and its fee, are applied.
+ * The hold row is locked first so concurrent captures and voids
+ * of the same hold are serialized and only one of them succeeds.
+ */
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (
+	CaptureHoldTxResult, error,
+) {
+	var result CaptureHoldTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = CaptureHoldTxResult{}
+
+		hold, err := lockActiveHold(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		if hold.ExpiresAt.Before(time.Now()) {
+			return ErrHoldExpired
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount < 0 {
+			return fmt.Errorf("capture amount must be positive, got %d", amount)
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		_, toAccount, err := lockAccounts(ctx, q, hold.AccountID, hold.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		// Release the whole hold before transferring the captured part.
+		fromAccount, err := q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if err = executeTransfer(ctx, q, fromAccount, toAccount, amount, &result.TransferTxResult); err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:         hold.ID,
+			Status:     HoldStatusCaptured,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// Releases an active hold without moving any money.
+func (store *SQLStore) VoidHoldTx(ctx context.Context, holdID int64) (
+	HoldTxResult, error,
+) {
+	return store.releaseHoldTx(ctx, holdID, HoldStatusVoided)
+}
+
+/**
+ * Releases an active hold that is past its expiry time.
+ * Holds that are not expired yet are left untouched
+ * and ErrHoldNotActive is returned for them.
+ */
+func (store *SQLStore) ExpireHoldTx(ctx context.Context, holdID int64) (
+	HoldTxResult, error,
+) {
+	return store.releaseHoldTx(ctx, holdID, HoldStatusExpired)
+}
+
+// Gives the held amount back to the available balance and closes the hold.
+func (store *SQLStore) releaseHoldTx(ctx context.Context, holdID int64, status string) (
+	HoldTxResult, error,
+) {
+	var result HoldTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = HoldTxResult{}
+
+		hold, err := lockActiveHold(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+		if status == HoldStatusExpired && hold.ExpiresAt.After(time.Now()) {
+			return ErrHoldNotActive
+		}
+
+		if _, err = q.GetAccountForUpdate(ctx, hold.AccountID); err != nil {
+			return err
+		}
+		result.Account, err = q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:     hold.ID,
+			Status: status,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// Locks a hold and checks that it can still be captured or released.
+func lockActiveHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != HoldStatusActive {
+		return hold, ErrHoldNotActive
+	}
+	return hold, nil
+}
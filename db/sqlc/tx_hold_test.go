@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func placeRandomHold(t *testing.T, amount int64, expiresAt time.Time) (HoldTxResult, Account) {
+	store := NewStore(testDB)
+
+	account := createFundedAccount(t, amount)
+	toAccount := createRandomAccount(t)
+
+	result, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		ExpiresAt:   expiresAt,
+	})
+	require.NoError(t, err)
+	return result, toAccount
+}
+
+func TestPlaceHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	expiresAt := time.Now().Add(time.Hour)
+	result, toAccount := placeRandomHold(t, amount, expiresAt)
+
+	hold := result.Hold
+	require.NotZero(t, hold.ID)
+	require.Equal(t, result.Account.ID, hold.AccountID)
+	require.Equal(t, toAccount.ID, hold.ToAccountID)
+	require.Equal(t, amount, hold.Amount)
+	require.Equal(t, HoldStatusActive, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+	require.WithinDuration(t, expiresAt, hold.ExpiresAt, time.Second)
+
+	// No money moves, but none of it is available anymore.
+	require.Equal(t, amount, result.Account.Balance)
+	require.Equal(t, amount, result.Account.HeldBalance)
+	require.Zero(t, result.Account.AvailableBalance())
+
+	_, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   result.Account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      1,
+		ExpiresAt:   expiresAt,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: result.Account.ID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	placed, toAccount := placeRandomHold(t, 10, time.Now().Add(time.Hour))
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: placed.Hold.ID,
+		Amount: 11,
+	})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	// Partial capture releases the rest of the hold.
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: placed.Hold.ID,
+		Amount: 4,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, placed.Account.ID, result.Transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(4), result.Transfer.Amount)
+
+	require.Equal(t, int64(6), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldBalance)
+	require.Equal(t, toAccount.Balance+4, result.ToAccount.Balance)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: placed.Hold.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	_, err = store.VoidHoldTx(context.Background(), placed.Hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestCaptureHoldTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	placed, _ := placeRandomHold(t, 10, time.Now().Add(time.Hour))
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+				HoldID: placed.Hold.ID,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrHoldNotActive)
+	}
+	require.Equal(t, 1, succeeded)
+
+	account, err := testQueries.GetAccount(context.Background(), placed.Account.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+	require.Zero(t, account.HeldBalance)
+}
+
+func TestCaptureHoldTxFee(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(1000)
+	fee := int64(15)
+
+	account := createFundedAccount(t, amount+fee)
+	toAccount := createRandomAccount(t)
+
+	placed, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	createFeeSchedule(t, UpsertFeeScheduleParams{
+		Currency:      account.Currency,
+		FlatFee:       5,
+		PercentageBps: 100,
+	})
+
+	// A capture is charged like any other transfer.
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: placed.Hold.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.NotNil(t, result.Fee)
+	require.Equal(t, fee, result.Fee.Transfer.Amount)
+	require.Equal(t, account.ID, result.Fee.Transfer.FromAccountID)
+
+	require.Zero(t, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldBalance)
+	require.Equal(t, toAccount.Balance+amount, result.ToAccount.Balance)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	placed, _ := placeRandomHold(t, 10, time.Now().Add(time.Hour))
+
+	result, err := store.VoidHoldTx(context.Background(), placed.Hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, result.Hold.Status)
+	require.Equal(t, int64(10), result.Account.Balance)
+	require.Zero(t, result.Account.HeldBalance)
+	require.Equal(t, int64(10), result.Account.AvailableBalance())
+}
+
+func TestExpireHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	active, _ := placeRandomHold(t, 10, time.Now().Add(time.Hour))
+	_, err := store.ExpireHoldTx(context.Background(), active.Hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	stale, _ := placeRandomHold(t, 10, time.Now().Add(-time.Second))
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: stale.Hold.ID,
+	})
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	holds, err := testQueries.ListExpiredHolds(context.Background(), ListExpiredHoldsParams{
+		Now:   time.Now(),
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+	require.Contains(t, holds, stale.Hold)
+	require.NotContains(t, holds, active.Hold)
+
+	result, err := store.ExpireHoldTx(context.Background(), stale.Hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusExpired, result.Hold.Status)
+	require.Zero(t, result.Account.HeldBalance)
+}
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Tier of the users that were not given another one.
+const DefaultUserTier = "standard"
+
+// Names of the transfer limits, as reported by TransferLimitError.
+const (
+	LimitPerTransfer  = "per_transfer"
+	LimitPerDay       = "per_day"
+	LimitPer30Days    = "per_30_days"
+	LimitCountPerHour = "count_per_hour"
+)
+
+// Lengths of the windows of the transfer limits.
+const (
+	limitDayWindow    = 24 * time.Hour
+	limit30DaysWindow = 30 * 24 * time.Hour
+	limitHourWindow   = time.Hour
+)
+
+// Returned when a transfer would exceed a limit of the sender's tier.
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// Describes the limit a transfer would exceed.
+// It matches ErrTransferLimitExceeded with errors.Is.
+type TransferLimitError struct {
+	Limit string `json:"limit"`
+	Max   int64  `json:"max"`
+
+	// Amount, or number of transfers for count limits, already used in the window.
+	Used int64 `json:"used"`
+
+	// When enough of the window is freed for the transfer to go through.
+	// Not set when waiting doesn't help, like for the per transfer limit.
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+func (e *TransferLimitError) Error() string {
+	msg := fmt.Sprintf("%v: %s limit is %d, %d used", ErrTransferLimitExceeded, e.Limit, e.Max, e.Used)
+	if e.ResetsAt != nil {
+		msg += fmt.Sprintf(", resets at %s", e.ResetsAt.Format(time.RFC3339))
+	}
+	return msg
+}
+
+func (e *TransferLimitError) Is(target error) bool {
+	return target == ErrTransferLimitExceeded
+}
+
+/**
+ * Checks that a transfer of amount from the account stays within
+ * the limits of its owner's tier and currency.
+ * Usage is read from the transfers the account already sent, excluding
+ * fees and reversals, so the account must be locked by the caller
+ * for concurrent transfers to see each other.
+ * The daily limit follows UTC calendar days, the others are rolling windows.
+ */
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	limit, err := q.GetAccountTransferLimit(ctx, GetAccountTransferLimitParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if limit.MaxPerTransfer > 0 && amount > limit.MaxPerTransfer {
+		return &TransferLimitError{Limit: LimitPerTransfer, Max: limit.MaxPerTransfer}
+	}
+	if limit.MaxPerDay == 0 && limit.MaxPer30Days == 0 && limit.MaxCountPerHour == 0 {
+		return nil
+	}
+
+	now = now.UTC()
+	dayStart := now.Truncate(limitDayWindow)
+	since := now.Add(-limit30DaysWindow)
+	velocity, err := q.GetTransferVelocity(ctx, GetTransferVelocityParams{
+		DayStart:  dayStart,
+		HourStart: now.Add(-limitHourWindow),
+		AccountID: account.ID,
+		Since:     since,
+	})
+	if err != nil {
+		return err
+	}
+
+	if limit.MaxPerDay > 0 && amount > limit.MaxPerDay-velocity.DayTotal {
+		resetsAt := dayStart.Add(limitDayWindow)
+		return &TransferLimitError{
+			Limit:    LimitPerDay,
+			Max:      limit.MaxPerDay,
+			Used:     velocity.DayTotal,
+			ResetsAt: &resetsAt,
+		}
+	}
+
+	if limit.MaxPer30Days > 0 && amount > limit.MaxPer30Days-velocity.RollingTotal {
+		resetsAt, err := limitResetTime(ctx, q, GetTransferLimitResetTimeParams{
+			AccountID: account.ID,
+			Since:     since,
+			MinTotal:  velocity.RollingTotal + amount - limit.MaxPer30Days,
+		}, limit30DaysWindow)
+		if err != nil {
+			return err
+		}
+		return &TransferLimitError{
+			Limit:    LimitPer30Days,
+			Max:      limit.MaxPer30Days,
+			Used:     velocity.RollingTotal,
+			ResetsAt: resetsAt,
+		}
+	}
+
+	maxCount := int64(limit.MaxCountPerHour)
+	if maxCount > 0 && velocity.HourCount >= maxCount {
+		resetsAt, err := limitResetTime(ctx, q, GetTransferLimitResetTimeParams{
+			AccountID: account.ID,
+			Since:     now.Add(-limitHourWindow),
+			MinCount:  velocity.HourCount - maxCount + 1,
+		}, limitHourWindow)
+		if err != nil {
+			return err
+		}
+		return &TransferLimitError{
+			Limit:    LimitCountPerHour,
+			Max:      maxCount,
+			Used:     velocity.HourCount,
+			ResetsAt: resetsAt,
+		}
+	}
+	return nil
+}
+
+// Returns when the oldest transfers that must leave a rolling window
+// for the limit to allow the transfer are out of it.
+func limitResetTime(
+	ctx context.Context,
+	q *Queries,
+	arg GetTransferLimitResetTimeParams,
+	window time.Duration,
+) (*time.Time, error) {
+	createdAt, err := q.GetTransferLimitResetTime(ctx, arg)
+	if err == sql.ErrNoRows {
+		// The transfer alone exceeds the limit.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resetsAt := createdAt.Add(window).UTC()
+	return &resetsAt, nil
+}
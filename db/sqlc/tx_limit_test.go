@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Moves the owner of the account to a tier of its own with the given limits,
+// so they don't apply to the accounts of other tests.
+func limitAccount(t *testing.T, account Account, arg UpsertTransferLimitParams) {
+	arg.Tier = util.RandomString(12)
+	arg.Currency = account.Currency
+
+	_, err := testQueries.UpsertTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+
+	user, err := testQueries.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Username: account.Owner,
+		Tier:     arg.Tier,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.Tier, user.Tier)
+}
+
+func TestTransferTxLimitPerTransfer(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	limitAccount(t, account1, UpsertTransferLimitParams{MaxPerTransfer: 50})
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        51,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	require.Equal(t, LimitPerTransfer, limitErr.Limit)
+	require.Equal(t, int64(50), limitErr.Max)
+	require.Nil(t, limitErr.ResetsAt)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxLimitPerDay(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	limitAccount(t, account1, UpsertTransferLimitParams{MaxPerDay: 30})
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerDay, limitErr.Limit)
+	require.Equal(t, int64(30), limitErr.Used)
+
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	require.NotNil(t, limitErr.ResetsAt)
+	require.True(t, tomorrow.Equal(*limitErr.ResetsAt))
+}
+
+func TestTransferTxLimitPer30Days(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	limitAccount(t, account1, UpsertTransferLimitParams{MaxPer30Days: 30})
+
+	var results []TransferTxResult
+	for _, amount := range []int64{10, 15} {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	// Only the first transfer needs to leave the window.
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPer30Days, limitErr.Limit)
+	require.Equal(t, int64(25), limitErr.Used)
+	require.NotNil(t, limitErr.ResetsAt)
+	require.WithinDuration(t, results[0].Transfer.CreatedAt.Add(30*24*time.Hour), *limitErr.ResetsAt, time.Millisecond)
+
+	// Waiting doesn't help a transfer above the limit.
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        31,
+	})
+	require.ErrorAs(t, err, &limitErr)
+	require.Nil(t, limitErr.ResetsAt)
+}
+
+func TestTransferTxLimitCountPerHour(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	limitAccount(t, account1, UpsertTransferLimitParams{MaxCountPerHour: 2})
+
+	var first TransferTxResult
+	for i := 0; i < 2; i++ {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        1,
+		})
+		require.NoError(t, err)
+		if i == 0 {
+			first = result
+		}
+	}
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitCountPerHour, limitErr.Limit)
+	require.Equal(t, int64(2), limitErr.Used)
+	require.NotNil(t, limitErr.ResetsAt)
+	require.WithinDuration(t, first.Transfer.CreatedAt.Add(time.Hour), *limitErr.ResetsAt, time.Millisecond)
+}
+
+func TestWithdrawTxLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createFundedAccount(t, 100)
+	limitAccount(t, account, UpsertTransferLimitParams{MaxPerTransfer: 50})
+
+	_, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    51,
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerTransfer, limitErr.Limit)
+
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    50,
+	})
+	require.NoError(t, err)
+
+	// Deposits are not limited.
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    51,
+	})
+	require.NoError(t, err)
+}
+
+func TestHoldTxLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+	limitAccount(t, account1, UpsertTransferLimitParams{MaxPerDay: 30})
+
+	_, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      31,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerDay, limitErr.Limit)
+
+	placed, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      20,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The limit is used up by another transfer before the capture.
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        20,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: placed.Hold.ID,
+	})
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitPerDay, limitErr.Limit)
+	require.Equal(t, int64(20), limitErr.Used)
+}
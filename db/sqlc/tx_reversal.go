@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// Returned when the whole amount of a transfer was already reversed.
+	ErrTransferAlreadyReversed = errors.New("transfer has already been reversed")
+
+	// Returned when a reversal asks for more than what is left to reverse.
+	ErrReversalExceedsTransfer = errors.New("reversal amount exceeds the amount left to reverse")
+
+	// Returned when the transfer to reverse is itself a reversal.
+	ErrReversalNotReversible = errors.New("a reversal can't be reversed")
+
+	// Returned when a recipient tries to give back money that came from a house account,
+	// or a fee. Only an operator can undo those.
+	ErrReversalNotAllowed = errors.New("transfers from house accounts and fees can only be reversed by an operator")
+)
+
+// Contains the input parameter of the reverse transfer transaction.
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+
+	// Amount to give back, zero reverses whatever is left of the transfer.
+	Amount int64 `json:"amount"`
+
+	// Set when the recipient gives the money back, rather than an operator.
+	// The reversal is then a payment of the recipient, bound by their limits.
+	ByRecipient bool `json:"by_recipient"`
+}
+
+/**
+ * Undoes a transfer, fully or partially.
+ * It creates a compensating transfer in the opposite direction
+ * that references the original one through reversal_of.
+ * A transfer can be reversed several times, as long as the reversals
+ * add up to at most its original amount, and the account that received it
+ * must have enough funds to give it back.
+ * Recipients can't reverse deposits, conversions or fees themselves.
+ * The result describes the compensating transfer,
+ * which is published as a transfer.created event.
+ */
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (
+	TransferTxResult, error,
+) {
+	var result TransferTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		result = TransferTxResult{}
+
+		// Lock the original transfer so concurrent reversals queue up.
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf.Valid {
+			return ErrReversalNotReversible
+		}
+
+		reversalOf := sql.NullInt64{Int64: original.ID, Valid: true}
+		reversed, err := q.SumTransferReversals(ctx, reversalOf)
+		if err != nil {
+			return err
+		}
+		left := original.Amount - reversed
+		if left <= 0 {
+			return ErrTransferAlreadyReversed
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = left
+		}
+		if amount < 0 {
+			return fmt.Errorf("reversal amount must be positive, got %d", amount)
+		}
+		if amount > left {
+			return ErrReversalExceedsTransfer
+		}
+
+		fromAccount, toAccount, err := lockAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if err = checkActive(fromAccount, toAccount); err != nil {
+			return err
+		}
+		if arg.ByRecipient {
+			// Giving back a deposit or a conversion would be a withdrawal
+			// that skips the checks of one.
+			if original.FeeOf.Valid || isSystemOwner(toAccount.Owner) {
+				return ErrReversalNotAllowed
+			}
+			if err = checkTransferLimits(ctx, q, fromAccount, amount, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err = checkFunds(fromAccount, amount); err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.CreateReversalTransfer(ctx, CreateReversalTransferParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ReversalOf:    reversalOf,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = postTransfer(ctx, q, &result); err != nil {
+			return err
+		}
+		return recordTransferCreated(ctx, q, result)
+	})
+
+	return result, err
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createReversibleTransfer(t *testing.T, amount int64) (Transfer, Account, Account) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, amount)
+	account2 := createFundedAccount(t, 0)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	return result.Transfer, result.FromAccount, result.ToAccount
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	original, account1, account2 := createReversibleTransfer(t, amount)
+
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+	})
+	require.NoError(t, err)
+
+	reversal := result.Transfer
+	require.Equal(t, account2.ID, reversal.FromAccountID)
+	require.Equal(t, account1.ID, reversal.ToAccountID)
+	require.Equal(t, amount, reversal.Amount)
+	require.True(t, reversal.ReversalOf.Valid)
+	require.Equal(t, original.ID, reversal.ReversalOf.Int64)
+
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, amount, result.ToEntry.Amount)
+	require.Equal(t, reversal.ID, result.FromEntry.TransferID.Int64)
+	require.Equal(t, reversal.ID, result.ToEntry.TransferID.Int64)
+
+	require.Equal(t, account2.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account1.Balance+amount, result.ToAccount.Balance)
+
+	// Nothing is left to reverse.
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+
+	// A reversal can't be reversed.
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: reversal.ID,
+	})
+	require.ErrorIs(t, err, ErrReversalNotReversible)
+}
+
+func TestReverseTransferTxPartial(t *testing.T) {
+	store := NewStore(testDB)
+
+	original, account1, account2 := createReversibleTransfer(t, 10)
+
+	_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+		Amount:     original.Amount + 1,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+		Amount:     4,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), result.Transfer.Amount)
+	require.Equal(t, account2.Balance-4, result.FromAccount.Balance)
+	require.Equal(t, account1.Balance+4, result.ToAccount.Balance)
+
+	// Later reversals are limited to what is left.
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+		Amount:     7,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), result.Transfer.Amount)
+	require.Equal(t, account2.Balance-10, result.FromAccount.Balance)
+	require.Equal(t, account1.Balance+10, result.ToAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+		Amount:     1,
+	})
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+}
+
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	original, _, account2 := createReversibleTransfer(t, 10)
+
+	// The recipient already spent the money.
+	_, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account2.ID,
+		Balance: 0,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestReverseTransferTxByRecipient(t *testing.T) {
+	store := NewStore(testDB)
+
+	original, account1, account2 := createReversibleTransfer(t, 10)
+	limitAccount(t, account2, UpsertTransferLimitParams{MaxPerTransfer: 4})
+
+	// The recipient pays the money back, within their limits.
+	_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID:  original.ID,
+		ByRecipient: true,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID:  original.ID,
+		Amount:      4,
+		ByRecipient: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance+4, result.ToAccount.Balance)
+
+	// An operator isn't bound by them.
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance-10, result.FromAccount.Balance)
+}
+
+func TestReverseTransferTxFromHouseAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	// Giving a deposit back would be a withdrawal.
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID:  deposit.Transfer.ID,
+		ByRecipient: true,
+	})
+	require.ErrorIs(t, err, ErrReversalNotAllowed)
+
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: deposit.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Transfer.Amount)
+}
+
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	original, _, _ := createReversibleTransfer(t, 10)
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: original.ID,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+	}
+	require.Equal(t, 1, succeeded)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Returned when the session was rotated, or blocked, since it was read.
+// Its refresh token is being reused, so the family should be revoked.
+var ErrSessionRotated = errors.New("session was already rotated")
+
+// Contains the input parameter of the rotate session transaction.
+type RotateSessionTxParams struct {
+	SessionID uuid.UUID `json:"session_id"`
+
+	// Session of the new refresh token. It joins the family of the rotated one.
+	NewSession CreateSessionParams `json:"new_session"`
+}
+
+/**
+ * Exchanges a session for a new one in the same family.
+ * The old session is marked as rotated, so its refresh token can't
+ * be renewed again. Of concurrent renewals of the same session,
+ * only the first succeeds, the others return ErrSessionRotated.
+ */
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		rotated, err := q.RotateSession(ctx, RotateSessionParams{
+			ID:        arg.SessionID,
+			RotatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionRotated
+		}
+		if err != nil {
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = rotated.FamilyID
+		session, err = q.CreateSession(ctx, newSession)
+		return err
+	})
+
+	return session, err
+}
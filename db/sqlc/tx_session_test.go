@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func rotateSessionTxParams(session Session) RotateSessionTxParams {
+	return RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     session.Username,
+			RefreshToken: util.RandomString(32),
+			UserAgent:    session.UserAgent,
+			ClientIp:     session.ClientIp,
+			ExpiresAt:    session.ExpiresAt,
+		},
+	}
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+
+	arg := rotateSessionTxParams(session1)
+	session2, err := store.RotateSessionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.NewSession.ID, session2.ID)
+	require.Equal(t, session1.FamilyID, session2.FamilyID)
+	require.Equal(t, arg.NewSession.RefreshToken, session2.RefreshToken)
+	require.False(t, session2.RotatedAt.Valid)
+
+	session1, err = testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session1.RotatedAt.Valid)
+	require.WithinDuration(t, time.Now(), session1.RotatedAt.Time, time.Second)
+
+	// Only the latest session of the family is listed.
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session2.ID, sessions[0].ID)
+	require.WithinDuration(t, session1.CreatedAt, sessions[0].CreatedAt, 0)
+
+	// A rotated session can't be rotated again.
+	_, err = store.RotateSessionTx(context.Background(), rotateSessionTxParams(session1))
+	require.ErrorIs(t, err, ErrSessionRotated)
+
+	// Nor can a blocked one.
+	_, err = testQueries.BlockSessionFamily(context.Background(), session2.FamilyID)
+	require.NoError(t, err)
+	_, err = store.RotateSessionTx(context.Background(), rotateSessionTxParams(session2))
+	require.ErrorIs(t, err, ErrSessionRotated)
+}
+
+func TestRotateSessionTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	session := createRandomSession(t, createRandomUser(t))
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.RotateSessionTx(context.Background(), rotateSessionTxParams(session))
+			errs <- err
+		}()
+	}
+
+	// Only one of the renewals with the same refresh token succeeds.
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrSessionRotated)
+	}
+	require.Equal(t, 1, succeeded)
+}
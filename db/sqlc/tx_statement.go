@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// Contains the input parameter of the statement transaction.
+type StatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`     // Inclusive.
+	To        time.Time `json:"to"`       // Exclusive.
+	AfterID   int64     `json:"after_id"` // Cursor: id of the last entry of the previous page.
+	PageSize  int32     `json:"page_size"`
+}
+
+// An entry with the balance of its account right after it was posted.
+type StatementEntry struct {
+	Entry
+	RunningBalance int64 `json:"running_balance"`
+}
+
+// The result of the statement transaction.
+type StatementTxResult struct {
+	OpeningBalance int64            `json:"opening_balance"`
+	ClosingBalance int64            `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+/**
+ * Reads a page of the entries of an account posted within [From, To),
+ * ordered by id, together with the balance of the account at From and at To.
+ * Balances are computed from entries, in a single read-only snapshot.
+ */
+func (store *SQLStore) StatementTx(ctx context.Context, arg StatementTxParams) (
+	StatementTxResult, error,
+) {
+	var result StatementTxResult
+
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		var err error
+		result = StatementTxResult{}
+
+		result.OpeningBalance, err = q.SumAccountEntries(ctx, SumAccountEntriesParams{
+			AccountID: arg.AccountID,
+			FromTime:  time.Time{},
+			ToTime:    arg.From,
+			MaxID:     math.MaxInt64,
+		})
+		if err != nil {
+			return err
+		}
+
+		windowTotal, err := q.SumAccountEntries(ctx, SumAccountEntriesParams{
+			AccountID: arg.AccountID,
+			FromTime:  arg.From,
+			ToTime:    arg.To,
+			MaxID:     math.MaxInt64,
+		})
+		if err != nil {
+			return err
+		}
+		result.ClosingBalance = result.OpeningBalance + windowTotal
+
+		// Balance right before the first entry of the page.
+		previousPagesTotal, err := q.SumAccountEntries(ctx, SumAccountEntriesParams{
+			AccountID: arg.AccountID,
+			FromTime:  arg.From,
+			ToTime:    arg.To,
+			MaxID:     arg.AfterID,
+		})
+		if err != nil {
+			return err
+		}
+		balance := result.OpeningBalance + previousPagesTotal
+
+		entries, err := q.ListAccountEntries(ctx, ListAccountEntriesParams{
+			AccountID: arg.AccountID,
+			FromTime:  arg.From,
+			ToTime:    arg.To,
+			AfterID:   arg.AfterID,
+			PageSize:  arg.PageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Entries = make([]StatementEntry, len(entries))
+		for i, entry := range entries {
+			balance += entry.Amount
+			result.Entries[i] = StatementEntry{
+				Entry:          entry,
+				RunningBalance: balance,
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
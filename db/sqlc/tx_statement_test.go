@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	amounts := []int64{10, 20, 30}
+	for _, amount := range amounts {
+		_, err := store.DepositTx(context.Background(), CashTxParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		})
+		require.NoError(t, err)
+	}
+
+	from := time.Time{}
+	to := time.Now().Add(time.Hour)
+
+	// First page.
+	page1, err := store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        to,
+		PageSize:  2,
+	})
+	require.NoError(t, err)
+	require.Zero(t, page1.OpeningBalance)
+	require.Equal(t, int64(60), page1.ClosingBalance)
+	require.Len(t, page1.Entries, 2)
+	require.Equal(t, int64(10), page1.Entries[0].RunningBalance)
+	require.Equal(t, int64(30), page1.Entries[1].RunningBalance)
+
+	// The running balance continues on the next page.
+	page2, err := store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        to,
+		AfterID:   page1.Entries[1].ID,
+		PageSize:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page2.Entries, 1)
+	require.Equal(t, int64(60), page2.Entries[0].RunningBalance)
+
+	// A window after every entry has no entries and a constant balance.
+	empty, err := store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		From:      to,
+		To:        to.Add(time.Hour),
+		PageSize:  2,
+	})
+	require.NoError(t, err)
+	require.Empty(t, empty.Entries)
+	require.Equal(t, int64(60), empty.OpeningBalance)
+	require.Equal(t, int64(60), empty.ClosingBalance)
+}
@@ -30,6 +30,8 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Email, user.Email)
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, DefaultUserTier, user.Tier)
+	require.Equal(t, util.CustomerRole, user.Role)
 
 	return user
 }
@@ -51,3 +53,44 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	user2, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user1.Username,
+		Role:     util.SupportRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, util.SupportRole, user2.Role)
+
+	// Unknown roles are rejected by the database.
+	_, err = testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user1.Username,
+		Role:     "root",
+	})
+	require.Error(t, err)
+}
+
+func TestListUsers(t *testing.T) {
+	user := createRandomUser(t)
+	_, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     util.AdminRole,
+	})
+	require.NoError(t, err)
+
+	users, err := testQueries.ListUsers(context.Background(), ListUsersParams{
+		Role:  util.AdminRole,
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+
+	usernames := make([]string, len(users))
+	for i, listed := range users {
+		require.Equal(t, util.AdminRole, listed.Role)
+		usernames[i] = listed.Username
+	}
+	require.Contains(t, usernames, user.Username)
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func createRandomWebhookSubscription(t *testing.T, owner string, eventTypes []string) WebhookSubscription {
+	arg := CreateWebhookSubscriptionParams{
+		Owner:      owner,
+		Url:        "https://example.com/" + util.RandomString(6),
+		Secret:     util.RandomString(32),
+		EventTypes: eventTypes,
+	}
+
+	subscription, err := testQueries.CreateWebhookSubscription(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, subscription.ID)
+	require.Equal(t, arg.Owner, subscription.Owner)
+	require.Equal(t, arg.Url, subscription.Url)
+	require.Equal(t, arg.Secret, subscription.Secret)
+	require.ElementsMatch(t, arg.EventTypes, subscription.EventTypes)
+	require.True(t, subscription.Enabled)
+	require.NotZero(t, subscription.CreatedAt)
+	return subscription
+}
+
+// Creates a pending delivery of a new event to a new subscription.
+func createRandomWebhookDelivery(t *testing.T) WebhookDelivery {
+	user := createRandomUser(t)
+	subscription := createRandomWebhookSubscription(t, user.Username, []string{})
+
+	event, err := testQueries.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		EventType: EventUserCreated,
+		Usernames: []string{user.Username},
+		Payload:   json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.CreateWebhookDeliveries(context.Background(), event.ID)
+	require.NoError(t, err)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	delivery := deliveries[0]
+	require.Equal(t, event.ID, delivery.EventID)
+	require.Equal(t, DeliveryStatusPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+	require.False(t, delivery.LockedUntil.Valid)
+	return delivery
+}
+
+// Claims a delivery that is due.
+func claimDueWebhookDelivery(t *testing.T, id int64) WebhookDelivery {
+	now := time.Now()
+	delivery, err := testQueries.ClaimWebhookDelivery(context.Background(), ClaimWebhookDeliveryParams{
+		ID:          id,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, delivery.LockedUntil.Valid)
+	return delivery
+}
+
+func TestUpdateWebhookSubscription(t *testing.T) {
+	user := createRandomUser(t)
+	subscription := createRandomWebhookSubscription(t, user.Username, []string{EventTransferCreated})
+
+	arg := UpdateWebhookSubscriptionParams{
+		ID:         subscription.ID,
+		Url:        "https://example.com/updated",
+		EventTypes: []string{},
+		Enabled:    false,
+	}
+	updated, err := testQueries.UpdateWebhookSubscription(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Url, updated.Url)
+	require.Empty(t, updated.EventTypes)
+	require.False(t, updated.Enabled)
+	require.Equal(t, subscription.Secret, updated.Secret)
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomWebhookSubscription(t, user.Username, []string{})
+	}
+	createRandomWebhookSubscription(t, createRandomUser(t).Username, []string{})
+
+	subscriptions, err := testQueries.ListWebhookSubscriptions(context.Background(), ListWebhookSubscriptionsParams{
+		Owner: user.Username,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 3)
+	for _, subscription := range subscriptions {
+		require.Equal(t, user.Username, subscription.Owner)
+	}
+}
+
+func TestDeleteWebhookSubscription(t *testing.T) {
+	delivery := createRandomWebhookDelivery(t)
+
+	err := testQueries.DeleteWebhookSubscription(context.Background(), delivery.SubscriptionID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetWebhookSubscription(context.Background(), delivery.SubscriptionID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Deliveries go along with their subscription.
+	_, err = testQueries.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateWebhookDeliveriesFilters(t *testing.T) {
+	user := createRandomUser(t)
+	all := createRandomWebhookSubscription(t, user.Username, []string{})
+	matching := createRandomWebhookSubscription(t, user.Username, []string{EventAccountCreated, EventTransferCreated})
+	other := createRandomWebhookSubscription(t, user.Username, []string{EventUserCreated})
+	disabled := createRandomWebhookSubscription(t, user.Username, []string{})
+	_, err := testQueries.UpdateWebhookSubscription(context.Background(), UpdateWebhookSubscriptionParams{
+		ID:         disabled.ID,
+		Url:        disabled.Url,
+		EventTypes: disabled.EventTypes,
+		Enabled:    false,
+	})
+	require.NoError(t, err)
+	stranger := createRandomWebhookSubscription(t, createRandomUser(t).Username, []string{})
+
+	event, err := testQueries.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		EventType: EventTransferCreated,
+		Usernames: []string{user.Username},
+		Payload:   json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+
+	// Fanning out twice creates each delivery once.
+	for i := 0; i < 2; i++ {
+		err = testQueries.CreateWebhookDeliveries(context.Background(), event.ID)
+		require.NoError(t, err)
+	}
+
+	expected := map[int64]int{all.ID: 1, matching.ID: 1, other.ID: 0, disabled.ID: 0, stranger.ID: 0}
+	for subscriptionID, count := range expected {
+		deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+			SubscriptionID: subscriptionID,
+			Limit:          10,
+		})
+		require.NoError(t, err)
+		require.Len(t, deliveries, count)
+	}
+}
+
+func TestClaimWebhookDelivery(t *testing.T) {
+	delivery := createRandomWebhookDelivery(t)
+	claimDueWebhookDelivery(t, delivery.ID)
+
+	// Still leased to the first dispatcher.
+	now := time.Now()
+	_, err := testQueries.ClaimWebhookDelivery(context.Background(), ClaimWebhookDeliveryParams{
+		ID:          delivery.ID,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	due, err := testQueries.ListDueWebhookDeliveries(context.Background(), ListDueWebhookDeliveriesParams{
+		Now:   now,
+		Limit: 1000,
+	})
+	require.NoError(t, err)
+	for _, d := range due {
+		require.NotEqual(t, delivery.ID, d.ID)
+	}
+}
+
+func TestRecordWebhookDeliverySuccess(t *testing.T) {
+	delivery := claimDueWebhookDelivery(t, createRandomWebhookDelivery(t).ID)
+
+	delivered, err := testQueries.RecordWebhookDeliverySuccess(context.Background(), RecordWebhookDeliverySuccessParams{
+		ID:             delivery.ID,
+		LockedUntil:    delivery.LockedUntil,
+		ResponseStatus: sql.NullInt32{Int32: 200, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusDelivered, delivered.Status)
+	require.Equal(t, int32(1), delivered.Attempts)
+	require.Equal(t, int32(200), delivered.ResponseStatus.Int32)
+	require.True(t, delivered.DeliveredAt.Valid)
+	require.False(t, delivered.LockedUntil.Valid)
+}
+
+func TestRecordWebhookDeliveryFailure(t *testing.T) {
+	delivery := claimDueWebhookDelivery(t, createRandomWebhookDelivery(t).ID)
+
+	arg := RecordWebhookDeliveryFailureParams{
+		ID:             delivery.ID,
+		LockedUntil:    delivery.LockedUntil,
+		Status:         DeliveryStatusDead,
+		NextAttemptAt:  time.Now(),
+		LastError:      sql.NullString{String: "endpoint responded with status 500", Valid: true},
+		ResponseStatus: sql.NullInt32{Int32: 500, Valid: true},
+	}
+	failed, err := testQueries.RecordWebhookDeliveryFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusDead, failed.Status)
+	require.Equal(t, int32(1), failed.Attempts)
+	require.Equal(t, arg.LastError, failed.LastError)
+	require.Equal(t, arg.ResponseStatus, failed.ResponseStatus)
+	require.False(t, failed.LockedUntil.Valid)
+
+	// The lease is over, nothing more is recorded.
+	_, err = testQueries.RecordWebhookDeliveryFailure(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	redelivered, err := testQueries.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, DeliveryStatusPending, redelivered.Status)
+	require.Zero(t, redelivered.Attempts)
+	require.False(t, redelivered.LastError.Valid)
+	require.WithinDuration(t, time.Now(), redelivered.NextAttemptAt, time.Second)
+}
+
+func TestRedeliverWebhookDeliveryLocked(t *testing.T) {
+	delivery := claimDueWebhookDelivery(t, createRandomWebhookDelivery(t).ID)
+
+	_, err := testQueries.RedeliverWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -8,7 +8,9 @@ CREATE TABLE "users" (
   "full_name" varchar NOT NULL,
   "email" varchar UNIQUE NOT NULL,
   "password_changed_at" timestamp NOT NULL DEFAULT (0001-01-01 00:00:00+00Z),
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "tier" varchar NOT NULL DEFAULT 'standard',
+  "role" varchar NOT NULL DEFAULT 'customer'
 );
 
 CREATE TABLE "accounts" (
@@ -16,14 +18,19 @@ CREATE TABLE "accounts" (
   "owner" varchar NOT NULL,
   "balance" bigint NOT NULL,
   "currency" varchar NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "overdraft_limit" bigint NOT NULL DEFAULT 0,
+  "held_balance" bigint NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "frozen_by" varchar
 );
 
 CREATE TABLE "entries" (
   "id" bigserial PRIMARY KEY,
   "account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "transfer_id" bigint
 );
 
 CREATE TABLE "transfers" (
@@ -31,7 +38,9 @@ CREATE TABLE "transfers" (
   "from_account_id" bigint NOT NULL,
   "to_account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "reversal_of" bigint,
+  "fee_of" bigint
 );
 
 CREATE TABLE "sessions" (
@@ -42,7 +51,153 @@ CREATE TABLE "sessions" (
   "client_ip" varchar NOT NULL,
   "is_blocked" boolean NOT NULL,
   "expires_at" timestamptz NOT NULL,
-  "creeated_at" timestamptz NOT NULL
+  "creeated_at" timestamptz NOT NULL,
+  "family_id" uuid NOT NULL,
+  "rotated_at" timestamptz
+);
+
+CREATE TABLE "idempotency_keys" (
+  "username" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response" jsonb NOT NULL DEFAULT ('{}'),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "key")
+);
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "fx_rates" (
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("from_currency", "to_currency")
+);
+
+CREATE TABLE "fx_quotes" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric NOT NULL,
+  "from_amount" bigint NOT NULL,
+  "to_amount" bigint NOT NULL,
+  "debit_transfer_id" bigint,
+  "credit_transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "recurrence" varchar NOT NULL DEFAULT 'once',
+  "day_of_month" integer NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "next_run_at" timestamptz NOT NULL,
+  "run_count" integer NOT NULL DEFAULT 0,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "retry_at" timestamptz,
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "last_transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "risk_decision_id" bigint
+);
+
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percentage_bps" integer NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "max_per_transfer" bigint NOT NULL DEFAULT 0,
+  "max_per_day" bigint NOT NULL DEFAULT 0,
+  "max_per_30_days" bigint NOT NULL DEFAULT 0,
+  "max_count_per_hour" integer NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency")
+);
+
+CREATE TABLE "risk_decisions" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "session_id" uuid,
+  "client_ip" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "matches" jsonb NOT NULL DEFAULT ('[]'),
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" integer NOT NULL,
+  "symbol" varchar NOT NULL DEFAULT '',
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "usernames" varchar[] NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "dispatched_at" timestamptz
+);
+
+CREATE TABLE "webhook_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT ('{}'),
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "event_id" bigint NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  "last_error" varchar,
+  "response_status" integer,
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
 );
 
 CREATE INDEX ON "accounts" ("owner");
@@ -51,22 +206,208 @@ CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
 
 CREATE INDEX ON "entries" ("account_id");
 
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
 CREATE INDEX ON "transfers" ("from_account_id");
 
 CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("to_account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("reversal_of");
+
+CREATE UNIQUE INDEX ON "transfers" ("fee_of");
+
+CREATE INDEX ON "sessions" ("username");
+
+CREATE INDEX ON "sessions" ("expires_at");
+
+CREATE INDEX ON "sessions" ("family_id");
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+CREATE INDEX ON "fx_quotes" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "next_run_at");
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "min_amount");
+
+CREATE INDEX ON "risk_decisions" ("username");
+
+CREATE INDEX ON "risk_decisions" ("action", "created_at");
+
+CREATE INDEX ON "outbox_events" ("dispatched_at", "id");
+
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("event_id", "subscription_id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "next_attempt_at");
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id", "id");
+
+COMMENT ON COLUMN "users"."tier" IS 'selects the transfer limits of the user';
+
+COMMENT ON COLUMN "users"."role" IS 'customer, support or admin';
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below zero the balance may go';
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'sum of the active holds on the account';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
+
+COMMENT ON COLUMN "accounts"."frozen_by" IS 'username of who froze the account, only set while it is frozen';
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that posted the entry';
+
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'transfer reversed by this one';
+
+COMMENT ON COLUMN "transfers"."fee_of" IS 'transfer charged by this fee';
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'ID of the session opened at login, shared by every rotation of its refresh token';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'when the refresh token was exchanged for a new one, presenting it again revokes the family';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'active, captured, voided or expired';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer that captured the hold';
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'units of to_currency bought by one unit of from_currency';
+
+COMMENT ON COLUMN "fx_quotes"."rate" IS 'rate locked in when the quote was created';
+
+COMMENT ON COLUMN "fx_quotes"."debit_transfer_id" IS 'transfer from the customer to the house account';
+
+COMMENT ON COLUMN "fx_quotes"."credit_transfer_id" IS 'transfer from the house account to the customer';
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."recurrence" IS 'once, weekly or monthly';
+
+COMMENT ON COLUMN "scheduled_transfers"."day_of_month" IS 'day of monthly runs, clamped to the length of the month';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active, completed, failed or cancelled';
+
+COMMENT ON COLUMN "scheduled_transfers"."run_count" IS 'successful runs, part of the idempotency key of the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts of the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."retry_at" IS 'earliest time of the next attempt after a failure';
+
+COMMENT ON COLUMN "scheduled_transfers"."locked_until" IS 'lease of the worker executing the next run';
+
+COMMENT ON COLUMN "scheduled_transfers"."last_transfer_id" IS 'transfer made by the last successful run';
+
+COMMENT ON COLUMN "scheduled_transfers"."risk_decision_id" IS 'assessment of the last create or edit, linked to the transfer of the next run';
+
+COMMENT ON COLUMN "fee_schedules"."min_amount" IS 'smallest transfer amount the tier applies to';
+
+COMMENT ON COLUMN "fee_schedules"."percentage_bps" IS 'percentage of the amount in basis points, added to the flat fee';
+
+COMMENT ON COLUMN "transfer_limits"."max_per_transfer" IS 'zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_per_day" IS 'total sent per UTC calendar day, zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_per_30_days" IS 'total sent over the last 30 days, zero means no limit';
+
+COMMENT ON COLUMN "transfer_limits"."max_count_per_hour" IS 'transfers sent over the last hour, zero means no limit';
+
+COMMENT ON COLUMN "risk_decisions"."session_id" IS 'session the transfer was requested from, if known';
+
+COMMENT ON COLUMN "risk_decisions"."action" IS 'allow, deny or flag';
+
+COMMENT ON COLUMN "risk_decisions"."matches" IS 'rules that matched, with their action and reason';
+
+COMMENT ON COLUMN "risk_decisions"."transfer_id" IS 'transfer made after the decision, if any';
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'decimal places of the minor unit amounts are expressed in';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'disabled currencies are rejected by new requests';
+
+COMMENT ON COLUMN "outbox_events"."usernames" IS 'users the event concerns, whose subscriptions receive it';
+
+COMMENT ON COLUMN "outbox_events"."dispatched_at" IS 'when deliveries were created for the subscriptions';
+
+COMMENT ON COLUMN "webhook_subscriptions"."secret" IS 'key of the HMAC-SHA256 signature of deliveries';
+
+COMMENT ON COLUMN "webhook_subscriptions"."event_types" IS 'empty means every event type';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, delivered or dead';
+
+COMMENT ON COLUMN "webhook_deliveries"."locked_until" IS 'lease of the dispatcher sending the delivery';
+
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status of the last attempt, if a response was received';
+
 ALTER TABLE "accounts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
 
 ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
 
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
 ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
 
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_of") REFERENCES "transfers" ("id");
+
 ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("debit_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("credit_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("last_transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("risk_decision_id") REFERENCES "risk_decisions" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_decisions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "webhook_subscriptions" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "outbox_events" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE;
@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Exchange rate between two currencies, as found in a rates file.
+type Rate struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	Rate         string `json:"rate"`
+}
+
+/**
+ * Reads exchange rates from a JSON file holding a list of Rate.
+ * Every rate is validated, so either all of them or none are returned.
+ */
+func LoadRates(path string) ([]Rate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	for i, rate := range rates {
+		if err := rate.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rate #%d in %s: %w", i+1, path, err)
+		}
+	}
+	return rates, nil
+}
+
+// Checks that both currencies are supported and differ, and that the rate is valid.
+func (rate Rate) Validate() error {
+	for _, currency := range []string{rate.FromCurrency, rate.ToCurrency} {
+		if !util.IsSupportedCurrency(currency) {
+			return fmt.Errorf("unsupported currency %q", currency)
+		}
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		return fmt.Errorf("%s can't be exchanged with itself", rate.FromCurrency)
+	}
+
+	_, err := ParseRate(rate.Rate)
+	return err
+}
@@ -0,0 +1,25 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestLoadRates(t *testing.T) {
+	rates, err := LoadRates("testdata/rates.json")
+	require.NoError(t, err)
+	require.Len(t, rates, 3)
+	require.Equal(t, Rate{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.92",
+	}, rates[0])
+
+	_, err = LoadRates("testdata/invalid_rates.json")
+	require.ErrorContains(t, err, "invalid rate #2")
+
+	_, err = LoadRates("testdata/missing.json")
+	require.Error(t, err)
+}
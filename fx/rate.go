@@ -0,0 +1,82 @@
+// Package fx converts amounts between currencies.
+package fx
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Decimal places kept when a rate is derived from another one.
+const rateScale = 10
+
+var (
+	// Returned when a rate is not a positive decimal number.
+	ErrInvalidRate = errors.New("rate must be a positive decimal number")
+
+	// Returned when a converted amount doesn't fit in an int64.
+	ErrAmountOverflow = errors.New("converted amount is too large")
+)
+
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Parses a rate written as a plain decimal number, like "1.0825".
+func ParseRate(rate string) (*big.Rat, error) {
+	if !decimalPattern.MatchString(rate) {
+		return nil, ErrInvalidRate
+	}
+
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return r, nil
+}
+
+/**
+ * Converts an amount with the given rate.
+ * Rates are quoted between major units, like dollars to yen,
+ * while amounts are in minor units, so the result is rescaled
+ * from the exponent of the source currency to the target's one.
+ * The result is rounded down, so a conversion never
+ * pays out more than the rate allows.
+ */
+func Convert(amount int64, rate string, fromExponent, toExponent int32) (int64, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return 0, err
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r)
+	if fromExponent != toExponent {
+		shift := int64(toExponent - fromExponent)
+		if shift < 0 {
+			shift = -shift
+		}
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(shift), nil))
+		if toExponent > fromExponent {
+			converted.Mul(converted, scale)
+		} else {
+			converted.Quo(converted, scale)
+		}
+	}
+
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return result.Int64(), nil
+}
+
+// Returns the rate of the opposite direction, rounded to rateScale decimals.
+func Invert(rate string) (string, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return "", err
+	}
+
+	inverted := new(big.Rat).Inv(r).FloatString(rateScale)
+	inverted = strings.TrimRight(inverted, "0")
+	return strings.TrimSuffix(inverted, "."), nil
+}
@@ -0,0 +1,79 @@
+package fx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	for _, rate := range []string{"1", "0.92", "1.0825"} {
+		r, err := ParseRate(rate)
+		require.NoError(t, err)
+		require.Positive(t, r.Sign())
+	}
+
+	for _, rate := range []string{"", "0", "0.0", "-1", "1/3", "1e3", ".5", "abc"} {
+		_, err := ParseRate(rate)
+		require.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		amount int64
+		rate   string
+		result int64
+	}{
+		{amount: 100, rate: "1", result: 100},
+		{amount: 100, rate: "0.92", result: 92},
+		{amount: 1000, rate: "1.0825", result: 1082},
+		{amount: 1, rate: "0.5", result: 0},
+	}
+
+	for _, tc := range testCases {
+		result, err := Convert(tc.amount, tc.rate, 2, 2)
+		require.NoError(t, err)
+		require.Equal(t, tc.result, result)
+	}
+
+	_, err := Convert(math.MaxInt64, "2", 2, 2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = Convert(math.MaxInt64/10, "1", 0, 2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = Convert(100, "0", 2, 2)
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestConvertExponents(t *testing.T) {
+	// 10.00 USD at 151.25 yen per dollar.
+	yen, err := Convert(1000, "151.25", 2, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1512), yen)
+
+	// 1513 yen back at the inverse rate, rounded down to the cent.
+	cents, err := Convert(1513, "0.0066115702", 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), cents)
+
+	// 1.000 BHD, which has three decimals, to USD.
+	cents, err = Convert(1000, "2.65", 3, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(265), cents)
+}
+
+func TestInvert(t *testing.T) {
+	rate, err := Invert("0.8")
+	require.NoError(t, err)
+	require.Equal(t, "1.25", rate)
+
+	rate, err = Invert("3")
+	require.NoError(t, err)
+	require.Equal(t, "0.3333333333", rate)
+
+	_, err = Invert("0")
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
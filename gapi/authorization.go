@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// Methods that can be called without an access token,
+// the ones outside of authMiddleware in the HTTP server.
+var publicMethods = map[string]bool{
+	pb.UserService_CreateUser_FullMethodName:          true,
+	pb.UserService_LoginUser_FullMethodName:           true,
+	pb.SessionService_RenewAccessToken_FullMethodName: true,
+}
+
+type authorizationPayloadKey struct{}
+
+// AuthInterceptor checks the bearer token of every call to a method
+// that isn't public, like authMiddleware does for HTTP routes.
+func authInterceptor(tokenMaker token.Maker, public map[string]bool) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if public[info.FullMethod] || strings.HasPrefix(info.FullMethod, "/grpc.reflection.") {
+			return handler(ctx, req)
+		}
+
+		payload, err := authorizeUser(ctx, tokenMaker)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(context.WithValue(ctx, authorizationPayloadKey{}, payload), req)
+	}
+}
+
+func authorizeUser(ctx context.Context, tokenMaker token.Maker) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return nil, fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	return tokenMaker.VerifyToken(fields[1])
+}
+
+// Returns the payload of the access token of the call.
+// Only set for methods that aren't public.
+func authPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authorizationPayloadKey{}).(*token.Payload)
+}
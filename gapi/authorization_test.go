@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	testCases := []struct {
+		name         string
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkCode    codes.Code
+	}{
+		{
+			name: "OK",
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, time.Minute)
+			},
+			checkCode: codes.OK,
+		},
+		{
+			name: "NoAuthorization",
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "UnsupportedAuthorization",
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				accessToken, _, err := tokenMaker.CreateToken(account.Owner, util.CustomerRole, uuid.Nil, time.Minute)
+				require.NoError(t, err)
+				return metadata.AppendToOutgoingContext(context.Background(),
+					authorizationHeaderKey, fmt.Sprintf("unsupported %s", accessToken),
+				)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(),
+					authorizationHeaderKey, authorizationTypeBearer,
+				)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+		{
+			name: "ExpiredToken",
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, -time.Minute)
+			},
+			checkCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			times := 0
+			if tc.checkCode == codes.OK {
+				times = 1
+			}
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+				Times(times).
+				Return(account, nil)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			rsp, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: account.ID})
+			if tc.checkCode == codes.OK {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, rsp.GetId())
+				return
+			}
+			requireStatusCode(t, tc.checkCode, err)
+		})
+	}
+}
+
+func TestAuthInterceptorPublicMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Reaching the store shows the call went through without a token.
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store)
+	client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+	_, err := client.LoginUser(context.Background(), &pb.LoginUserRequest{
+		Username: util.RandomOwner(),
+		Password: util.RandomString(6),
+	})
+	requireStatusCode(t, codes.NotFound, err)
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"database/sql"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+		Tier:              user.Tier,
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		HeldBalance:      account.HeldBalance,
+		Status:           account.Status,
+		AvailableBalance: account.AvailableBalance(),
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:         entry.ID,
+		AccountId:  entry.AccountID,
+		Amount:     entry.Amount,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+		TransferId: convertNullInt64(entry.TransferID),
+	}
+}
+
+// The currency is the one of both accounts of the transfer.
+func convertTransfer(transfer db.Transfer, currency string) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      currency,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+		ReversalOf:    convertNullInt64(transfer.ReversalOf),
+		FeeOf:         convertNullInt64(transfer.FeeOf),
+	}
+}
+
+func convertTransferResult(result db.TransferTxResult, currency string) *pb.TransferResult {
+	rsp := &pb.TransferResult{
+		Transfer:    convertTransfer(result.Transfer, currency),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+	if result.Fee != nil {
+		rsp.Fee = &pb.TransferFee{
+			Transfer:  convertTransfer(result.Fee.Transfer, currency),
+			FromEntry: convertEntry(result.Fee.FromEntry),
+			ToEntry:   convertEntry(result.Fee.ToEntry),
+		}
+	}
+	return rsp
+}
+
+func convertNullInt64(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
@@ -0,0 +1,102 @@
+package gapi
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain of the ErrorInfo details.
+const errorDomain = "simplebank"
+
+func fieldViolation(field string, err error) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: err.Error(),
+	}
+}
+
+// Returns InvalidArgument with the violations as BadRequest details.
+func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) error {
+	badRequest := &errdetails.BadRequest{FieldViolations: violations}
+	statusInvalid := status.New(codes.InvalidArgument, "invalid parameters")
+
+	statusDetails, err := statusInvalid.WithDetails(badRequest)
+	if err != nil {
+		return statusInvalid.Err()
+	}
+	return statusDetails.Err()
+}
+
+// Returns a status with an ErrorInfo detail, so clients can tell errors apart
+// without parsing the message.
+func errorInfoError(code codes.Code, err error, reason string, metadata map[string]string) error {
+	st := status.New(code, err.Error())
+
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: metadata,
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// Looked up records that don't exist are NotFound, other errors are Internal.
+func lookupError(err error) error {
+	if err == sql.ErrNoRows {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+/**
+ * Converts the errors of the ledger transactions.
+ * Business rules are FailedPrecondition, like the 409 and 422 of the HTTP server.
+ * Insufficient funds and transfer limits carry their numbers as ErrorInfo metadata.
+ */
+func ledgerError(err error) error {
+	var fundsErr *db.InsufficientFundsError
+	if errors.As(err, &fundsErr) {
+		return errorInfoError(codes.FailedPrecondition, err, "INSUFFICIENT_FUNDS", map[string]string{
+			"available_balance": strconv.FormatInt(fundsErr.AvailableBalance, 10),
+		})
+	}
+
+	var limitErr *db.TransferLimitError
+	if errors.As(err, &limitErr) {
+		metadata := map[string]string{
+			"limit": limitErr.Limit,
+			"max":   strconv.FormatInt(limitErr.Max, 10),
+			"used":  strconv.FormatInt(limitErr.Used, 10),
+		}
+		if limitErr.ResetsAt != nil {
+			metadata["resets_at"] = limitErr.ResetsAt.Format(time.RFC3339)
+		}
+		return errorInfoError(codes.FailedPrecondition, err, "TRANSFER_LIMIT_EXCEEDED", metadata)
+	}
+
+	switch {
+	case errors.Is(err, db.ErrTransferAlreadyReversed):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, db.ErrReversalNotAllowed):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, db.ErrAccountNotActive),
+		errors.Is(err, db.ErrIdempotencyKeyMismatch),
+		errors.Is(err, db.ErrFeeOverflow),
+		errors.Is(err, db.ErrReversalExceedsTransfer),
+		errors.Is(err, db.ErrReversalNotReversible),
+		errors.Is(err, db.ErrAccountBalanceNotZero),
+		errors.Is(err, db.ErrAccountHasActiveHolds):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,115 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+// Serves the server on an in-memory listener and returns a connection to it.
+// Both are closed when the test ends.
+func dialTestServer(t *testing.T, server *Server) *grpc.ClientConn {
+	listener := bufconn.Listen(bufSize)
+	grpcServer := server.newGRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(
+		context.Background(),
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// Returns a context whose calls carry an access token of the user.
+func newContextWithBearerToken(
+	t *testing.T,
+	tokenMaker token.Maker,
+	username string,
+	duration time.Duration,
+) context.Context {
+	accessToken, payload, err := tokenMaker.CreateToken(username, util.CustomerRole, uuid.Nil, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)
+	md := metadata.MD{
+		authorizationHeaderKey: []string{authorizationHeader},
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func requireStatusCode(t *testing.T, code codes.Code, err error) {
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code(), st.Message())
+}
+
+// Requires an InvalidArgument error with a violation of the field.
+func requireFieldViolation(t *testing.T, field string, err error) {
+	requireStatusCode(t, codes.InvalidArgument, err)
+
+	st, _ := status.FromError(err)
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				if violation.GetField() == field {
+					return
+				}
+			}
+		}
+	}
+	t.Fatalf("no violation of field %s in %v", field, st.Details())
+}
+
+// Requires an error with an ErrorInfo detail of the reason.
+func requireErrorReason(t *testing.T, code codes.Code, reason string, err error) *errdetails.ErrorInfo {
+	requireStatusCode(t, code, err)
+
+	st, _ := status.FromError(err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			require.Equal(t, reason, info.GetReason())
+			return info
+		}
+	}
+	t.Fatalf("no error info in %v", st.Details())
+	return nil
+}
@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	userAgentHeader     = "user-agent"
+	xForwardedForHeader = "x-forwarded-for"
+)
+
+// Where a call comes from, recorded with sessions and risk decisions.
+type callMetadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func extractMetadata(ctx context.Context) callMetadata {
+	mtdt := callMetadata{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+		// The first address is the one of the client, the others are proxies.
+		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
+			mtdt.ClientIP = strings.TrimSpace(strings.Split(clientIPs[0], ",")[0])
+		}
+	}
+
+	if mtdt.ClientIP == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			mtdt.ClientIP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(mtdt.ClientIP); err == nil {
+				mtdt.ClientIP = host
+			}
+		}
+	}
+	return mtdt
+}
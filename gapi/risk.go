@@ -0,0 +1,108 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Returned to the user when the risk rules deny a transfer.
+var errTransferDenied = errors.New("transfer denied by risk rules")
+
+/**
+ * Evaluates the risk rules on a transfer and records the decision,
+ * like the HTTP server does.
+ * Returns nil when no rule is configured.
+ */
+func (server *Server) assessTransfer(
+	ctx context.Context,
+	authPayload *token.Payload,
+	req *pb.CreateTransferRequest,
+) (*db.RiskDecision, error) {
+	if server.riskEngine.Empty() {
+		return nil, nil
+	}
+
+	known, err := server.store.HasTransferBetween(ctx, db.HasTransferBetweenParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	transfer := risk.Transfer{
+		Username:      authPayload.Username,
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+		NewRecipient:  !known,
+		ClientIP:      extractMetadata(ctx).ClientIP,
+		Time:          time.Now(),
+	}
+
+	// Tokens issued before sessions were tracked in them have no session.
+	sessionID := uuid.NullUUID{UUID: authPayload.SessionID, Valid: authPayload.SessionID != uuid.Nil}
+	if sessionID.Valid {
+		session, err := server.loginSession(ctx, sessionID.UUID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if err == nil {
+			transfer.Session = &risk.Session{
+				ClientIP:  session.ClientIp,
+				CreatedAt: session.CreatedAt,
+			}
+		}
+	}
+
+	decision := server.riskEngine.Evaluate(transfer)
+	matches, err := json.Marshal(decision.Matches)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	record, err := server.store.CreateRiskDecision(ctx, db.CreateRiskDecisionParams{
+		Username:      transfer.Username,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		Currency:      transfer.Currency,
+		SessionID:     sessionID,
+		ClientIp:      transfer.ClientIP,
+		Action:        string(decision.Action),
+		Matches:       matches,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &record, nil
+}
+
+// The reasons are only shown to analysts, through the recorded decision.
+func transferDeniedError(decision *db.RiskDecision) error {
+	return errorInfoError(codes.PermissionDenied, errTransferDenied, "TRANSFER_DENIED", map[string]string{
+		"risk_decision_id": strconv.FormatInt(decision.ID, 10),
+	})
+}
+
+// Loads the first session of the login, the one the session rules look at.
+func (server *Server) loginSession(ctx context.Context, sessionID uuid.UUID) (db.Session, error) {
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil || session.FamilyID == session.ID {
+		return session, err
+	}
+	return server.store.GetSession(ctx, session.FamilyID)
+}
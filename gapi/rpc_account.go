@@ -0,0 +1,364 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.Account, error) {
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("currency", err),
+		})
+	}
+
+	authPayload := authPayload(ctx)
+	if err := server.checkUser(ctx, authPayload.Username); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:    authPayload.Username,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Error(codes.AlreadyExists, err.Error())
+			case "foreign_key_violation":
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			}
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return convertAccount(account), nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", err),
+		})
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return convertAccount(account), nil
+}
+
+func (server *Server) ListAccounts(
+	ctx context.Context,
+	req *pb.ListAccountsRequest,
+) (*pb.ListAccountsResponse, error) {
+	if violations := validatePage(req.GetPageId(), req.GetPageSize()); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload := authPayload(ctx)
+	if err := server.checkUser(ctx, authPayload.Username); err != nil {
+		return nil, err
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:  authPayload.Username,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	rsp := &pb.ListAccountsResponse{
+		Accounts: make([]*pb.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		rsp.Accounts[i] = convertAccount(account)
+	}
+	return rsp, nil
+}
+
+func (server *Server) ListAccountEntries(
+	ctx context.Context,
+	req *pb.ListAccountEntriesRequest,
+) (*pb.ListAccountEntriesResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := validateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	if req.GetCursor() < 0 {
+		violations = append(violations, fieldViolation("cursor", errors.New("must not be negative")))
+	}
+	if err := validatePageSize(req.GetPageSize(), 5, 100); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	from, to := timeRange(req.GetFrom(), req.GetTo())
+	if !from.Before(to) {
+		violations = append(violations, fieldViolation("from", errors.New("must be before to")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.StatementTxParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        to,
+		AfterID:   req.GetCursor(),
+		PageSize:  req.GetPageSize(),
+	}
+
+	statement, err := server.store.StatementTx(ctx, arg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	rsp := &pb.ListAccountEntriesResponse{
+		AccountId:      account.ID,
+		From:           timestamppb.New(arg.From),
+		To:             timestamppb.New(arg.To),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        make([]*pb.StatementEntry, len(statement.Entries)),
+	}
+	for i, entry := range statement.Entries {
+		rsp.Entries[i] = &pb.StatementEntry{
+			Entry:          convertEntry(entry.Entry),
+			RunningBalance: entry.RunningBalance,
+		}
+	}
+	if len(statement.Entries) == int(arg.PageSize) {
+		rsp.NextCursor = statement.Entries[len(statement.Entries)-1].ID
+	}
+	return rsp, nil
+}
+
+func (server *Server) Deposit(ctx context.Context, req *pb.CashRequest) (*pb.CashResponse, error) {
+	return server.postCash(ctx, req, server.store.DepositTx)
+}
+
+func (server *Server) Withdraw(ctx context.Context, req *pb.CashRequest) (*pb.CashResponse, error) {
+	return server.postCash(ctx, req, server.store.WithdrawTx)
+}
+
+// Runs a deposit or withdrawal transaction on an account of the current user.
+func (server *Server) postCash(
+	ctx context.Context,
+	req *pb.CashRequest,
+	cashTx func(context.Context, db.CashTxParams) (db.CashTxResult, error),
+) (*pb.CashResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := validateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	if err := validateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if err := server.checkUser(ctx, authPayload(ctx).Username); err != nil {
+		return nil, err
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    req.GetAmount(),
+	})
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	rsp := &pb.CashResponse{
+		Transfer: convertTransfer(result.Transfer, account.Currency),
+		Account:  convertAccount(result.Account),
+		Entry:    convertEntry(result.Entry),
+	}
+	return rsp, nil
+}
+
+func (server *Server) FreezeAccount(ctx context.Context, req *pb.AccountStatusRequest) (*pb.Account, error) {
+	return server.changeAccountStatus(ctx, req, db.AccountStatusActive, db.AccountStatusFrozen)
+}
+
+func (server *Server) UnfreezeAccount(ctx context.Context, req *pb.AccountStatusRequest) (*pb.Account, error) {
+	return server.changeAccountStatus(ctx, req, db.AccountStatusFrozen, db.AccountStatusActive)
+}
+
+/**
+ * Moves an account of the current user from one status to another.
+ * Freezing records the user, and accounts frozen by an operator
+ * can't be unfrozen by their owner.
+ */
+func (server *Server) changeAccountStatus(
+	ctx context.Context,
+	req *pb.AccountStatusRequest,
+	from string,
+	to string,
+) (*pb.Account, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", err),
+		})
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != from {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"account [%d] is %s, expected %s", account.ID, account.Status, from,
+		)
+	}
+	if account.FrozenBy.Valid && account.FrozenBy.String != account.Owner {
+		return nil, status.Errorf(codes.PermissionDenied, "account [%d] was frozen by an operator", account.ID)
+	}
+
+	arg := db.UpdateAccountStatusParams{
+		ID:           account.ID,
+		Status:       to,
+		FromStatus:   from,
+		FromFrozenBy: account.FrozenBy,
+	}
+	if to == db.AccountStatusFrozen {
+		arg.FrozenBy = sql.NullString{String: account.Owner, Valid: true}
+	}
+
+	account, err = server.store.UpdateAccountStatus(ctx, arg)
+	if err != nil {
+		// The status was changed by a concurrent call.
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.Aborted, "account [%d] was changed by another call", req.GetId())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return convertAccount(account), nil
+}
+
+func (server *Server) CloseAccount(
+	ctx context.Context,
+	req *pb.CloseAccountRequest,
+) (*pb.CloseAccountResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := validateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	if req.GetSweepToAccountId() < 0 {
+		violations = append(violations, fieldViolation("sweep_to_account_id", errors.New("must not be negative")))
+	} else if req.GetSweepToAccountId() == req.GetId() {
+		violations = append(violations, fieldViolation("sweep_to_account_id", errors.New("cannot sweep an account into itself")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.ownedAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	// The remaining balance can only go to another account of the same user.
+	if req.GetSweepToAccountId() > 0 {
+		sweepAccount, err := server.ownedAccount(ctx, req.GetSweepToAccountId())
+		if err != nil {
+			return nil, err
+		}
+		if sweepAccount.Currency != account.Currency {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"account [%d] currency mismatch: %s vs %s",
+				sweepAccount.ID, sweepAccount.Currency, account.Currency,
+			)
+		}
+	}
+
+	result, err := server.store.CloseAccountTx(ctx, db.CloseAccountTxParams{
+		AccountID:        account.ID,
+		SweepToAccountID: req.GetSweepToAccountId(),
+	})
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	rsp := &pb.CloseAccountResponse{
+		Account: convertAccount(result.Account),
+	}
+	if result.Sweep != nil {
+		rsp.Sweep = convertTransferResult(*result.Sweep, account.Currency)
+	}
+	return rsp, nil
+}
+
+// Loads an account and checks that it belongs to the current user.
+func (server *Server) ownedAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, lookupError(err)
+	}
+
+	if account.Owner != authPayload(ctx).Username {
+		return account, status.Errorf(codes.PermissionDenied,
+			"account [%d] doesn't belong to the authenticated user", account.ID,
+		)
+	}
+	return account, nil
+}
+
+// Tokens outlive users, so calls that create records check the user still exists.
+func (server *Server) checkUser(ctx context.Context, username string) error {
+	if _, err := server.store.GetUser(ctx, username); err != nil {
+		return status.Errorf(codes.Unauthenticated, "user with %v as username doesn't exists", username)
+	}
+	return nil
+}
+
+func validatePage(pageID int32, pageSize int32) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validatePageID(pageID); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+	if err := validatePageSize(pageSize, 5, 10); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+	return violations
+}
+
+// Fills in the bounds of an optional time range, like the HTTP queries:
+// from defaults to the zero time and to defaults to now.
+func timeRange(from *timestamppb.Timestamp, to *timestamppb.Timestamp) (time.Time, time.Time) {
+	var fromTime time.Time
+	if from != nil {
+		fromTime = from.AsTime()
+	}
+	toTime := time.Now()
+	if to != nil {
+		toTime = to.AsTime()
+	}
+	return fromTime, toTime
+}
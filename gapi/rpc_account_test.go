@@ -0,0 +1,606 @@
+package gapi
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc/codes"
+)
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+		Status:   db.AccountStatusActive,
+	}
+}
+
+func requireAccount(t *testing.T, want db.Account, got *pb.Account) {
+	require.Equal(t, want.ID, got.GetId())
+	require.Equal(t, want.Owner, got.GetOwner())
+	require.Equal(t, want.Balance, got.GetBalance())
+	require.Equal(t, want.Currency, got.GetCurrency())
+	require.Equal(t, want.Status, got.GetStatus())
+	require.Equal(t, want.AvailableBalance(), got.GetAvailableBalance())
+}
+
+func TestGetAccountRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		accountID     int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.Account, err error)
+	}{
+		{
+			name:      "OK",
+			username:  user.Username,
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				require.NoError(t, err)
+				requireAccount(t, account, rsp)
+			},
+		},
+		{
+			name:      "PermissionDenied",
+			username:  "unauthorized_user",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name:      "NotFound",
+			username:  user.Username,
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.NotFound, err)
+			},
+		},
+		{
+			name:      "InternalError",
+			username:  user.Username,
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.Internal, err)
+			},
+		},
+		{
+			name:      "InvalidID",
+			username:  user.Username,
+			accountID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireFieldViolation(t, "id", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute)
+			rsp, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: tc.accountID})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestListAccountsRPC(t *testing.T) {
+	user, _ := randomUser(t)
+
+	n := 5
+	accounts := make([]db.Account, n)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: int32(n)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(db.ListAccountsParams{
+						Owner:  user.Username,
+						Limit:  int32(n),
+						Offset: 0,
+					})).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, rsp.GetAccounts(), n)
+				for i, account := range accounts {
+					requireAccount(t, account, rsp.GetAccounts()[i])
+				}
+			},
+		},
+		{
+			name: "UserNotFound",
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: int32(n)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				requireFieldViolation(t, "page_size", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.ListAccounts(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestWithdrawRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CashResponse, err error)
+	}{
+		{
+			name:   "OK",
+			amount: amount,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				withdrawn := account
+				withdrawn.Balance -= amount
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Eq(db.CashTxParams{
+						AccountID: account.ID,
+						Amount:    amount,
+					})).
+					Times(1).
+					Return(db.CashTxResult{
+						Transfer: db.Transfer{ID: 1, FromAccountID: account.ID, Amount: amount},
+						Account:  withdrawn,
+						Entry:    db.Entry{ID: 1, AccountID: account.ID, Amount: -amount},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.Balance-amount, rsp.GetAccount().GetBalance())
+				require.Equal(t, -amount, rsp.GetEntry().GetAmount())
+				require.Equal(t, account.Currency, rsp.GetTransfer().GetCurrency())
+			},
+		},
+		{
+			name:   "InsufficientFunds",
+			amount: amount,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CashTxResult{}, &db.InsufficientFundsError{
+						AccountID:        account.ID,
+						AvailableBalance: 5,
+						Amount:           amount,
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				info := requireErrorReason(t, codes.FailedPrecondition, "INSUFFICIENT_FUNDS", err)
+				require.Equal(t, "5", info.GetMetadata()["available_balance"])
+			},
+		},
+		{
+			name:   "AccountNotActive",
+			amount: amount,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CashTxResult{}, db.ErrAccountNotActive)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				requireStatusCode(t, codes.FailedPrecondition, err)
+			},
+		},
+		{
+			name:   "NegativeAmount",
+			amount: -amount,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				requireFieldViolation(t, "amount", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.Withdraw(ctx, &pb.CashRequest{Id: account.ID, Amount: tc.amount})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestFreezeAccountRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozen := account
+	frozen.Status = db.AccountStatusFrozen
+	frozen.FrozenBy = sql.NullString{String: user.Username, Valid: true}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.Account, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Eq(db.UpdateAccountStatusParams{
+						ID:         account.ID,
+						Status:     db.AccountStatusFrozen,
+						FrozenBy:   sql.NullString{String: user.Username, Valid: true},
+						FromStatus: db.AccountStatusActive,
+					})).
+					Times(1).
+					Return(frozen, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				require.NoError(t, err)
+				requireAccount(t, frozen, rsp)
+			},
+		},
+		{
+			name: "AlreadyFrozen",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(frozen, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.FailedPrecondition, err)
+			},
+		},
+		{
+			name: "ConcurrentChange",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.Aborted, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.FreezeAccount(ctx, &pb.AccountStatusRequest{Id: account.ID})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestUnfreezeAccountRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozen := account
+	frozen.Status = db.AccountStatusFrozen
+	frozen.FrozenBy = sql.NullString{String: user.Username, Valid: true}
+
+	operatorFrozen := frozen
+	operatorFrozen.FrozenBy = sql.NullString{String: util.RandomOwner(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.Account, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(frozen, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Eq(db.UpdateAccountStatusParams{
+						ID:           account.ID,
+						Status:       db.AccountStatusActive,
+						FromStatus:   db.AccountStatusFrozen,
+						FromFrozenBy: frozen.FrozenBy,
+					})).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				require.NoError(t, err)
+				requireAccount(t, account, rsp)
+			},
+		},
+		{
+			name: "FrozenByOperator",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(operatorFrozen, nil)
+				store.EXPECT().
+					UpdateAccountStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Account, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.UnfreezeAccount(ctx, &pb.AccountStatusRequest{Id: account.ID})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestCloseAccountRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	sweepAccount := randomAccount(user.Username)
+	sweepAccount.ID = account.ID + 1
+	sweepAccount.Currency = account.Currency
+
+	closed := account
+	closed.Balance = 0
+	closed.Status = db.AccountStatusClosed
+
+	testCases := []struct {
+		name          string
+		req           *pb.CloseAccountRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CloseAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.CloseAccountRequest{Id: account.ID, SweepToAccountId: sweepAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(sweepAccount.ID)).
+					Times(1).
+					Return(sweepAccount, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Eq(db.CloseAccountTxParams{
+						AccountID:        account.ID,
+						SweepToAccountID: sweepAccount.ID,
+					})).
+					Times(1).
+					Return(db.CloseAccountTxResult{
+						Account: closed,
+						Sweep: &db.TransferTxResult{
+							Transfer:    db.Transfer{ID: 1, FromAccountID: account.ID, ToAccountID: sweepAccount.ID, Amount: account.Balance},
+							FromAccount: closed,
+							ToAccount:   sweepAccount,
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CloseAccountResponse, err error) {
+				require.NoError(t, err)
+				requireAccount(t, closed, rsp.GetAccount())
+				require.Equal(t, account.Balance, rsp.GetSweep().GetTransfer().GetAmount())
+				require.Equal(t, sweepAccount.ID, rsp.GetSweep().GetTransfer().GetToAccountId())
+			},
+		},
+		{
+			name: "BalanceNotZero",
+			req:  &pb.CloseAccountRequest{Id: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CloseAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CloseAccountResponse, err error) {
+				requireStatusCode(t, codes.FailedPrecondition, err)
+			},
+		},
+		{
+			name: "SweepIntoItself",
+			req:  &pb.CloseAccountRequest{Id: account.ID, SweepToAccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CloseAccountResponse, err error) {
+				requireFieldViolation(t, "sweep_to_account_id", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewAccountServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.CloseAccount(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) RenewAccessToken(
+	ctx context.Context,
+	req *pb.RenewAccessTokenRequest,
+) (*pb.RenewAccessTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			{Field: "refresh_token", Description: "is required"},
+		})
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	if session.Username != refreshPayload.Username {
+		return nil, status.Error(codes.Unauthenticated, "incorrect session user")
+	}
+
+	if session.RefreshToken != req.GetRefreshToken() {
+		return nil, status.Error(codes.Unauthenticated, "mismatched session token")
+	}
+
+	// Only a thief would present a refresh token that was already rotated.
+	if session.RotatedAt.Valid {
+		return nil, server.revokeSessionFamily(ctx, session)
+	}
+
+	if session.IsBlocked {
+		return nil, status.Error(codes.Unauthenticated, "blocked session")
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, status.Error(codes.Unauthenticated, "expired session")
+	}
+
+	// The role may have changed since the login, the new tokens carry the current one.
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	// Rotation doesn't extend the family, it expires with the login.
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		uuid.Nil,
+		time.Until(session.ExpiresAt),
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %v", err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		newRefreshPayload.ID,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create access token: %v", err)
+	}
+
+	mtdt := extractMetadata(ctx)
+	newSession, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     session.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    mtdt.UserAgent,
+			ClientIp:     mtdt.ClientIP,
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		// Another renewal with the same refresh token won the race.
+		if errors.Is(err, db.ErrSessionRotated) {
+			return nil, server.revokeSessionFamily(ctx, session)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to rotate session: %v", err)
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		SessionId:             newSession.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(newRefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+// Blocks every session of the family of a reused refresh token.
+func (server *Server) revokeSessionFamily(ctx context.Context, session db.Session) error {
+	_, err := server.store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to block session family: %v", err)
+	}
+	return status.Error(codes.Unauthenticated, "refresh token was already used, its sessions are revoked")
+}
@@ -0,0 +1,244 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRenewAccessTokenRPC(t *testing.T) {
+	username := util.RandomOwner()
+	var rotateArg db.RotateSessionTxParams
+	var tokenMaker token.Maker
+
+	// The user as stored now, it was a customer at login.
+	user := db.User{Username: username, Role: util.SupportRole}
+	buildUserStub := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetUser(gomock.Any(), gomock.Eq(username)).
+			Times(1).
+			Return(user, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						FamilyID:     payload.ID,
+						Username:     username,
+						RefreshToken: refreshToken,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+				buildUserStub(store)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+						rotateArg = arg
+						return db.Session{ID: arg.NewSession.ID, FamilyID: payload.ID}, nil
+					})
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.GetAccessToken())
+				require.True(t, rsp.GetAccessTokenExpiresAt().AsTime().After(time.Now()))
+
+				// The old session is rotated out for a new one of the same login.
+				require.NotEqual(t, rotateArg.SessionID, rotateArg.NewSession.ID)
+				require.Equal(t, rotateArg.NewSession.ID.String(), rsp.GetSessionId())
+				require.Equal(t, rotateArg.NewSession.RefreshToken, rsp.GetRefreshToken())
+				require.WithinDuration(t, rotateArg.NewSession.ExpiresAt, rsp.GetRefreshTokenExpiresAt().AsTime(), time.Second)
+				require.WithinDuration(t, time.Now().Add(time.Hour), rsp.GetRefreshTokenExpiresAt().AsTime(), time.Second)
+
+				// The role granted since the login is picked up.
+				accessPayload, err := tokenMaker.VerifyToken(rsp.GetAccessToken())
+				require.NoError(t, err)
+				require.Equal(t, util.SupportRole, accessPayload.Role)
+			},
+		},
+		{
+			name: "ReusedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				familyID := uuid.New()
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						FamilyID:     familyID,
+						Username:     username,
+						RefreshToken: refreshToken,
+						RotatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(familyID)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "ConcurrentRotation",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						FamilyID:     payload.ID,
+						Username:     username,
+						RefreshToken: refreshToken,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+				buildUserStub(store)
+
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrSessionRotated)
+
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(int64(2), nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.NotFound, err)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						Username:     username,
+						RefreshToken: refreshToken,
+						IsBlocked:    true,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						Username:     username,
+						RefreshToken: "another token",
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error) {
+				requireStatusCode(t, codes.Internal, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tokenMaker = server.tokenMaker
+
+			refreshToken, payload, err := server.tokenMaker.CreateToken(username, util.CustomerRole, uuid.Nil, time.Hour)
+			require.NoError(t, err)
+			tc.buildStubs(store, refreshToken, payload)
+
+			client := pb.NewSessionServiceClient(dialTestServer(t, server))
+			rsp, err := client.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{
+				RefreshToken: refreshToken,
+			})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestRenewAccessTokenRPCInvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	client := pb.NewSessionServiceClient(dialTestServer(t, server))
+
+	_, err := client.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{
+		RefreshToken: "invalid",
+	})
+	requireStatusCode(t, codes.Unauthenticated, err)
+
+	_, err = client.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{})
+	requireFieldViolation(t, "refresh_token", err)
+}
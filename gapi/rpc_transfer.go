@@ -0,0 +1,274 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/risk"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxIdempotencyKeyLength = 255
+
+const (
+	directionIn  = "in"
+	directionOut = "out"
+	directionAll = "all"
+)
+
+func (server *Server) CreateTransfer(
+	ctx context.Context,
+	req *pb.CreateTransferRequest,
+) (*pb.CreateTransferResponse, error) {
+	if violations := validateCreateTransferRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	authPayload := authPayload(ctx)
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Error(codes.PermissionDenied, "source account doesn't belong to the authenticated user")
+	}
+
+	if _, err := server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	}
+	if len(req.GetIdempotencyKey()) > 0 {
+		arg.Idempotency = &db.IdempotencyParams{
+			Username: authPayload.Username,
+			Key:      req.GetIdempotencyKey(),
+		}
+
+		// Retries are answered from the stored result without a new assessment.
+		result, err := server.store.GetIdempotentTransfer(ctx, arg)
+		if err == nil {
+			rsp := &pb.CreateTransferResponse{
+				Result:   convertTransferResult(result, req.GetCurrency()),
+				Replayed: true,
+			}
+			return rsp, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, ledgerError(err)
+		}
+	}
+
+	decision, err := server.assessTransfer(ctx, authPayload, req)
+	if err != nil {
+		return nil, err
+	}
+	if decision != nil && decision.Action == string(risk.ActionDeny) {
+		return nil, transferDeniedError(decision)
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	if decision != nil {
+		_, err = server.store.UpdateRiskDecisionTransfer(ctx, db.UpdateRiskDecisionTransferParams{
+			ID:         decision.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Result:   convertTransferResult(result, req.GetCurrency()),
+		Replayed: result.Replayed,
+	}
+	return rsp, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+	if err := validateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	} else if req.GetToAccountId() == req.GetFromAccountId() {
+		violations = append(violations, fieldViolation("to_account_id", errors.New("must differ from from_account_id")))
+	}
+	if err := validateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+	if len(req.GetIdempotencyKey()) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("must not exceed %d characters", maxIdempotencyKeyLength)
+		violations = append(violations, fieldViolation("idempotency_key", err))
+	} else if strings.HasPrefix(req.GetIdempotencyKey(), db.ScheduledRunKeyPrefix) {
+		err := fmt.Errorf("must not start with %q, it is reserved for scheduled transfers", db.ScheduledRunKeyPrefix)
+		violations = append(violations, fieldViolation("idempotency_key", err))
+	}
+	return violations
+}
+
+// Loads an account and checks that it holds the currency of the call.
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, lookupError(err)
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument,
+			"account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency,
+		)
+	}
+	return account, nil
+}
+
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.Transfer, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("id", err),
+		})
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	// The transfer must touch at least one account of the current user.
+	username := authPayload(ctx).Username
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if account.Owner == username {
+			return convertTransfer(transfer, account.Currency), nil
+		}
+	}
+
+	return nil, status.Error(codes.PermissionDenied, "transfer doesn't belong to the authenticated user")
+}
+
+func (server *Server) ListTransfers(
+	ctx context.Context,
+	req *pb.ListTransfersRequest,
+) (*pb.ListTransfersResponse, error) {
+	violations := validatePage(req.GetPageId(), req.GetPageSize())
+	if req.GetAccountId() < 0 {
+		violations = append(violations, fieldViolation("account_id", errors.New("must not be negative")))
+	}
+	direction := req.GetDirection()
+	switch direction {
+	case "":
+		direction = directionAll
+	case directionIn, directionOut, directionAll:
+	default:
+		err := fmt.Errorf("must be one of %s, %s or %s", directionIn, directionOut, directionAll)
+		violations = append(violations, fieldViolation("direction", err))
+	}
+	from, to := timeRange(req.GetFrom(), req.GetTo())
+	if !from.Before(to) {
+		violations = append(violations, fieldViolation("from", errors.New("must be before to")))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload := authPayload(ctx)
+	if req.GetAccountId() != 0 {
+		if _, err := server.ownedAccount(ctx, req.GetAccountId()); err != nil {
+			return nil, err
+		}
+	}
+
+	transfers, err := server.store.ListOwnerTransfers(ctx, db.ListOwnerTransfersParams{
+		IncludeOut: direction != directionIn,
+		IncludeIn:  direction != directionOut,
+		Owner:      authPayload.Username,
+		AccountID:  req.GetAccountId(),
+		FromTime:   from,
+		ToTime:     to,
+		Limit:      req.GetPageSize(),
+		Offset:     (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	rsp := &pb.ListTransfersResponse{
+		Transfers: make([]*pb.Transfer, len(transfers)),
+	}
+	for i, transfer := range transfers {
+		rsp.Transfers[i] = convertTransfer(db.Transfer{
+			ID:            transfer.ID,
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+			CreatedAt:     transfer.CreatedAt,
+			ReversalOf:    transfer.ReversalOf,
+			FeeOf:         transfer.FeeOf,
+		}, transfer.Currency)
+	}
+	return rsp, nil
+}
+
+func (server *Server) ReverseTransfer(
+	ctx context.Context,
+	req *pb.ReverseTransferRequest,
+) (*pb.TransferResult, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := validateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	if req.Amount != nil {
+		if err := validateAmount(req.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation("amount", err))
+		}
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	// The reversal debits the recipient, so only they can give the money back.
+	toAccount, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if toAccount.Owner != authPayload(ctx).Username {
+		return nil, status.Error(codes.PermissionDenied, "transfer wasn't received by the authenticated user")
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID:  transfer.ID,
+		Amount:      req.GetAmount(),
+		ByRecipient: true,
+	})
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	return convertTransferResult(result, toAccount.Currency), nil
+}
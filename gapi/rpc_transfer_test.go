@@ -0,0 +1,656 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc/codes"
+)
+
+func randomTransfer(fromAccountID, toAccountID int64) db.Transfer {
+	return db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        util.RandomInt(1, 100),
+	}
+}
+
+func TestCreateTransferRPC(t *testing.T) {
+	amount := int64(10)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	eurAccount := randomAccount(user2.Username)
+	eurAccount.ID = account1.ID + 2
+	eurAccount.Currency = util.EUR
+
+	transfer := db.Transfer{
+		ID:            1,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId:  account1.ID,
+				ToAccountId:    account2.ID,
+				Amount:         amount,
+				Currency:       util.USD,
+				IdempotencyKey: "transfer-1",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetIdempotentTransfer(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+						Idempotency: &db.IdempotencyParams{
+							Username: user1.Username,
+							Key:      "transfer-1",
+						},
+					})).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    transfer,
+						FromAccount: account1,
+						ToAccount:   account2,
+						Replayed:    true,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, rsp.GetReplayed())
+				require.Equal(t, transfer.ID, rsp.GetResult().GetTransfer().GetId())
+				require.Equal(t, util.USD, rsp.GetResult().GetTransfer().GetCurrency())
+				require.Nil(t, rsp.GetResult().GetFee())
+			},
+		},
+		{
+			name:     "Replayed",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId:  account1.ID,
+				ToAccountId:    account2.ID,
+				Amount:         amount,
+				Currency:       util.USD,
+				IdempotencyKey: "transfer-1",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					GetIdempotentTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    transfer,
+						FromAccount: account1,
+						ToAccount:   account2,
+						Replayed:    true,
+					}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, rsp.GetReplayed())
+				require.Equal(t, transfer.ID, rsp.GetResult().GetTransfer().GetId())
+			},
+		},
+		{
+			name:     "PermissionDenied",
+			username: user2.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name:     "ToAccountNotFound",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, codes.NotFound, err)
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   eurAccount.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).Times(1).Return(eurAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, codes.InvalidArgument, err)
+			},
+		},
+		{
+			name:     "TransferLimitExceeded",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.TransferLimitError{
+						Limit: "daily",
+						Max:   100,
+						Used:  95,
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				info := requireErrorReason(t, codes.FailedPrecondition, "TRANSFER_LIMIT_EXCEEDED", err)
+				require.Equal(t, "daily", info.GetMetadata()["limit"])
+				require.Equal(t, "95", info.GetMetadata()["used"])
+			},
+		},
+		{
+			name:     "SameAccount",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account1.ID,
+				Amount:        amount,
+				Currency:      util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireFieldViolation(t, "to_account_id", err)
+			},
+		},
+		{
+			name:     "InvalidCurrency",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      "XYZ",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireFieldViolation(t, "currency", err)
+			},
+		},
+		{
+			name:     "IdempotencyKeyTooLong",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId:  account1.ID,
+				ToAccountId:    account2.ID,
+				Amount:         amount,
+				Currency:       util.USD,
+				IdempotencyKey: strings.Repeat("k", maxIdempotencyKeyLength+1),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireFieldViolation(t, "idempotency_key", err)
+			},
+		},
+		{
+			name:     "ScheduledRunIdempotencyKey",
+			username: user1.Username,
+			req: &pb.CreateTransferRequest{
+				FromAccountId:  account1.ID,
+				ToAccountId:    account2.ID,
+				Amount:         amount,
+				Currency:       util.USD,
+				IdempotencyKey: db.ScheduledRunKeyPrefix + "7:0",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotentTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireFieldViolation(t, "idempotency_key", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewTransferServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute)
+			rsp, err := client.CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestCreateTransferRPCRiskDenied(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+
+	decision := db.RiskDecision{ID: util.RandomInt(1, 1000), Action: string(risk.ActionDeny)}
+	var decisionArg db.CreateRiskDecisionParams
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().
+		HasTransferBetween(gomock.Any(), gomock.Eq(db.HasTransferBetweenParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+		})).
+		Times(1).
+		Return(false, nil)
+	store.EXPECT().
+		CreateRiskDecision(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateRiskDecisionParams) (db.RiskDecision, error) {
+			decisionArg = arg
+			return decision, nil
+		})
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	server.riskEngine = risk.NewEngine(risk.Check{
+		Name:   "new_recipient",
+		Action: risk.ActionDeny,
+		Rule:   risk.NewRecipientRule{MinAmount: 1},
+	})
+	client := pb.NewTransferServiceClient(dialTestServer(t, server))
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user1.Username, time.Minute)
+	_, err := client.CreateTransfer(ctx, &pb.CreateTransferRequest{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+	})
+	info := requireErrorReason(t, codes.PermissionDenied, "TRANSFER_DENIED", err)
+	require.Equal(t, strconv.FormatInt(decision.ID, 10), info.GetMetadata()["risk_decision_id"])
+
+	require.Equal(t, user1.Username, decisionArg.Username)
+	require.Equal(t, string(risk.ActionDeny), decisionArg.Action)
+	require.False(t, decisionArg.SessionID.Valid)
+}
+
+func TestGetTransferRPC(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.Currency = account1.Currency
+	transfer := randomTransfer(account1.ID, account2.ID)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.Transfer, err error)
+	}{
+		{
+			name:     "Recipient",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Transfer, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, rsp.GetId())
+				require.Equal(t, transfer.Amount, rsp.GetAmount())
+				require.Equal(t, account1.Currency, rsp.GetCurrency())
+				require.Nil(t, rsp.ReversalOf)
+			},
+		},
+		{
+			name:     "PermissionDenied",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Transfer, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.Transfer, err error) {
+				requireStatusCode(t, codes.NotFound, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewTransferServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute)
+			rsp, err := client.GetTransfer(ctx, &pb.GetTransferRequest{Id: transfer.ID})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestListTransfersRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	n := 5
+	transfers := make([]db.ListOwnerTransfersRow, n)
+	for i := range transfers {
+		transfer := randomTransfer(account.ID, account.ID+1)
+		transfers[i] = db.ListOwnerTransfersRow{
+			ID:            transfer.ID,
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+			Currency:      account.Currency,
+		}
+	}
+
+	// The arguments of the list query, checked once the response is back.
+	var listArg db.ListOwnerTransfersParams
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListTransfersRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.ListTransfersResponse, err error)
+	}{
+		{
+			name: "OutOfAccount",
+			req: &pb.ListTransfersRequest{
+				AccountId: account.ID,
+				Direction: directionOut,
+				PageId:    2,
+				PageSize:  int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListOwnerTransfers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListOwnerTransfersParams) ([]db.ListOwnerTransfersRow, error) {
+						listArg = arg
+						return transfers, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, rsp.GetTransfers(), n)
+				for i, transfer := range transfers {
+					require.Equal(t, transfer.ID, rsp.GetTransfers()[i].GetId())
+					require.Equal(t, transfer.Currency, rsp.GetTransfers()[i].GetCurrency())
+				}
+
+				require.True(t, listArg.IncludeOut)
+				require.False(t, listArg.IncludeIn)
+				require.Equal(t, user.Username, listArg.Owner)
+				require.Equal(t, account.ID, listArg.AccountID)
+				require.True(t, listArg.FromTime.IsZero())
+				require.WithinDuration(t, time.Now(), listArg.ToTime, time.Second)
+				require.Equal(t, int32(n), listArg.Limit)
+				require.Equal(t, int32(n), listArg.Offset)
+			},
+		},
+		{
+			name: "AccountOfAnotherUser",
+			req: &pb.ListTransfersRequest{
+				AccountId: account.ID + 1,
+				PageId:    1,
+				PageSize:  int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID+1)).
+					Times(1).
+					Return(randomAccount(util.RandomOwner()), nil)
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListTransfersResponse, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name: "InvalidDirection",
+			req: &pb.ListTransfersRequest{
+				Direction: "sideways",
+				PageId:    1,
+				PageSize:  int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListTransfersResponse, err error) {
+				requireFieldViolation(t, "direction", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewTransferServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, time.Minute)
+			rsp, err := client.ListTransfers(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestReverseTransferRPC(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.Currency = account1.Currency
+	transfer := randomTransfer(account1.ID, account2.ID)
+	partial := int64(1)
+
+	testCases := []struct {
+		name          string
+		username      string
+		amount        *int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.TransferResult, err error)
+	}{
+		{
+			name:     "Partial",
+			username: user2.Username,
+			amount:   &partial,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				reversal := randomTransfer(account2.ID, account1.ID)
+				reversal.Amount = partial
+				reversal.ReversalOf = sql.NullInt64{Int64: transfer.ID, Valid: true}
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Eq(db.ReverseTransferTxParams{
+						TransferID:  transfer.ID,
+						Amount:      partial,
+						ByRecipient: true,
+					})).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    reversal,
+						FromAccount: account2,
+						ToAccount:   account1,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.TransferResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, partial, rsp.GetTransfer().GetAmount())
+				require.Equal(t, transfer.ID, rsp.GetTransfer().GetReversalOf())
+			},
+		},
+		{
+			name:     "AlreadyReversed",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Eq(db.ReverseTransferTxParams{
+						TransferID:  transfer.ID,
+						ByRecipient: true,
+					})).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrTransferAlreadyReversed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.TransferResult, err error) {
+				requireStatusCode(t, codes.AlreadyExists, err)
+			},
+		},
+		{
+			name:     "FromHouseAccount",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrReversalNotAllowed)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.TransferResult, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name:     "Sender",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.TransferResult, err error) {
+				requireStatusCode(t, codes.PermissionDenied, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewTransferServiceClient(dialTestServer(t, server))
+
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute)
+			rsp, err := client.ReverseTransfer(ctx, &pb.ReverseTransferRequest{
+				Id:     transfer.ID,
+				Amount: tc.amount,
+			})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
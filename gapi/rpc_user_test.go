@@ -0,0 +1,341 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc/codes"
+)
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user = db.User{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+		Tier:           db.DefaultUserTier,
+	}
+	return
+}
+
+type eqCreateUserParamsMatcher struct {
+	arg      db.CreateUserParams
+	password string
+}
+
+func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserParams)
+	if !ok {
+		return false
+	}
+
+	err := util.CheckPassword(e.password, arg.HashedPassword)
+	if err != nil {
+		return false
+	}
+
+	e.arg.HashedPassword = arg.HashedPassword
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqCreateUserParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserParamsMatcher{arg, password}
+}
+
+func TestCreateUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.User, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserParams{
+					Username: user.Username,
+					FullName: user.FullName,
+					Email:    user.Email,
+				}
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, rsp.GetUsername())
+				require.Equal(t, user.FullName, rsp.GetFullName())
+				require.Equal(t, user.Email, rsp.GetEmail())
+				require.Equal(t, user.Tier, rsp.GetTier())
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				requireStatusCode(t, codes.AlreadyExists, err)
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				requireStatusCode(t, codes.Internal, err)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req: &pb.CreateUserRequest{
+				Username: "invalid-user#1",
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				requireFieldViolation(t, "username", err)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				requireFieldViolation(t, "email", err)
+			},
+		},
+		{
+			name: "TooShortPassword",
+			req: &pb.CreateUserRequest{
+				Username: user.Username,
+				Password: "123",
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.User, err error) {
+				requireFieldViolation(t, "password", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+			rsp, err := client.CreateUser(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestLoginUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+	// The session created by the call, checked once the response is back.
+	var sessionArg db.CreateSessionParams
+
+	testCases := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						sessionArg = arg
+						return db.Session{
+							ID:           arg.ID,
+							Username:     arg.Username,
+							RefreshToken: arg.RefreshToken,
+							ExpiresAt:    arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.GetSessionId())
+				require.NotEmpty(t, rsp.GetAccessToken())
+				require.NotEmpty(t, rsp.GetRefreshToken())
+				require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+
+				require.Equal(t, rsp.GetSessionId(), sessionArg.ID.String())
+				require.Equal(t, user.Username, sessionArg.Username)
+				require.Equal(t, rsp.GetRefreshToken(), sessionArg.RefreshToken)
+				require.Contains(t, sessionArg.UserAgent, "grpc-go")
+				require.NotEmpty(t, sessionArg.ClientIp)
+				require.False(t, sessionArg.IsBlocked)
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.LoginUserRequest{
+				Username: "NotFound",
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, codes.NotFound, err)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, codes.Unauthenticated, err)
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, codes.Internal, err)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req: &pb.LoginUserRequest{
+				Username: "invalid-user#1",
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.LoginUserResponse, err error) {
+				requireFieldViolation(t, "username", err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			client := pb.NewUserServiceClient(dialTestServer(t, server))
+
+			rsp, err := client.LoginUser(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
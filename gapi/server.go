@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"fmt"
+	"net"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/pb"
+	"github.com/wiliamhw/simplebank/risk"
+	"github.com/wiliamhw/simplebank/token"
+	"github.com/wiliamhw/simplebank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Serve gRPC requests, with the same store and tokens as the HTTP server.
+type Server struct {
+	pb.UnimplementedUserServiceServer
+	pb.UnimplementedSessionServiceServer
+	pb.UnimplementedAccountServiceServer
+	pb.UnimplementedTransferServiceServer
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+	riskEngine *risk.Engine
+}
+
+// Creates a new gRPC server.
+func NewServer(config util.Config, store db.Store) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %v", err)
+	}
+
+	riskEngine := risk.NewEngine()
+	if config.RiskRulesFile != "" {
+		riskEngine, err = risk.LoadEngine(config.RiskRulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load risk rules: %v", err)
+		}
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		riskEngine: riskEngine,
+	}
+	return server, nil
+}
+
+// Registers the services behind the auth interceptor.
+func (server *Server) newGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authInterceptor(server.tokenMaker, publicMethods)),
+	)
+	pb.RegisterUserServiceServer(grpcServer, server)
+	pb.RegisterSessionServiceServer(grpcServer, server)
+	pb.RegisterAccountServiceServer(grpcServer, server)
+	pb.RegisterTransferServiceServer(grpcServer, server)
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+// Runs the gRPC server on a specific address.
+func (server *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %v", address, err)
+	}
+	return server.newGRPCServer().Serve(listener)
+}
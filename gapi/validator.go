@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// The same rules as the binding tags of the HTTP requests.
+var isAlphanum = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+
+func validateUsername(value string) error {
+	if !isAlphanum(value) {
+		return fmt.Errorf("must contain only letters or digits")
+	}
+	return nil
+}
+
+func validatePassword(value string) error {
+	if len(value) < 6 {
+		return fmt.Errorf("must contain at least 6 characters")
+	}
+	return nil
+}
+
+func validateFullName(value string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateCurrency(value string) error {
+	if !util.IsSupportedCurrency(value) {
+		return fmt.Errorf("unsupported currency %q", value)
+	}
+	return nil
+}
+
+func validateID(value int64) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func validateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be positive")
+	}
+	return nil
+}
+
+func validatePageID(value int32) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func validatePageSize(value int32, min int32, max int32) error {
+	if value < min || value > max {
+		return fmt.Errorf("must be between %d and %d", min, max)
+	}
+	return nil
+}
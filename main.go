@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
+	"os"
 
 	_ "github.com/lib/pq"
 	"github.com/wiliamhw/simplebank/api"
 	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/fx"
+	"github.com/wiliamhw/simplebank/gapi"
+	"github.com/wiliamhw/simplebank/notify"
+	"github.com/wiliamhw/simplebank/reconcile"
 	"github.com/wiliamhw/simplebank/util"
+	"github.com/wiliamhw/simplebank/worker"
 )
 
 func main() {
@@ -21,7 +29,50 @@ func main() {
 	}
 
 	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+	util.SetCurrencyLoader(currencyLoader(store), config.CurrencyCacheDuration)
+
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(store, os.Args[2:])
+		return
+	}
+
+	if config.FxRatesFile != "" {
+		if err := loadFxRates(store, config.FxRatesFile); err != nil {
+			log.Fatalf("cannot load fx rates: %v", err)
+		}
+	}
+
+	if config.HoldExpiryInterval > 0 {
+		expirer := worker.NewHoldExpirer(store, config.HoldExpiryInterval, 100)
+		go expirer.Run(context.Background())
+	}
+
+	if config.ScheduledTransferInterval > 0 {
+		scheduler := worker.NewTransferScheduler(store, config.ScheduledTransferInterval, 100)
+		go scheduler.Run(context.Background())
+	}
+
+	if config.WebhookDispatchInterval > 0 {
+		dispatcher := worker.NewWebhookDispatcher(store, config.WebhookDispatchInterval, 100)
+		go dispatcher.Run(context.Background())
+	}
+
+	if config.SessionPurgeInterval > 0 {
+		purger := worker.NewSessionPurger(store, config.SessionPurgeInterval, 1000)
+		go purger.Run(context.Background())
+	}
+
+	accountEvents := notify.NewHub()
+	go func() {
+		err := accountEvents.Listen(context.Background(), config.GetDBSource(), db.AccountEventsChannel)
+		if err != nil {
+			log.Fatalf("cannot listen to account events: %v", err)
+		}
+	}()
+
+	go runGRPCServer(config, store)
+
+	server, err := api.NewServer(config, store, accountEvents)
 	if err != nil {
 		log.Fatalf("cannot create server: %v", err)
 	}
@@ -30,3 +81,77 @@ func main() {
 		log.Fatalf("cannot start server: %v", err)
 	}
 }
+
+// Serves the gRPC API next to the HTTP one, on the same store.
+func runGRPCServer(config util.Config, store db.Store) {
+	server, err := gapi.NewServer(config, store)
+	if err != nil {
+		log.Fatalf("cannot create gRPC server: %v", err)
+	}
+
+	log.Printf("start gRPC server at %s", config.GetGRPCURL())
+	if err := server.Start(config.GetGRPCURL()); err != nil {
+		log.Fatalf("cannot start gRPC server: %v", err)
+	}
+}
+
+// Writes a ledger discrepancy report to stdout.
+// Exits with status 1 if any discrepancy is found.
+func runReconcile(store db.Store, args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	batchSize := flags.Int("batch-size", 1000, "number of rows read per query")
+	flags.Parse(args)
+
+	reconciler := reconcile.NewReconciler(store, int32(*batchSize))
+	summary, err := reconciler.WriteReport(context.Background(), os.Stdout)
+	if err != nil {
+		log.Fatalf("cannot reconcile ledger: %v", err)
+	}
+
+	if summary.Discrepancies > 0 {
+		os.Exit(1)
+	}
+}
+
+// Reads the currencies of the database, for util.LookupCurrency.
+func currencyLoader(store db.Store) util.CurrencyLoader {
+	return func(ctx context.Context) ([]util.Currency, error) {
+		rows, err := store.ListCurrencies(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		currencies := make([]util.Currency, len(rows))
+		for i, row := range rows {
+			currencies[i] = util.Currency{
+				Code:     row.Code,
+				Exponent: row.Exponent,
+				Symbol:   row.Symbol,
+				Enabled:  row.Enabled,
+			}
+		}
+		return currencies, nil
+	}
+}
+
+// Stores the exchange rates of a rates file, replacing existing ones.
+func loadFxRates(store db.Store, path string) error {
+	rates, err := fx.LoadRates(path)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range rates {
+		_, err := store.UpsertFxRate(context.Background(), db.UpsertFxRateParams{
+			FromCurrency: rate.FromCurrency,
+			ToCurrency:   rate.ToCurrency,
+			Rate:         rate.Rate,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("loaded %d fx rates from %s", len(rates), path)
+	return nil
+}
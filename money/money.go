@@ -0,0 +1,184 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wiliamhw/simplebank/util"
+)
+
+var (
+	ErrOverflow         = errors.New("amount overflows")
+	ErrCurrencyMismatch = errors.New("currencies don't match")
+)
+
+/**
+ * An amount of money in a currency, held in minor units, like cents for USD.
+ * How many decimal places the minor unit has is the exponent of the currency,
+ * so 1234 USD cents are formatted and parsed as "12.34".
+ */
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// Creates money from an amount in minor units.
+func New(amount int64, currency string) Money {
+	return Money{amount: amount, currency: currency}
+}
+
+// The amount in minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() string {
+	return m.currency
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// Returns the number of decimal places of the minor unit of the currency.
+func exponent(currency string) (int, error) {
+	c, ok := util.LookupCurrency(currency)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %q", currency)
+	}
+	return int(c.Exponent), nil
+}
+
+/**
+ * Parses a decimal amount of the currency, like "12.34" or "-5".
+ * It can't have more decimal places than the currency,
+ * nor an exponent, a leading plus sign or digit grouping.
+ */
+func Parse(s string, currency string) (Money, error) {
+	exp, err := exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	digits := strings.TrimPrefix(s, "-")
+	negative := len(digits) < len(s)
+
+	units, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		units, fraction = digits[:i], digits[i+1:]
+		if len(fraction) == 0 {
+			return Money{}, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	if len(units) == 0 || !isDigits(units) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(fraction) > exp {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", s, exp, currency)
+	}
+
+	// Parsed as a negative number, so the smallest amount still fits.
+	amount, err := strconv.ParseInt("-"+units+fraction+strings.Repeat("0", exp-len(fraction)), 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, ErrOverflow
+		}
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if !negative {
+		if amount == math.MinInt64 {
+			return Money{}, ErrOverflow
+		}
+		amount = -amount
+	}
+	return New(amount, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * Formats the amount with all the decimal places of the currency, like "12.30".
+ * Amounts of unknown currencies are formatted in minor units.
+ */
+func (m Money) String() string {
+	exp, err := exponent(m.currency)
+	if err != nil {
+		exp = 0
+	}
+	return format(m.amount, exp)
+}
+
+func format(amount int64, exp int) string {
+	sign := ""
+	// Converted before negating, as the smallest amount has no positive counterpart.
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = -abs
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Formats the amount followed by the currency code, like "12.34 USD".
+func (m Money) Format() string {
+	return m.String() + " " + m.currency
+}
+
+// Adds money of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.currency), nil
+}
+
+// Subtracts money of the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	difference := m.amount - other.amount
+	if (other.amount > 0 && difference > m.amount) || (other.amount < 0 && difference < m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return New(difference, m.currency), nil
+}
+
+func (m Money) Neg() (Money, error) {
+	if m.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return New(-m.amount, m.currency), nil
+}
+
+// Marshals the amount as a decimal string, like "12.34".
+// The currency isn't included, it is given by the surrounding object.
+func (m Money) MarshalJSON() ([]byte, error) {
+	exp, err := exponent(m.currency)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.Quote(format(m.amount, exp))), nil
+}
@@ -0,0 +1,117 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		text   string
+		amount int64
+		err    bool
+	}{
+		{text: "12.34", amount: 1234},
+		{text: "12.3", amount: 1230},
+		{text: "12", amount: 1200},
+		{text: "0.05", amount: 5},
+		{text: "-0.05", amount: -5},
+		{text: "007.00", amount: 700},
+		{text: "92233720368547758.07", amount: math.MaxInt64},
+		{text: "-92233720368547758.08", amount: math.MinInt64},
+		{text: "92233720368547758.08", err: true},
+		{text: "12.345", err: true},
+		{text: "12.", err: true},
+		{text: ".5", err: true},
+		{text: "+1", err: true},
+		{text: "1e3", err: true},
+		{text: "1,000", err: true},
+		{text: "--1", err: true},
+		{text: "-", err: true},
+		{text: "", err: true},
+	}
+
+	for _, tc := range testCases {
+		m, err := Parse(tc.text, util.USD)
+		if tc.err {
+			require.Error(t, err, tc.text)
+			continue
+		}
+		require.NoError(t, err, tc.text)
+		require.Equal(t, New(tc.amount, util.USD), m, tc.text)
+	}
+
+	_, err := Parse("92233720368547758.08", util.USD)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Parse("1", "XXX")
+	require.Error(t, err)
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "12.34", New(1234, util.USD).String())
+	require.Equal(t, "0.05", New(5, util.USD).String())
+	require.Equal(t, "-0.05", New(-5, util.USD).String())
+	require.Equal(t, "0.00", New(0, util.USD).String())
+	require.Equal(t, "-92233720368547758.08", New(math.MinInt64, util.USD).String())
+	require.Equal(t, "1234", New(1234, "XXX").String())
+	require.Equal(t, "12.34 EUR", New(1234, util.EUR).Format())
+
+	for _, amount := range []int64{0, 1, -1, 99, 100, -101, math.MaxInt64, math.MinInt64} {
+		m := New(amount, util.CAD)
+		parsed, err := Parse(m.String(), util.CAD)
+		require.NoError(t, err)
+		require.Equal(t, m, parsed)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(150, util.USD)
+	b := New(25, util.USD)
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, New(175, util.USD), sum)
+
+	difference, err := b.Sub(a)
+	require.NoError(t, err)
+	require.Equal(t, New(-125, util.USD), difference)
+
+	neg, err := a.Neg()
+	require.NoError(t, err)
+	require.Equal(t, New(-150, util.USD), neg)
+
+	_, err = a.Add(New(1, util.EUR))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = a.Sub(New(1, util.EUR))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(math.MaxInt64, util.USD).Add(New(1, util.USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, util.USD).Add(New(-1, util.USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, util.USD).Sub(New(1, util.USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(0, util.USD).Sub(New(math.MinInt64, util.USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, util.USD).Neg()
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]Money{"amount": New(1234, util.USD)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.34"}`, string(data))
+
+	_, err = json.Marshal(New(1234, "XXX"))
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	// How often the listener connection is checked when it is idle.
+	pingInterval = 90 * time.Second
+)
+
+/**
+ * Wakes up the subscribers of an account when it is notified.
+ * A wake up only says that something changed, subscribers read
+ * the database to find out what. Wake ups that arrive while a subscriber
+ * is busy are merged into one, so publishing never blocks.
+ */
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribes to an account until the returned function is called.
+func (hub *Hub) Subscribe(accountID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.subscribers[accountID] == nil {
+		hub.subscribers[accountID] = make(map[chan struct{}]struct{})
+	}
+	hub.subscribers[accountID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+
+		delete(hub.subscribers[accountID], ch)
+		if len(hub.subscribers[accountID]) == 0 {
+			delete(hub.subscribers, accountID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Wakes up the subscribers of an account.
+func (hub *Hub) Publish(accountID int64) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for ch := range hub.subscribers[accountID] {
+		wake(ch)
+	}
+}
+
+// Wakes up every subscriber.
+func (hub *Hub) PublishAll() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for _, subscribers := range hub.subscribers {
+		for ch := range subscribers {
+			wake(ch)
+		}
+	}
+}
+
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+/**
+ * Publishes the account ids received on notifications until ctx is cancelled
+ * or the channel is closed.
+ * A nil notification means the connection was re-established and some
+ * notifications may have been lost, so every subscriber is woken up.
+ */
+func (hub *Hub) Run(ctx context.Context, notifications <-chan *pq.Notification) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if n == nil {
+				hub.PublishAll()
+				continue
+			}
+
+			accountID, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				log.Printf("invalid notification on %s: %q", n.Channel, n.Extra)
+				continue
+			}
+			hub.Publish(accountID)
+		}
+	}
+}
+
+// Listens to a Postgres channel and runs the hub on its notifications
+// until ctx is cancelled.
+func (hub *Hub) Listen(ctx context.Context, dataSource string, channel string) error {
+	listener := pq.NewListener(dataSource, minReconnectInterval, maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("%s listener: %v", channel, err)
+			}
+		},
+	)
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				listener.Ping()
+			}
+		}
+	}()
+
+	hub.Run(ctx, listener.Notify)
+	return nil
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func requireWoken(t *testing.T, ch <-chan struct{}) {
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not woken up")
+	}
+}
+
+func requireNotWoken(t *testing.T, ch <-chan struct{}) {
+	select {
+	case <-ch:
+		t.Fatal("subscriber was woken up")
+	default:
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	ch1, unsubscribe1 := hub.Subscribe(1)
+	ch2, unsubscribe2 := hub.Subscribe(1)
+	other, unsubscribeOther := hub.Subscribe(2)
+	defer unsubscribe2()
+	defer unsubscribeOther()
+
+	// Wake ups are merged while the subscriber is busy.
+	hub.Publish(1)
+	hub.Publish(1)
+	requireWoken(t, ch1)
+	requireNotWoken(t, ch1)
+	requireWoken(t, ch2)
+	requireNotWoken(t, other)
+
+	unsubscribe1()
+	hub.Publish(1)
+	requireNotWoken(t, ch1)
+	requireWoken(t, ch2)
+
+	hub.PublishAll()
+	requireWoken(t, ch2)
+	requireWoken(t, other)
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	hub := NewHub()
+	_, unsubscribe := hub.Subscribe(1)
+	unsubscribe()
+
+	require.Empty(t, hub.subscribers)
+}
+
+func TestHubRun(t *testing.T) {
+	hub := NewHub()
+	ch1, unsubscribe1 := hub.Subscribe(1)
+	ch2, unsubscribe2 := hub.Subscribe(2)
+	defer unsubscribe1()
+	defer unsubscribe2()
+
+	notifications := make(chan *pq.Notification)
+	done := make(chan struct{})
+	go func() {
+		hub.Run(context.Background(), notifications)
+		close(done)
+	}()
+
+	notifications <- &pq.Notification{Channel: "account_events", Extra: "invalid"}
+	notifications <- &pq.Notification{Channel: "account_events", Extra: "1"}
+	requireWoken(t, ch1)
+	requireNotWoken(t, ch2)
+
+	// The connection was re-established.
+	notifications <- nil
+	requireWoken(t, ch1)
+	requireWoken(t, ch2)
+
+	close(notifications)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("hub did not stop")
+	}
+}
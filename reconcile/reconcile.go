@@ -0,0 +1,182 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+// Kinds of discrepancies found in the ledger.
+const (
+	KindBalanceMismatch         = "balance_mismatch"
+	KindTransferEntriesMismatch = "transfer_entries_mismatch"
+	KindOrphanEntry             = "orphan_entry"
+)
+
+// A single inconsistency in the ledger.
+// Only the field matching Kind is set.
+type Discrepancy struct {
+	Kind     string                         `json:"kind"`
+	Account  *db.ListAccountEntryTotalsRow  `json:"account,omitempty"`
+	Transfer *db.ListTransferEntryCountsRow `json:"transfer,omitempty"`
+	Entry    *db.Entry                      `json:"entry,omitempty"`
+}
+
+// Counts of a reconciliation run.
+type Summary struct {
+	AccountsChecked  int64 `json:"accounts_checked"`
+	TransfersChecked int64 `json:"transfers_checked"`
+	Discrepancies    int64 `json:"discrepancies"`
+}
+
+// Verifies that the ledger is consistent.
+// Tables are read in batches of batchSize rows ordered by id,
+// so memory usage doesn't grow with the size of the tables.
+type Reconciler struct {
+	store     db.Querier
+	batchSize int32
+}
+
+func NewReconciler(store db.Querier, batchSize int32) *Reconciler {
+	return &Reconciler{
+		store:     store,
+		batchSize: batchSize,
+	}
+}
+
+/**
+ * Runs every check and calls report for each discrepancy found:
+ * - the balance of each account must equal the sum of its entries,
+ * - each transfer must have exactly one debit and one credit entry,
+ * - each entry must belong to a transfer.
+ */
+func (r *Reconciler) Run(ctx context.Context, report func(Discrepancy) error) (Summary, error) {
+	var summary Summary
+	found := func(d Discrepancy) error {
+		summary.Discrepancies++
+		return report(d)
+	}
+
+	if err := r.checkAccounts(ctx, &summary, found); err != nil {
+		return summary, err
+	}
+	if err := r.checkTransfers(ctx, &summary, found); err != nil {
+		return summary, err
+	}
+	if err := r.checkOrphanEntries(ctx, found); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// Runs every check and writes the report to w as JSON lines,
+// one per discrepancy, followed by a line with the summary.
+func (r *Reconciler) WriteReport(ctx context.Context, w io.Writer) (Summary, error) {
+	encoder := json.NewEncoder(w)
+
+	summary, err := r.Run(ctx, func(d Discrepancy) error {
+		return encoder.Encode(d)
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	err = encoder.Encode(struct {
+		Summary Summary `json:"summary"`
+	}{summary})
+	return summary, err
+}
+
+func (r *Reconciler) checkAccounts(
+	ctx context.Context,
+	summary *Summary,
+	report func(Discrepancy) error,
+) error {
+	var afterID int64
+	for {
+		// Balances and entries of a batch are read from the same snapshot.
+		rows, err := r.store.ListAccountEntryTotals(ctx, db.ListAccountEntryTotalsParams{
+			AfterID:   afterID,
+			BatchSize: r.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range rows {
+			summary.AccountsChecked++
+			row := &rows[i]
+			if row.Balance != row.EntriesTotal {
+				err := report(Discrepancy{Kind: KindBalanceMismatch, Account: row})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(rows) < int(r.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (r *Reconciler) checkTransfers(
+	ctx context.Context,
+	summary *Summary,
+	report func(Discrepancy) error,
+) error {
+	var afterID int64
+	for {
+		rows, err := r.store.ListTransferEntryCounts(ctx, db.ListTransferEntryCountsParams{
+			AfterID:   afterID,
+			BatchSize: r.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range rows {
+			summary.TransfersChecked++
+			row := &rows[i]
+			if row.DebitCount != 1 || row.CreditCount != 1 || row.EntryCount != 2 {
+				err := report(Discrepancy{Kind: KindTransferEntriesMismatch, Transfer: row})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(rows) < int(r.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (r *Reconciler) checkOrphanEntries(ctx context.Context, report func(Discrepancy) error) error {
+	var afterID int64
+	for {
+		entries, err := r.store.ListOrphanEntries(ctx, db.ListOrphanEntriesParams{
+			AfterID:   afterID,
+			BatchSize: r.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			err := report(Discrepancy{Kind: KindOrphanEntry, Entry: &entries[i]})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < int(r.batchSize) {
+			return nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
@@ -0,0 +1,99 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+func TestReconciler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	batchSize := int32(2)
+
+	accounts := []db.ListAccountEntryTotalsRow{
+		{ID: 1, Balance: 10, EntriesTotal: 10},
+		{ID: 2, Balance: 20, EntriesTotal: 15},
+		{ID: 3, Balance: 0, EntriesTotal: 0},
+	}
+	transfers := []db.ListTransferEntryCountsRow{
+		{ID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 5, DebitCount: 1, CreditCount: 1, EntryCount: 2},
+		{ID: 2, FromAccountID: 2, ToAccountID: 1, Amount: 5, DebitCount: 1, CreditCount: 0, EntryCount: 1},
+	}
+	orphan := db.Entry{ID: 7, AccountID: 2, Amount: 5}
+
+	// Each table is read in batches, continuing after the last id.
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountEntryTotals(gomock.Any(), gomock.Eq(db.ListAccountEntryTotalsParams{AfterID: 0, BatchSize: batchSize})).
+			Return(accounts[:2], nil),
+		store.EXPECT().
+			ListAccountEntryTotals(gomock.Any(), gomock.Eq(db.ListAccountEntryTotalsParams{AfterID: 2, BatchSize: batchSize})).
+			Return(accounts[2:], nil),
+		store.EXPECT().
+			ListTransferEntryCounts(gomock.Any(), gomock.Eq(db.ListTransferEntryCountsParams{AfterID: 0, BatchSize: batchSize})).
+			Return(transfers, nil),
+		store.EXPECT().
+			ListTransferEntryCounts(gomock.Any(), gomock.Eq(db.ListTransferEntryCountsParams{AfterID: 2, BatchSize: batchSize})).
+			Return([]db.ListTransferEntryCountsRow{}, nil),
+		store.EXPECT().
+			ListOrphanEntries(gomock.Any(), gomock.Eq(db.ListOrphanEntriesParams{AfterID: 0, BatchSize: batchSize})).
+			Return([]db.Entry{orphan}, nil),
+	)
+
+	var report bytes.Buffer
+	summary, err := NewReconciler(store, batchSize).WriteReport(context.Background(), &report)
+	require.NoError(t, err)
+	require.Equal(t, Summary{AccountsChecked: 3, TransfersChecked: 2, Discrepancies: 3}, summary)
+
+	decoder := json.NewDecoder(&report)
+	var discrepancy Discrepancy
+
+	require.NoError(t, decoder.Decode(&discrepancy))
+	require.Equal(t, KindBalanceMismatch, discrepancy.Kind)
+	require.Equal(t, accounts[1], *discrepancy.Account)
+
+	discrepancy = Discrepancy{}
+	require.NoError(t, decoder.Decode(&discrepancy))
+	require.Equal(t, KindTransferEntriesMismatch, discrepancy.Kind)
+	require.Equal(t, transfers[1], *discrepancy.Transfer)
+
+	discrepancy = Discrepancy{}
+	require.NoError(t, decoder.Decode(&discrepancy))
+	require.Equal(t, KindOrphanEntry, discrepancy.Kind)
+	require.Equal(t, orphan.ID, discrepancy.Entry.ID)
+
+	var last struct {
+		Summary Summary `json:"summary"`
+	}
+	require.NoError(t, decoder.Decode(&last))
+	require.Equal(t, summary, last.Summary)
+}
+
+func TestReconcilerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountEntryTotals(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().
+		ListTransferEntryCounts(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := NewReconciler(store, 10).Run(context.Background(), func(Discrepancy) error {
+		return nil
+	})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,81 @@
+package risk
+
+// What is done with a transfer.
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionDeny  Action = "deny"
+
+	// The transfer goes through, but is marked for review by analysts.
+	ActionFlag Action = "flag"
+)
+
+func (action Action) valid() bool {
+	return action == ActionAllow || action == ActionDeny || action == ActionFlag
+}
+
+// A rule with the action taken on the transfers that match it.
+type Check struct {
+	Name   string
+	Action Action
+	Rule   Rule
+}
+
+// A check that matched a transfer.
+type Match struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// The outcome of the evaluation of a transfer.
+type Decision struct {
+	Action  Action  `json:"action"`
+	Matches []Match `json:"matches"`
+}
+
+// Evaluates transfers against a list of checks.
+type Engine struct {
+	checks []Check
+}
+
+// Creates an engine evaluating the checks in order.
+func NewEngine(checks ...Check) *Engine {
+	return &Engine{checks: checks}
+}
+
+// Tells whether the engine has any check to evaluate.
+func (engine *Engine) Empty() bool {
+	return len(engine.checks) == 0
+}
+
+/**
+ * Evaluates the checks in order.
+ * The first matching check that allows or denies decides,
+ * and the remaining checks are skipped.
+ * Matching flag checks don't stop the evaluation. If nothing decided,
+ * the transfer is flagged when any of them matched, and allowed otherwise.
+ */
+func (engine *Engine) Evaluate(transfer Transfer) Decision {
+	decision := Decision{Action: ActionAllow, Matches: []Match{}}
+
+	for _, check := range engine.checks {
+		matched, reason := check.Rule.Match(transfer)
+		if !matched {
+			continue
+		}
+
+		decision.Matches = append(decision.Matches, Match{
+			Rule:   check.Name,
+			Action: check.Action,
+			Reason: reason,
+		})
+		if check.Action != ActionFlag {
+			decision.Action = check.Action
+			return decision
+		}
+		decision.Action = ActionFlag
+	}
+	return decision
+}
@@ -0,0 +1,91 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Rule matching every transfer.
+type alwaysRule struct{}
+
+func (rule alwaysRule) Match(transfer Transfer) (bool, string) {
+	return true, "always"
+}
+
+// Rule matching no transfer.
+type neverRule struct{}
+
+func (rule neverRule) Match(transfer Transfer) (bool, string) {
+	return false, ""
+}
+
+func TestEngineEvaluate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		checks  []Check
+		action  Action
+		matches []string
+	}{
+		{
+			name:    "NoCheck",
+			action:  ActionAllow,
+			matches: []string{},
+		},
+		{
+			name: "NoMatch",
+			checks: []Check{
+				{Name: "deny", Action: ActionDeny, Rule: neverRule{}},
+				{Name: "flag", Action: ActionFlag, Rule: neverRule{}},
+			},
+			action:  ActionAllow,
+			matches: []string{},
+		},
+		{
+			name: "FlagsAccumulate",
+			checks: []Check{
+				{Name: "flag1", Action: ActionFlag, Rule: alwaysRule{}},
+				{Name: "skipped", Action: ActionDeny, Rule: neverRule{}},
+				{Name: "flag2", Action: ActionFlag, Rule: alwaysRule{}},
+			},
+			action:  ActionFlag,
+			matches: []string{"flag1", "flag2"},
+		},
+		{
+			name: "DenyStops",
+			checks: []Check{
+				{Name: "flag", Action: ActionFlag, Rule: alwaysRule{}},
+				{Name: "deny", Action: ActionDeny, Rule: alwaysRule{}},
+				{Name: "allow", Action: ActionAllow, Rule: alwaysRule{}},
+			},
+			action:  ActionDeny,
+			matches: []string{"flag", "deny"},
+		},
+		{
+			name: "AllowStops",
+			checks: []Check{
+				{Name: "allow", Action: ActionAllow, Rule: alwaysRule{}},
+				{Name: "deny", Action: ActionDeny, Rule: alwaysRule{}},
+			},
+			action:  ActionAllow,
+			matches: []string{"allow"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewEngine(tc.checks...)
+			require.Equal(t, len(tc.checks) == 0, engine.Empty())
+
+			decision := engine.Evaluate(randomTransfer())
+			require.Equal(t, tc.action, decision.Action)
+
+			names := []string{}
+			for _, match := range decision.Matches {
+				names = append(names, match.Rule)
+				require.Equal(t, "always", match.Reason)
+			}
+			require.Equal(t, tc.matches, names)
+		})
+	}
+}
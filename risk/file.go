@@ -0,0 +1,123 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// Types of rules that can be declared in a rules file.
+const (
+	RuleNewRecipient    = "new_recipient"
+	RuleSessionAge      = "session_age"
+	RuleIPMismatch      = "ip_mismatch"
+	RuleCountryMismatch = "country_mismatch"
+)
+
+/**
+ * Declares a check in a rules file.
+ * Which parameters are used depends on the type of the rule:
+ * new_recipient uses min_amount and currency, session_age uses max_age,
+ * and country_mismatch uses networks, the address ranges of each country.
+ */
+type RuleConfig struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Action Action `json:"action"`
+
+	MinAmount int64               `json:"min_amount,omitempty"`
+	Currency  string              `json:"currency,omitempty"`
+	MaxAge    string              `json:"max_age,omitempty"`
+	Networks  map[string][]string `json:"networks,omitempty"`
+}
+
+/**
+ * Reads the checks of a JSON file holding a list of RuleConfig,
+ * and returns an engine evaluating them in the order of the file.
+ * Every rule is validated, so either all of them or none are loaded.
+ */
+func LoadEngine(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []RuleConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(configs))
+	checks := make([]Check, len(configs))
+	for i, config := range configs {
+		checks[i], err = config.Check()
+		if err == nil && names[config.Name] {
+			err = fmt.Errorf("duplicate rule name %q", config.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule #%d in %s: %w", i+1, path, err)
+		}
+		names[config.Name] = true
+	}
+	return NewEngine(checks...), nil
+}
+
+// Validates the config and builds the check it declares.
+func (config RuleConfig) Check() (Check, error) {
+	check := Check{Name: config.Name, Action: config.Action}
+	if config.Name == "" {
+		return check, errors.New("name is required")
+	}
+	if !config.Action.valid() {
+		return check, fmt.Errorf("unknown action %q", config.Action)
+	}
+
+	switch config.Type {
+	case RuleNewRecipient:
+		if config.MinAmount < 0 {
+			return check, errors.New("min_amount must not be negative")
+		}
+		if config.Currency != "" && !util.IsSupportedCurrency(config.Currency) {
+			return check, fmt.Errorf("unsupported currency %q", config.Currency)
+		}
+		check.Rule = NewRecipientRule{MinAmount: config.MinAmount, Currency: config.Currency}
+
+	case RuleSessionAge:
+		maxAge, err := time.ParseDuration(config.MaxAge)
+		if err != nil {
+			return check, fmt.Errorf("invalid max_age: %w", err)
+		}
+		if maxAge <= 0 {
+			return check, errors.New("max_age must be positive")
+		}
+		check.Rule = SessionAgeRule{MaxAge: maxAge}
+
+	case RuleIPMismatch:
+		check.Rule = IPMismatchRule{}
+
+	case RuleCountryMismatch:
+		if len(config.Networks) == 0 {
+			return check, errors.New("networks are required")
+		}
+		rule := CountryMismatchRule{Networks: make(map[string][]*net.IPNet, len(config.Networks))}
+		for country, cidrs := range config.Networks {
+			for _, cidr := range cidrs {
+				_, network, err := net.ParseCIDR(cidr)
+				if err != nil {
+					return check, fmt.Errorf("invalid network of %s: %w", country, err)
+				}
+				rule.Networks[country] = append(rule.Networks[country], network)
+			}
+		}
+		check.Rule = rule
+
+	default:
+		return check, fmt.Errorf("unknown rule type %q", config.Type)
+	}
+	return check, nil
+}
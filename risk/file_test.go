@@ -0,0 +1,54 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEngine(t *testing.T) {
+	engine, err := LoadEngine("testdata/rules.json")
+	require.NoError(t, err)
+	require.Len(t, engine.checks, 4)
+	require.Equal(t, Check{
+		Name:   "large_transfer_to_new_recipient",
+		Action: ActionDeny,
+		Rule:   NewRecipientRule{MinAmount: 100000},
+	}, engine.checks[0])
+	require.Equal(t, SessionAgeRule{MaxAge: 5 * time.Minute}, engine.checks[2].Rule)
+
+	countries := engine.checks[1].Rule.(CountryMismatchRule)
+	require.Len(t, countries.Networks["ID"], 2)
+	require.Len(t, countries.Networks["SG"], 1)
+
+	_, err = LoadEngine("testdata/invalid_rules.json")
+	require.ErrorContains(t, err, "invalid rule #2")
+
+	_, err = LoadEngine("testdata/missing.json")
+	require.Error(t, err)
+}
+
+func TestRuleConfigCheck(t *testing.T) {
+	invalid := []RuleConfig{
+		{Type: RuleIPMismatch, Action: ActionFlag},
+		{Name: "rule", Type: RuleIPMismatch, Action: "block"},
+		{Name: "rule", Type: "unknown", Action: ActionFlag},
+		{Name: "rule", Type: RuleNewRecipient, Action: ActionDeny, MinAmount: -1},
+		{Name: "rule", Type: RuleNewRecipient, Action: ActionDeny, Currency: "XYZ"},
+		{Name: "rule", Type: RuleSessionAge, Action: ActionFlag},
+		{Name: "rule", Type: RuleSessionAge, Action: ActionFlag, MaxAge: "-5m"},
+		{Name: "rule", Type: RuleCountryMismatch, Action: ActionDeny},
+		{
+			Name:     "rule",
+			Type:     RuleCountryMismatch,
+			Action:   ActionDeny,
+			Networks: map[string][]string{"ID": {"36.64.0.0"}},
+		},
+	}
+
+	for _, config := range invalid {
+		_, err := config.Check()
+		require.Error(t, err, config)
+	}
+}
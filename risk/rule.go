@@ -0,0 +1,139 @@
+package risk
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Facts about a transfer that rules are evaluated against.
+type Transfer struct {
+	Username      string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+
+	// Whether the sender never transferred to the recipient account before.
+	NewRecipient bool
+
+	// Address the transfer was requested from.
+	ClientIP string
+
+	// Session the transfer was requested from, nil if unknown.
+	Session *Session
+
+	Time time.Time
+}
+
+// Session of the user requesting a transfer.
+type Session struct {
+	// Address the user logged in from.
+	ClientIP  string
+	CreatedAt time.Time
+}
+
+// Condition a transfer is checked against.
+type Rule interface {
+	// Returns whether the transfer matches the rule and, if it does, why.
+	Match(transfer Transfer) (bool, string)
+}
+
+/**
+ * Matches transfers to an account the sender never transferred to,
+ * of at least MinAmount.
+ * When Currency is set, only transfers in that currency match.
+ */
+type NewRecipientRule struct {
+	MinAmount int64
+	Currency  string
+}
+
+func (rule NewRecipientRule) Match(transfer Transfer) (bool, string) {
+	if !transfer.NewRecipient || transfer.Amount < rule.MinAmount {
+		return false, ""
+	}
+	if rule.Currency != "" && transfer.Currency != rule.Currency {
+		return false, ""
+	}
+	return true, fmt.Sprintf("first transfer to account [%d], %d %s",
+		transfer.ToAccountID, transfer.Amount, transfer.Currency,
+	)
+}
+
+// Matches transfers from a session created less than MaxAge ago.
+type SessionAgeRule struct {
+	MaxAge time.Duration
+}
+
+func (rule SessionAgeRule) Match(transfer Transfer) (bool, string) {
+	if transfer.Session == nil {
+		return false, ""
+	}
+
+	age := transfer.Time.Sub(transfer.Session.CreatedAt)
+	if age >= rule.MaxAge {
+		return false, ""
+	}
+	return true, fmt.Sprintf("session created %s ago", age.Round(time.Second))
+}
+
+// Matches transfers requested from another address than the one of the session.
+type IPMismatchRule struct{}
+
+func (rule IPMismatchRule) Match(transfer Transfer) (bool, string) {
+	if transfer.Session == nil || transfer.ClientIP == transfer.Session.ClientIP {
+		return false, ""
+	}
+	return true, fmt.Sprintf("requested from %s, logged in from %s",
+		transfer.ClientIP, transfer.Session.ClientIP,
+	)
+}
+
+/**
+ * Matches transfers requested from another country than the one of the session.
+ * Countries are resolved from Networks, the address ranges of each country.
+ * An address outside of all ranges only mismatches a known country.
+ */
+type CountryMismatchRule struct {
+	Networks map[string][]*net.IPNet
+}
+
+func (rule CountryMismatchRule) Match(transfer Transfer) (bool, string) {
+	if transfer.Session == nil {
+		return false, ""
+	}
+
+	country := rule.country(transfer.ClientIP)
+	sessionCountry := rule.country(transfer.Session.ClientIP)
+	if country == sessionCountry {
+		return false, ""
+	}
+	return true, fmt.Sprintf("requested from %s, logged in from %s",
+		countryName(country), countryName(sessionCountry),
+	)
+}
+
+// Returns the country of the address, empty if unknown.
+func (rule CountryMismatchRule) country(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+
+	for country, networks := range rule.Networks {
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return country
+			}
+		}
+	}
+	return ""
+}
+
+func countryName(country string) string {
+	if country == "" {
+		return "an unknown country"
+	}
+	return country
+}
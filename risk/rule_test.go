@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+func randomTransfer() Transfer {
+	now := time.Now()
+	return Transfer{
+		Username:      util.RandomOwner(),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1001, 2000),
+		Amount:        util.RandomMoney(),
+		Currency:      util.USD,
+		ClientIP:      "36.64.0.1",
+		Session: &Session{
+			ClientIP:  "36.64.0.1",
+			CreatedAt: now.Add(-time.Hour),
+		},
+		Time: now,
+	}
+}
+
+func TestNewRecipientRule(t *testing.T) {
+	rule := NewRecipientRule{MinAmount: 1000, Currency: util.USD}
+
+	transfer := randomTransfer()
+	transfer.Amount = 1000
+	matched, _ := rule.Match(transfer)
+	require.False(t, matched)
+
+	transfer.NewRecipient = true
+	matched, reason := rule.Match(transfer)
+	require.True(t, matched)
+	require.Contains(t, reason, "1000 USD")
+
+	transfer.Amount = 999
+	matched, _ = rule.Match(transfer)
+	require.False(t, matched)
+
+	transfer.Amount = 1000
+	transfer.Currency = util.EUR
+	matched, _ = rule.Match(transfer)
+	require.False(t, matched)
+}
+
+func TestSessionAgeRule(t *testing.T) {
+	rule := SessionAgeRule{MaxAge: 5 * time.Minute}
+
+	transfer := randomTransfer()
+	matched, _ := rule.Match(transfer)
+	require.False(t, matched)
+
+	transfer.Session.CreatedAt = transfer.Time.Add(-2 * time.Minute)
+	matched, reason := rule.Match(transfer)
+	require.True(t, matched)
+	require.Equal(t, "session created 2m0s ago", reason)
+
+	transfer.Session = nil
+	matched, _ = rule.Match(transfer)
+	require.False(t, matched)
+}
+
+func TestIPMismatchRule(t *testing.T) {
+	rule := IPMismatchRule{}
+
+	transfer := randomTransfer()
+	matched, _ := rule.Match(transfer)
+	require.False(t, matched)
+
+	transfer.ClientIP = "36.64.0.2"
+	matched, reason := rule.Match(transfer)
+	require.True(t, matched)
+	require.Equal(t, "requested from 36.64.0.2, logged in from 36.64.0.1", reason)
+}
+
+func TestCountryMismatchRule(t *testing.T) {
+	_, indonesia, err := net.ParseCIDR("36.64.0.0/11")
+	require.NoError(t, err)
+	_, singapore, err := net.ParseCIDR("8.128.0.0/10")
+	require.NoError(t, err)
+	rule := CountryMismatchRule{Networks: map[string][]*net.IPNet{
+		"ID": {indonesia},
+		"SG": {singapore},
+	}}
+
+	testCases := []struct {
+		clientIP string
+		matched  bool
+		reason   string
+	}{
+		{clientIP: "36.64.0.1", matched: false},
+		{clientIP: "36.95.255.255", matched: false},
+		{clientIP: "8.128.0.1", matched: true, reason: "requested from SG, logged in from ID"},
+		{clientIP: "192.0.2.1", matched: true, reason: "requested from an unknown country, logged in from ID"},
+		{clientIP: "invalid", matched: true, reason: "requested from an unknown country, logged in from ID"},
+	}
+
+	for _, tc := range testCases {
+		transfer := randomTransfer()
+		transfer.ClientIP = tc.clientIP
+
+		matched, reason := rule.Match(transfer)
+		require.Equal(t, tc.matched, matched, tc.clientIP)
+		require.Equal(t, tc.reason, reason, tc.clientIP)
+	}
+
+	// Neither address is known.
+	transfer := randomTransfer()
+	transfer.ClientIP = "192.0.2.1"
+	transfer.Session.ClientIP = "192.0.2.2"
+	matched, _ := rule.Match(transfer)
+	require.False(t, matched)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 const minSecretKeySize = 32
@@ -23,9 +24,9 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 	return &JWTMaker{secretKey}, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token for a specific username, role, session and duration
+func (maker *JWTMaker) CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -2,12 +2,14 @@ package token
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Maker is an interface for managing tokens
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific username, role, session and duration
+	CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken checks if the token is valid or not
 	VerifyToken(token string) (*Payload, error)
@@ -18,9 +18,24 @@ type Config struct {
 
 	AppAddress           string        `mapstructure:"APP_ADDRESS"`
 	AppPort              string        `mapstructure:"APP_PORT"`
+	GRPCPort             string        `mapstructure:"GRPC_PORT"`
 	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMETRIC_KEY"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+
+	HoldExpiryInterval        time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	ScheduledTransferInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_INTERVAL"`
+	WebhookDispatchInterval   time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	SessionPurgeInterval      time.Duration `mapstructure:"SESSION_PURGE_INTERVAL"`
+
+	// How long the currencies are cached. Zero caches them until they are changed.
+	CurrencyCacheDuration time.Duration `mapstructure:"CURRENCY_CACHE_DURATION"`
+
+	FxRatesFile     string        `mapstructure:"FX_RATES_FILE"`
+	FxQuoteDuration time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+
+	// JSON file of the risk rules evaluated on transfers. No rule applies when empty.
+	RiskRulesFile string `mapstructure:"RISK_RULES_FILE"`
 }
 
 // Read configuration from file or environtment variables.
@@ -53,3 +68,7 @@ func (c *Config) GetDBSource() string {
 func (c *Config) GetAppURL() string {
 	return fmt.Sprintf("%s:%s", c.AppAddress, c.AppPort)
 }
+
+func (c *Config) GetGRPCURL() string {
+	return fmt.Sprintf("%s:%s", c.AppAddress, c.GRPCPort)
+}
@@ -1,13 +1,128 @@
 package util
 
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
 
-var currencies = [...]string{EUR, USD, CAD}
+// Currency the bank can hold accounts in.
+type Currency struct {
+	Code string `json:"code"`
+
+	// Number of decimal places of the minor unit, 2 for cents.
+	// Amounts are always expressed in minor units.
+	Exponent int32  `json:"exponent"`
+	Symbol   string `json:"symbol"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Reads every currency, enabled or not.
+type CurrencyLoader func(ctx context.Context) ([]Currency, error)
+
+// How long a currency lookup waits for the loader.
+const currencyLoadTimeout = 5 * time.Second
+
+/**
+ * Caches the currencies returned by a loader.
+ * They are loaded again after the cache duration, or on the first
+ * lookup after an invalidation. If loading fails, the cached currencies
+ * are kept until the next attempt.
+ */
+type currencyCache struct {
+	mu       sync.Mutex
+	load     CurrencyLoader
+	duration time.Duration
+	byCode   map[string]Currency
+	loadedAt time.Time
+}
+
+// Used until a loader is set, like in tests that don't have a database.
+var defaultCurrencies = []Currency{
+	{Code: EUR, Exponent: 2, Symbol: "€", Enabled: true},
+	{Code: USD, Exponent: 2, Symbol: "$", Enabled: true},
+	{Code: CAD, Exponent: 2, Symbol: "CA$", Enabled: true},
+}
+
+var currencyLookup = newCurrencyCache(nil, 0)
+
+func newCurrencyCache(load CurrencyLoader, duration time.Duration) *currencyCache {
+	cache := &currencyCache{load: load, duration: duration}
+	cache.set(defaultCurrencies)
+	return cache
+}
+
+func (cache *currencyCache) set(list []Currency) {
+	cache.byCode = make(map[string]Currency, len(list))
+	for _, currency := range list {
+		cache.byCode[currency.Code] = currency
+	}
+}
+
+func (cache *currencyCache) get(code string) (Currency, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.load != nil && cache.expired() {
+		ctx, cancel := context.WithTimeout(context.Background(), currencyLoadTimeout)
+		list, err := cache.load(ctx)
+		cancel()
+
+		if err != nil {
+			log.Printf("cannot load currencies, keeping %d cached: %v", len(cache.byCode), err)
+		} else {
+			cache.set(list)
+		}
+		cache.loadedAt = time.Now()
+	}
+
+	currency, ok := cache.byCode[code]
+	return currency, ok
+}
+
+func (cache *currencyCache) expired() bool {
+	if cache.loadedAt.IsZero() {
+		return true
+	}
+	return cache.duration > 0 && time.Since(cache.loadedAt) >= cache.duration
+}
+
+func (cache *currencyCache) invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.loadedAt = time.Time{}
+}
+
+/**
+ * Makes currency lookups read from the loader, through a cache
+ * refreshed every duration, or only when invalidated if duration is zero.
+ * Without a loader, only the default currencies are known.
+ * It must be called before the first lookup.
+ */
+func SetCurrencyLoader(load CurrencyLoader, duration time.Duration) {
+	currencyLookup = newCurrencyCache(load, duration)
+}
+
+// Makes the next lookup load the currencies again, after they changed.
+func InvalidateCurrencies() {
+	currencyLookup.invalidate()
+}
+
+// Returns the currency with the code, enabled or not.
+func LookupCurrency(code string) (Currency, bool) {
+	return currencyLookup.get(code)
+}
 
+// Tells whether money can be held and moved in the currency.
 func IsSupportedCurrency(currency string) bool {
-	return InArray(currencies, currency)
+	c, ok := LookupCurrency(currency)
+	return ok && c.Enabled
 }
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCurrencies(t *testing.T) {
+	for _, code := range []string{EUR, USD, CAD} {
+		require.True(t, IsSupportedCurrency(code))
+	}
+	require.False(t, IsSupportedCurrency("JPY"))
+
+	currency, ok := LookupCurrency(USD)
+	require.True(t, ok)
+	require.Equal(t, int32(2), currency.Exponent)
+}
+
+func TestCurrencyCache(t *testing.T) {
+	loads := 0
+	var loadErr error
+	list := []Currency{
+		{Code: "JPY", Exponent: 0, Symbol: "¥", Enabled: true},
+		{Code: "BHD", Exponent: 3, Symbol: "BD", Enabled: false},
+	}
+	cache := newCurrencyCache(func(ctx context.Context) ([]Currency, error) {
+		loads++
+		return list, loadErr
+	}, time.Hour)
+
+	currency, ok := cache.get("JPY")
+	require.True(t, ok)
+	require.Equal(t, list[0], currency)
+	currency, ok = cache.get("BHD")
+	require.True(t, ok)
+	require.False(t, currency.Enabled)
+	_, ok = cache.get(USD)
+	require.False(t, ok)
+	require.Equal(t, 1, loads)
+
+	// Changes are only seen once the cache is invalidated.
+	list = append(list, Currency{Code: "GBP", Exponent: 2, Symbol: "£", Enabled: true})
+	_, ok = cache.get("GBP")
+	require.False(t, ok)
+
+	cache.invalidate()
+	_, ok = cache.get("GBP")
+	require.True(t, ok)
+	require.Equal(t, 2, loads)
+
+	// A failed load keeps the cached currencies.
+	loadErr = errors.New("connection refused")
+	list = nil
+	cache.invalidate()
+	_, ok = cache.get("GBP")
+	require.True(t, ok)
+	require.Equal(t, 3, loads)
+
+	// Expired currencies are loaded again.
+	loadErr = nil
+	cache.loadedAt = time.Now().Add(-time.Hour)
+	_, ok = cache.get("GBP")
+	require.False(t, ok)
+	require.Equal(t, 4, loads)
+}
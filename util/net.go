@@ -0,0 +1,19 @@
+package util
+
+import "net"
+
+/**
+ * Reports whether ip is reachable on the public internet.
+ * Loopback, private, link-local, multicast and unspecified addresses
+ * point into our own network, so requests made on behalf of users
+ * must not go to them.
+ */
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
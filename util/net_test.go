@@ -0,0 +1,33 @@
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"224.0.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.public, IsPublicIP(net.ParseIP(tc.ip)), tc.ip)
+	}
+}
@@ -42,9 +42,9 @@ func RandomMoney() int64 {
 	return RandomInt(0, 1000)
 }
 
-// Generates a random currency code.
+// Generates a random currency code among the ones seeded by the migrations.
 func RandomCurrency() string {
-	return currencies[rand.Intn(len(currencies))]
+	return defaultCurrencies[rand.Intn(len(defaultCurrencies))].Code
 }
 
 func RandomEmail() string {
@@ -0,0 +1,8 @@
+package util
+
+// Roles of the users. Everyone is a customer until granted another role.
+const (
+	CustomerRole = "customer"
+	SupportRole  = "support"
+	AdminRole    = "admin"
+)
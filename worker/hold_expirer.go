@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+// Releases holds that are past their expiry time.
+type HoldExpirer struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewHoldExpirer(store db.Store, interval time.Duration, batchSize int32) *HoldExpirer {
+	return &HoldExpirer{
+		store:     store,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Expires stale holds every interval until ctx is cancelled.
+func (e *HoldExpirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := e.ExpireHolds(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("cannot expire holds: %v", err)
+		} else if n > 0 {
+			log.Printf("expired %d holds", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Expires every active hold that is past its expiry time
+ * and returns how many were expired.
+ * Each hold is released in its own transaction, so a hold that is
+ * captured or voided concurrently is simply skipped.
+ */
+func (e *HoldExpirer) ExpireHolds(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		holds, err := e.store.ListExpiredHolds(ctx, db.ListExpiredHoldsParams{
+			Now:   time.Now(),
+			Limit: e.batchSize,
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		for _, hold := range holds {
+			_, err := e.store.ExpireHoldTx(ctx, hold.ID)
+			if errors.Is(err, db.ErrHoldNotActive) {
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(holds) < int(e.batchSize) {
+			return expired, nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+func TestExpireHolds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	// Two full batches and a short one.
+	batches := [][]db.Hold{
+		{{ID: 1}, {ID: 2}},
+		{{ID: 3}, {ID: 4}},
+		{{ID: 5}},
+	}
+	for _, batch := range batches {
+		store.EXPECT().
+			ListExpiredHolds(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(batch, nil)
+	}
+
+	// Captured by someone else in the meantime.
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Eq(int64(3))).
+		Times(1).
+		Return(db.HoldTxResult{}, db.ErrHoldNotActive)
+
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Times(4)
+
+	expirer := NewHoldExpirer(store, 0, 2)
+	n, err := expirer.ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+}
+
+func TestExpireHoldsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ListExpiredHolds(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Hold{{ID: 1}, {ID: 2}}, nil)
+
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Eq(int64(1))).
+		Times(1).
+		Return(db.HoldTxResult{}, sql.ErrConnDone)
+
+	expirer := NewHoldExpirer(store, 0, 2)
+	n, err := expirer.ExpireHolds(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Zero(t, n)
+}
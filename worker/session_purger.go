@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+// Deletes sessions whose refresh token has expired.
+type SessionPurger struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewSessionPurger(store db.Store, interval time.Duration, batchSize int32) *SessionPurger {
+	return &SessionPurger{
+		store:     store,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Purges expired sessions every interval until ctx is cancelled.
+func (p *SessionPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.PurgeSessions(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("cannot purge sessions: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d expired sessions", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Deletes every session that expired and returns how many were deleted.
+ * They are deleted in batches, so a large backlog doesn't hold
+ * a long lock on the sessions table.
+ */
+func (p *SessionPurger) PurgeSessions(ctx context.Context) (int64, error) {
+	var purged int64
+	now := time.Now()
+	for {
+		n, err := p.store.DeleteExpiredSessions(ctx, db.DeleteExpiredSessionsParams{
+			Now:   now,
+			Limit: p.batchSize,
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+
+		if n < int64(p.batchSize) {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+)
+
+func TestPurgeSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	// Two full batches and a short one.
+	gomock.InOrder(
+		store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Return(int64(2), nil),
+		store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Return(int64(2), nil),
+		store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Return(int64(1), nil),
+	)
+
+	purger := NewSessionPurger(store, 0, 2)
+	n, err := purger.PurgeSessions(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+}
+
+func TestPurgeSessionsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Return(int64(2), nil),
+		store.EXPECT().DeleteExpiredSessions(gomock.Any(), gomock.Any()).Return(int64(0), sql.ErrConnDone),
+	)
+
+	purger := NewSessionPurger(store, 0, 2)
+	n, err := purger.PurgeSessions(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, int64(2), n)
+}
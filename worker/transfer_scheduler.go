@@ -0,0 +1,269 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+// How long a worker owns a scheduled transfer it is executing.
+// Another worker may take it over once the lease is over.
+const defaultScheduleLease = 5 * time.Minute
+
+// Controls how failed runs of a scheduled transfer are retried.
+type ScheduleRetryPolicy struct {
+	MaxAttempts int32         // Failed attempts before the transfer is marked as failed.
+	BaseDelay   time.Duration // Delay before the first retry, doubled after each failure.
+	MaxDelay    time.Duration // Upper bound of the delay before any retry.
+}
+
+var DefaultScheduleRetryPolicy = ScheduleRetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Minute,
+	MaxDelay:    time.Hour,
+}
+
+// Returns the delay before the retry that follows the given failed attempt (starting from 1).
+func (policy ScheduleRetryPolicy) delay(attempt int32) time.Duration {
+	return backoff(policy.BaseDelay, policy.MaxDelay, attempt)
+}
+
+// Exponential backoff: base after the first failed attempt, doubled after each one, up to max.
+func backoff(base, max time.Duration, attempt int32) time.Duration {
+	delay := base
+	for i := int32(1); i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+/**
+ * Executes scheduled transfers when they are due.
+ * Several schedulers can run against the same database: a transfer
+ * is claimed with a lease before it runs, so only one of them executes it.
+ * Every run uses an idempotency key, so a run that is retried after
+ * a crash replays the stored transfer instead of moving money twice.
+ * If the transfer was edited in between, the key no longer matches it:
+ * the earlier run is recorded as is and the edit applies from the next run.
+ */
+type TransferScheduler struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int32
+	lease     time.Duration
+	retry     ScheduleRetryPolicy
+}
+
+func NewTransferScheduler(store db.Store, interval time.Duration, batchSize int32) *TransferScheduler {
+	return &TransferScheduler{
+		store:     store,
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     defaultScheduleLease,
+		retry:     DefaultScheduleRetryPolicy,
+	}
+}
+
+// Executes due transfers every interval until ctx is cancelled.
+func (s *TransferScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.RunDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("cannot run scheduled transfers: %v", err)
+		} else if n > 0 {
+			log.Printf("executed %d scheduled transfers", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Executes every scheduled transfer that is due
+ * and returns how many were executed successfully.
+ * Failed transfers are recorded and retried later,
+ * only errors of the scheduler itself are returned.
+ */
+func (s *TransferScheduler) RunDue(ctx context.Context) (int, error) {
+	executed := 0
+	for {
+		due, err := s.store.ListDueScheduledTransfers(ctx, db.ListDueScheduledTransfersParams{
+			Now:   time.Now(),
+			Limit: s.batchSize,
+		})
+		if err != nil {
+			return executed, err
+		}
+
+		for _, st := range due {
+			ok, err := s.execute(ctx, st.ID)
+			if err != nil {
+				return executed, err
+			}
+			if ok {
+				executed++
+			}
+		}
+
+		if len(due) < int(s.batchSize) {
+			return executed, nil
+		}
+	}
+}
+
+// Claims a scheduled transfer and runs it.
+// Returns false if it was claimed by another worker or the transfer failed.
+func (s *TransferScheduler) execute(ctx context.Context, id int64) (bool, error) {
+	now := time.Now()
+	st, err := s.store.ClaimScheduledTransfer(ctx, db.ClaimScheduledTransferParams{
+		ID:          id,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(s.lease), Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: st.FromAccountID,
+		ToAccountID:   st.ToAccountID,
+		Amount:        st.Amount,
+		Idempotency: &db.IdempotencyParams{
+			Username: st.Owner,
+			Key:      runKey(st),
+		},
+	})
+	edited := errors.Is(err, db.ErrIdempotencyKeyMismatch)
+	if edited {
+		result, err = s.previousRun(ctx, st)
+	}
+	if err != nil {
+		// Shutting down, the run is retried once the lease is over.
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, s.recordFailure(ctx, st, err)
+	}
+
+	// The decision of an edit assessed the amount of the next run,
+	// not the one of the transfer that went through before it.
+	decisionID := st.RiskDecisionID
+	if decisionID.Valid && !edited {
+		_, err = s.store.UpdateRiskDecisionTransfer(ctx, db.UpdateRiskDecisionTransferParams{
+			ID:         decisionID.Int64,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return false, err
+		}
+		decisionID = sql.NullInt64{}
+	}
+
+	return true, s.recordRun(ctx, st, result.Transfer, decisionID)
+}
+
+/**
+ * Loads the result of a run that went through but wasn't recorded,
+ * when the scheduled transfer was edited before the run was retried.
+ * The stored result can't be replayed with the edited request.
+ */
+func (s *TransferScheduler) previousRun(ctx context.Context, st db.ScheduledTransfer) (
+	db.TransferTxResult, error,
+) {
+	var result db.TransferTxResult
+
+	key, err := s.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: st.Owner,
+		Key:      runKey(st),
+	})
+	if err != nil {
+		return result, err
+	}
+	if err = json.Unmarshal(key.Response, &result); err != nil {
+		return result, err
+	}
+
+	log.Printf("scheduled transfer [%d] was edited after run %d, recording its transfer [%d]",
+		st.ID, st.RunCount, result.Transfer.ID,
+	)
+	return result, nil
+}
+
+// Moves a scheduled transfer to its next run, or completes it.
+// The risk decision is kept until it is linked to a transfer.
+func (s *TransferScheduler) recordRun(
+	ctx context.Context,
+	st db.ScheduledTransfer,
+	transfer db.Transfer,
+	riskDecisionID sql.NullInt64,
+) error {
+	status := db.ScheduleStatusActive
+	nextRunAt, recurring := st.NextRunAfter(time.Now())
+	if !recurring {
+		status = db.ScheduleStatusCompleted
+	}
+
+	_, err := s.store.RecordScheduledTransferRun(ctx, db.RecordScheduledTransferRunParams{
+		ID:             st.ID,
+		LockedUntil:    st.LockedUntil,
+		Status:         status,
+		NextRunAt:      nextRunAt,
+		LastTransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+		RiskDecisionID: riskDecisionID,
+	})
+	return ignoreLostLease(st, err)
+}
+
+// Schedules a retry of a failed run, or gives up after too many attempts.
+func (s *TransferScheduler) recordFailure(ctx context.Context, st db.ScheduledTransfer, runErr error) error {
+	attempts := st.Attempts + 1
+
+	status := db.ScheduleStatusActive
+	retryAt := sql.NullTime{Time: time.Now().Add(s.retry.delay(attempts)), Valid: true}
+	if attempts >= s.retry.MaxAttempts {
+		status = db.ScheduleStatusFailed
+		retryAt = sql.NullTime{}
+	}
+
+	_, err := s.store.RecordScheduledTransferFailure(ctx, db.RecordScheduledTransferFailureParams{
+		ID:          st.ID,
+		LockedUntil: st.LockedUntil,
+		Status:      status,
+		RetryAt:     retryAt,
+		LastError:   sql.NullString{String: runErr.Error(), Valid: true},
+	})
+	return ignoreLostLease(st, err)
+}
+
+// The lease ran out and another worker took the transfer over.
+// It replays the same run, so there is nothing left to record.
+func ignoreLostLease(st db.ScheduledTransfer, err error) error {
+	if err == sql.ErrNoRows {
+		log.Printf("lost the lease of scheduled transfer [%d]", st.ID)
+		return nil
+	}
+	return err
+}
+
+// Idempotency key of the next run of a scheduled transfer.
+func runKey(st db.ScheduledTransfer) string {
+	return fmt.Sprintf("%s%d:%d", db.ScheduledRunKeyPrefix, st.ID, st.RunCount)
+}
@@ -0,0 +1,239 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+func claimedTransfer(recurrence string, attempts int32) db.ScheduledTransfer {
+	return db.ScheduledTransfer{
+		ID:            7,
+		Owner:         "alice",
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+		Recurrence:    recurrence,
+		Status:        db.ScheduleStatusActive,
+		NextRunAt:     time.Now().Add(-time.Minute),
+		RunCount:      3,
+		Attempts:      attempts,
+		LockedUntil:   sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+}
+
+func TestRunDueScheduledTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	st := claimedTransfer(db.RecurrenceOnce, 0)
+	st.RiskDecisionID = sql.NullInt64{Int64: 5, Valid: true}
+
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ScheduledTransfer{{ID: st.ID}, {ID: 8}}, nil)
+
+	store.EXPECT().
+		ClaimScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(st, nil)
+
+	// Claimed by another worker in the meantime.
+	store.EXPECT().
+		ClaimScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			require.Equal(t, st.FromAccountID, arg.FromAccountID)
+			require.Equal(t, st.ToAccountID, arg.ToAccountID)
+			require.Equal(t, st.Amount, arg.Amount)
+			require.Equal(t, &db.IdempotencyParams{
+				Username: st.Owner,
+				Key:      "scheduled-transfer:7:3",
+			}, arg.Idempotency)
+			return db.TransferTxResult{Transfer: db.Transfer{ID: 42}}, nil
+		})
+
+	store.EXPECT().
+		UpdateRiskDecisionTransfer(gomock.Any(), gomock.Eq(db.UpdateRiskDecisionTransferParams{
+			ID:         5,
+			TransferID: sql.NullInt64{Int64: 42, Valid: true},
+		})).
+		Times(1)
+
+	store.EXPECT().
+		RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordScheduledTransferRunParams) (db.ScheduledTransfer, error) {
+			require.Equal(t, st.ID, arg.ID)
+			require.Equal(t, st.LockedUntil, arg.LockedUntil)
+			require.Equal(t, db.ScheduleStatusCompleted, arg.Status)
+			require.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, arg.LastTransferID)
+			require.False(t, arg.RiskDecisionID.Valid)
+			return db.ScheduledTransfer{}, nil
+		})
+
+	scheduler := NewTransferScheduler(store, 0, 10)
+	n, err := scheduler.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestRunDueScheduledTransfersEdited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	st := claimedTransfer(db.RecurrenceWeekly, 0)
+	st.RiskDecisionID = sql.NullInt64{Int64: 5, Valid: true}
+
+	store.EXPECT().
+		ListDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ScheduledTransfer{{ID: st.ID}}, nil)
+
+	store.EXPECT().
+		ClaimScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(st, nil)
+
+	// The run went through before a crash, and the amount was edited since.
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.TransferTxResult{}, db.ErrIdempotencyKeyMismatch)
+
+	response, err := json.Marshal(db.TransferTxResult{Transfer: db.Transfer{ID: 41}})
+	require.NoError(t, err)
+	store.EXPECT().
+		GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{
+			Username: st.Owner,
+			Key:      "scheduled-transfer:7:3",
+		})).
+		Times(1).
+		Return(db.IdempotencyKey{Response: response}, nil)
+
+	// The decision assessed the edit, it waits for the next run.
+	store.EXPECT().
+		UpdateRiskDecisionTransfer(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	var recorded db.RecordScheduledTransferRunParams
+	store.EXPECT().
+		RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordScheduledTransferRunParams) (db.ScheduledTransfer, error) {
+			recorded = arg
+			return db.ScheduledTransfer{}, nil
+		})
+
+	store.EXPECT().
+		RecordScheduledTransferFailure(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	scheduler := NewTransferScheduler(store, 0, 10)
+	n, err := scheduler.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.Equal(t, db.ScheduleStatusActive, recorded.Status)
+	require.Equal(t, sql.NullInt64{Int64: 41, Valid: true}, recorded.LastTransferID)
+	require.Equal(t, st.RiskDecisionID, recorded.RiskDecisionID)
+}
+
+func TestRunDueScheduledTransfersFailure(t *testing.T) {
+	testCases := []struct {
+		name      string
+		attempts  int32
+		status    string
+		retryable bool
+	}{
+		{
+			name:      "Retry",
+			attempts:  0,
+			status:    db.ScheduleStatusActive,
+			retryable: true,
+		},
+		{
+			name:      "OutOfAttempts",
+			attempts:  DefaultScheduleRetryPolicy.MaxAttempts - 1,
+			status:    db.ScheduleStatusFailed,
+			retryable: false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			st := claimedTransfer(db.RecurrenceWeekly, tc.attempts)
+
+			store.EXPECT().
+				ListDueScheduledTransfers(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return([]db.ScheduledTransfer{{ID: st.ID}}, nil)
+
+			store.EXPECT().
+				ClaimScheduledTransfer(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(st, nil)
+
+			store.EXPECT().
+				TransferTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+
+			store.EXPECT().
+				RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			store.EXPECT().
+				RecordScheduledTransferFailure(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordScheduledTransferFailureParams) (db.ScheduledTransfer, error) {
+					require.Equal(t, st.ID, arg.ID)
+					require.Equal(t, st.LockedUntil, arg.LockedUntil)
+					require.Equal(t, tc.status, arg.Status)
+					require.Equal(t, tc.retryable, arg.RetryAt.Valid)
+					require.Equal(t, db.ErrInsufficientFunds.Error(), arg.LastError.String)
+					return db.ScheduledTransfer{}, nil
+				})
+
+			scheduler := NewTransferScheduler(store, 0, 10)
+			n, err := scheduler.RunDue(context.Background())
+			require.NoError(t, err)
+			require.Zero(t, n)
+		})
+	}
+}
+
+func TestScheduleRetryPolicyDelay(t *testing.T) {
+	policy := ScheduleRetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   time.Minute,
+		MaxDelay:    5 * time.Minute,
+	}
+
+	require.Equal(t, time.Minute, policy.delay(1))
+	require.Equal(t, 2*time.Minute, policy.delay(2))
+	require.Equal(t, 4*time.Minute, policy.delay(3))
+	require.Equal(t, 5*time.Minute, policy.delay(4))
+	require.Equal(t, 5*time.Minute, policy.delay(9))
+}
@@ -0,0 +1,380 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+	"github.com/wiliamhw/simplebank/util"
+)
+
+// How long a dispatcher owns a delivery it is sending.
+// Another dispatcher may take it over once the lease is over.
+const defaultWebhookLease = time.Minute
+
+// How long an endpoint has to answer a delivery.
+const defaultWebhookTimeout = 10 * time.Second
+
+// Headers of a webhook request.
+const (
+	WebhookSignatureHeader  = "Simplebank-Signature"
+	WebhookEventIDHeader    = "Simplebank-Event-Id"
+	WebhookEventTypeHeader  = "Simplebank-Event-Type"
+	WebhookDeliveryIDHeader = "Simplebank-Delivery-Id"
+)
+
+// Controls how failed webhook deliveries are retried.
+type WebhookRetryPolicy struct {
+	MaxAttempts int32         // Failed attempts before the delivery is dead.
+	BaseDelay   time.Duration // Delay before the first retry, doubled after each failure.
+	MaxDelay    time.Duration // Upper bound of the delay before any retry.
+}
+
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    6 * time.Hour,
+}
+
+// Returns the delay before the retry that follows the given failed attempt (starting from 1).
+func (policy WebhookRetryPolicy) delay(attempt int32) time.Duration {
+	return backoff(policy.BaseDelay, policy.MaxDelay, attempt)
+}
+
+var (
+	errSubscriptionDisabled = errors.New("subscription is disabled")
+
+	// Returned when the URL of a subscription resolves to an address of our own network.
+	errWebhookAddressDenied = errors.New("endpoint address is not allowed")
+
+	// Returned when an endpoint answers with a redirect, which is not followed.
+	errWebhookRedirect = errors.New("endpoint redirected")
+)
+
+// Body of a webhook request.
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+/**
+ * Signs the body of a webhook request sent at timestamp.
+ * The signature is "t=<unix timestamp>,v1=<hex HMAC-SHA256>", where the
+ * HMAC of "<unix timestamp>.<body>" is keyed with the subscription secret.
+ * Receivers recompute it to authenticate the request, and check the
+ * timestamp to reject replays of old requests.
+ */
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+/**
+ * Delivers the events of the outbox to webhook subscriptions.
+ * New events are first fanned out to a delivery per subscription,
+ * then due deliveries are sent. A delivery is claimed with a lease
+ * before it is sent, so several dispatchers can run against the same
+ * database. Failed deliveries are retried with exponential backoff and
+ * become dead after too many attempts, until they are redelivered.
+ * Delivery is at least once: receivers should deduplicate on the event id.
+ */
+type WebhookDispatcher struct {
+	store     db.Store
+	client    *http.Client
+	interval  time.Duration
+	batchSize int32
+	lease     time.Duration
+	retry     WebhookRetryPolicy
+}
+
+func NewWebhookDispatcher(store db.Store, interval time.Duration, batchSize int32) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:     store,
+		client:    newWebhookClient(util.IsPublicIP),
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     defaultWebhookLease,
+		retry:     DefaultWebhookRetryPolicy,
+	}
+}
+
+/**
+ * Returns the client deliveries are sent with.
+ * Subscription URLs are chosen by users, so the client only connects to
+ * addresses allowed by allowIP, checked after the host is resolved so DNS
+ * can't sneak in an internal address, and it never follows redirects.
+ */
+func newWebhookClient(allowIP func(ip net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: defaultWebhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowIP(ip) {
+				return errWebhookAddressDenied
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: defaultWebhookTimeout,
+		// No proxy, it would be the one the address is checked against.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: defaultWebhookTimeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Dispatches events every interval until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := d.RunDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("cannot dispatch webhooks: %v", err)
+		} else if n > 0 {
+			log.Printf("delivered %d webhooks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Fans out new events, then sends every delivery that is due
+ * and returns how many were delivered successfully.
+ * Failed deliveries are recorded and retried later,
+ * only errors of the dispatcher itself are returned.
+ */
+func (d *WebhookDispatcher) RunDue(ctx context.Context) (int, error) {
+	for {
+		n, err := d.store.DispatchEventsTx(ctx, d.batchSize)
+		if err != nil {
+			return 0, err
+		}
+		if n < int(d.batchSize) {
+			break
+		}
+	}
+
+	delivered := 0
+	for {
+		due, err := d.store.ListDueWebhookDeliveries(ctx, db.ListDueWebhookDeliveriesParams{
+			Now:   time.Now(),
+			Limit: d.batchSize,
+		})
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, delivery := range due {
+			ok, err := d.deliver(ctx, delivery.ID)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+
+		if len(due) < int(d.batchSize) {
+			return delivered, nil
+		}
+	}
+}
+
+// Claims a delivery and sends it.
+// Returns false if it was claimed by another dispatcher or sending failed.
+func (d *WebhookDispatcher) deliver(ctx context.Context, id int64) (bool, error) {
+	now := time.Now()
+	delivery, err := d.store.ClaimWebhookDelivery(ctx, db.ClaimWebhookDeliveryParams{
+		ID:          id,
+		Now:         now,
+		LockedUntil: sql.NullTime{Time: now.Add(d.lease), Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event, err := d.store.GetOutboxEvent(ctx, delivery.EventID)
+	if err != nil {
+		return false, err
+	}
+	subscription, err := d.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err == sql.ErrNoRows {
+		// Deleted in the meantime, along with its deliveries.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !subscription.Enabled {
+		return false, d.recordFailure(ctx, delivery, 0, errSubscriptionDisabled, true)
+	}
+
+	status, err := d.send(ctx, subscription, delivery, event)
+	if err != nil {
+		// Shutting down, the delivery is retried once the lease is over.
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, d.recordFailure(ctx, delivery, status, err, false)
+	}
+
+	_, err = d.store.RecordWebhookDeliverySuccess(ctx, db.RecordWebhookDeliverySuccessParams{
+		ID:             delivery.ID,
+		LockedUntil:    delivery.LockedUntil,
+		ResponseStatus: sql.NullInt32{Int32: int32(status), Valid: true},
+	})
+	return true, ignoreLostDeliveryLease(delivery, err)
+}
+
+/**
+ * POSTs a signed event to the URL of a subscription.
+ * Returns the status of the response, zero if none was received,
+ * and an error unless the status is 2xx.
+ */
+func (d *WebhookDispatcher) send(
+	ctx context.Context,
+	subscription db.WebhookSubscription,
+	delivery db.WebhookDelivery,
+	event db.OutboxEvent,
+) (int, error) {
+	body, err := json.Marshal(WebhookEvent{
+		ID:        event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(subscription.Secret, time.Now(), body))
+	req.Header.Set(WebhookEventIDHeader, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(WebhookEventTypeHeader, event.EventType)
+	req.Header.Set(WebhookDeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+
+	rsp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	// Drained so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, 64<<10))
+
+	if rsp.StatusCode >= 300 && rsp.StatusCode <= 399 {
+		return rsp.StatusCode, errWebhookRedirect
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, fmt.Errorf("endpoint responded with status %d", rsp.StatusCode)
+	}
+	return rsp.StatusCode, nil
+}
+
+/**
+ * Describes why a delivery failed, for its owner to see.
+ * Errors of the HTTP client carry what the network told us about the
+ * endpoint, and so does its status, so only the kind of failure is kept.
+ */
+func deliveryError(status int, err error) string {
+	for _, known := range []error{errSubscriptionDisabled, errWebhookAddressDenied, errWebhookRedirect} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case status > 0:
+		return "endpoint responded with an error status"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "endpoint timed out"
+	default:
+		return "endpoint could not be reached"
+	}
+}
+
+// Schedules a retry of a failed delivery, or gives up after too many attempts.
+// Deliveries that can't succeed on a retry are given up right away.
+func (d *WebhookDispatcher) recordFailure(
+	ctx context.Context,
+	delivery db.WebhookDelivery,
+	status int,
+	sendErr error,
+	permanent bool,
+) error {
+	attempts := delivery.Attempts + 1
+
+	arg := db.RecordWebhookDeliveryFailureParams{
+		ID:            delivery.ID,
+		LockedUntil:   delivery.LockedUntil,
+		Status:        db.DeliveryStatusPending,
+		NextAttemptAt: time.Now().Add(d.retry.delay(attempts)),
+		LastError:     sql.NullString{String: deliveryError(status, sendErr), Valid: true},
+	}
+	if status > 0 {
+		arg.ResponseStatus = sql.NullInt32{Int32: int32(status), Valid: true}
+	}
+	if permanent || attempts >= d.retry.MaxAttempts {
+		arg.Status = db.DeliveryStatusDead
+		arg.NextAttemptAt = time.Now()
+	}
+
+	_, err := d.store.RecordWebhookDeliveryFailure(ctx, arg)
+	return ignoreLostDeliveryLease(delivery, err)
+}
+
+// The lease ran out and another dispatcher took the delivery over.
+// It sends the event again, so there is nothing left to record.
+func ignoreLostDeliveryLease(delivery db.WebhookDelivery, err error) error {
+	if err == sql.ErrNoRows {
+		log.Printf("lost the lease of webhook delivery [%d]", delivery.ID)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,330 @@
+package worker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/wiliamhw/simplebank/db/mock"
+	db "github.com/wiliamhw/simplebank/db/sqlc"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func claimedDelivery(attempts int32) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:             11,
+		EventID:        5,
+		SubscriptionID: 3,
+		Status:         db.DeliveryStatusPending,
+		Attempts:       attempts,
+		NextAttemptAt:  time.Now().Add(-time.Minute),
+		LockedUntil:    sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+}
+
+func outboxEvent() db.OutboxEvent {
+	return db.OutboxEvent{
+		ID:        5,
+		EventType: db.EventTransferCreated,
+		Usernames: []string{"alice", "bob"},
+		Payload:   json.RawMessage(`{"id":42,"amount":10}`),
+		CreatedAt: time.Now().Truncate(time.Second).UTC(),
+	}
+}
+
+// Stubs the store for a single due delivery sent to url.
+func buildDeliveryStubs(store *mockdb.MockStore, delivery db.WebhookDelivery, url string, enabled bool) {
+	store.EXPECT().
+		DispatchEventsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(1, nil)
+
+	store.EXPECT().
+		ListDueWebhookDeliveries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.WebhookDelivery{{ID: delivery.ID}}, nil)
+
+	store.EXPECT().
+		ClaimWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(delivery, nil)
+
+	store.EXPECT().
+		GetOutboxEvent(gomock.Any(), gomock.Eq(delivery.EventID)).
+		Times(1).
+		Return(outboxEvent(), nil)
+
+	store.EXPECT().
+		GetWebhookSubscription(gomock.Any(), gomock.Eq(delivery.SubscriptionID)).
+		Times(1).
+		Return(db.WebhookSubscription{
+			ID:      delivery.SubscriptionID,
+			Owner:   "alice",
+			Url:     url,
+			Secret:  testWebhookSecret,
+			Enabled: enabled,
+		}, nil)
+}
+
+// The test endpoints listen on loopback, which the dispatcher refuses to reach.
+func newTestWebhookDispatcher(store db.Store) *WebhookDispatcher {
+	dispatcher := NewWebhookDispatcher(store, 0, 10)
+	dispatcher.client = newWebhookClient(func(net.IP) bool { return true })
+	return dispatcher
+}
+
+func TestRunDueWebhookDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	delivery := claimedDelivery(0)
+	event := outboxEvent()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "5", r.Header.Get(WebhookEventIDHeader))
+		require.Equal(t, db.EventTransferCreated, r.Header.Get(WebhookEventTypeHeader))
+		require.Equal(t, "11", r.Header.Get(WebhookDeliveryIDHeader))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requireValidSignature(t, r.Header.Get(WebhookSignatureHeader), body)
+
+		var got WebhookEvent
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Equal(t, event.ID, got.ID)
+		require.Equal(t, event.EventType, got.Type)
+		require.WithinDuration(t, event.CreatedAt, got.CreatedAt, 0)
+		require.JSONEq(t, string(event.Payload), string(got.Data))
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	buildDeliveryStubs(store, delivery, server.URL, true)
+
+	store.EXPECT().
+		RecordWebhookDeliveryFailure(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	store.EXPECT().
+		RecordWebhookDeliverySuccess(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliverySuccessParams) (db.WebhookDelivery, error) {
+			require.Equal(t, delivery.ID, arg.ID)
+			require.Equal(t, delivery.LockedUntil, arg.LockedUntil)
+			require.Equal(t, sql.NullInt32{Int32: http.StatusNoContent, Valid: true}, arg.ResponseStatus)
+			return db.WebhookDelivery{}, nil
+		})
+
+	dispatcher := newTestWebhookDispatcher(store)
+	n, err := dispatcher.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestRunDueWebhookDeliveriesFailure(t *testing.T) {
+	testCases := []struct {
+		name           string
+		attempts       int32
+		enabled        bool
+		status         string
+		responseStatus sql.NullInt32
+	}{
+		{
+			name:           "Retry",
+			attempts:       0,
+			enabled:        true,
+			status:         db.DeliveryStatusPending,
+			responseStatus: sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true},
+		},
+		{
+			name:           "OutOfAttempts",
+			attempts:       DefaultWebhookRetryPolicy.MaxAttempts - 1,
+			enabled:        true,
+			status:         db.DeliveryStatusDead,
+			responseStatus: sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true},
+		},
+		{
+			name:     "SubscriptionDisabled",
+			attempts: 0,
+			enabled:  false,
+			status:   db.DeliveryStatusDead,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			delivery := claimedDelivery(tc.attempts)
+
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
+
+			buildDeliveryStubs(store, delivery, server.URL, tc.enabled)
+
+			store.EXPECT().
+				RecordWebhookDeliverySuccess(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			store.EXPECT().
+				RecordWebhookDeliveryFailure(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryFailureParams) (db.WebhookDelivery, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					require.Equal(t, delivery.LockedUntil, arg.LockedUntil)
+					require.Equal(t, tc.status, arg.Status)
+					require.Equal(t, tc.responseStatus, arg.ResponseStatus)
+					require.True(t, arg.LastError.Valid)
+					if tc.status == db.DeliveryStatusPending {
+						delay := DefaultWebhookRetryPolicy.delay(tc.attempts + 1)
+						require.WithinDuration(t, time.Now().Add(delay), arg.NextAttemptAt, time.Second)
+					}
+					return db.WebhookDelivery{}, nil
+				})
+
+			dispatcher := newTestWebhookDispatcher(store)
+			n, err := dispatcher.RunDue(context.Background())
+			require.NoError(t, err)
+			require.Zero(t, n)
+
+			if tc.enabled {
+				require.Equal(t, 1, requests)
+			} else {
+				require.Zero(t, requests)
+			}
+		})
+	}
+}
+
+func TestRunDueWebhookDeliveriesDenied(t *testing.T) {
+	testCases := []struct {
+		name       string
+		redirect   bool
+		dispatcher func(store db.Store) *WebhookDispatcher
+		lastError  string
+	}{
+		{
+			name:       "InternalAddress",
+			dispatcher: func(store db.Store) *WebhookDispatcher { return NewWebhookDispatcher(store, 0, 10) },
+			lastError:  errWebhookAddressDenied.Error(),
+		},
+		{
+			name:       "Redirect",
+			redirect:   true,
+			dispatcher: newTestWebhookDispatcher,
+			lastError:  errWebhookRedirect.Error(),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			delivery := claimedDelivery(0)
+
+			// Stands for a service of our own network.
+			internalRequests := 0
+			internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				internalRequests++
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer internal.Close()
+
+			url := internal.URL
+			if tc.redirect {
+				redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+				}))
+				defer redirect.Close()
+				url = redirect.URL
+			}
+
+			buildDeliveryStubs(store, delivery, url, true)
+
+			store.EXPECT().
+				RecordWebhookDeliverySuccess(gomock.Any(), gomock.Any()).
+				Times(0)
+
+			var failure db.RecordWebhookDeliveryFailureParams
+			store.EXPECT().
+				RecordWebhookDeliveryFailure(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.RecordWebhookDeliveryFailureParams) (db.WebhookDelivery, error) {
+					failure = arg
+					return db.WebhookDelivery{}, nil
+				})
+
+			n, err := tc.dispatcher(store).RunDue(context.Background())
+			require.NoError(t, err)
+			require.Zero(t, n)
+
+			require.Zero(t, internalRequests)
+			require.Equal(t, sql.NullString{String: tc.lastError, Valid: true}, failure.LastError)
+		})
+	}
+}
+
+func TestDeliveryError(t *testing.T) {
+	require.Equal(t, "endpoint responded with an error status", deliveryError(http.StatusNotFound, errors.New("endpoint responded with status 404")))
+	require.Equal(t, errWebhookAddressDenied.Error(), deliveryError(0, fmt.Errorf("dial tcp 10.0.0.1:80: %w", errWebhookAddressDenied)))
+
+	// The error of the client is not passed on.
+	err := errors.New("dial tcp 10.0.0.1:6379: connect: connection refused")
+	require.Equal(t, "endpoint could not be reached", deliveryError(0, err))
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(`1700000000.{"id":1}`))
+	expected := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	require.Equal(t, expected, SignWebhook(testWebhookSecret, timestamp, body))
+	require.NotEqual(t, expected, SignWebhook("other", timestamp, body))
+}
+
+// Checks a signature the way a receiver would.
+func requireValidSignature(t *testing.T, signature string, body []byte) {
+	parts := strings.Split(signature, ",")
+	require.Len(t, parts, 2)
+	require.True(t, strings.HasPrefix(parts[0], "t="))
+	require.True(t, strings.HasPrefix(parts[1], "v1="))
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(strings.TrimPrefix(parts[0], "t=") + "."))
+	mac.Write(body)
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), strings.TrimPrefix(parts[1], "v1="))
+}